@@ -0,0 +1,76 @@
+// Copyright 2026 Tamás Gulácsi.
+//
+// SPDX-License-Identifier: MIT
+
+package binarysearch
+
+// LowerBound returns the first index i in the sorted s where cmp(s[i], target) >= 0,
+// or len(s) if there is no such element.
+func LowerBound[S ~[]E, E, T any](s S, target T, cmp func(E, T) int) int {
+	return Search(len(s), func(i int) bool { return cmp(s[i], target) >= 0 })
+}
+
+// UpperBound returns the first index i in the sorted s where cmp(s[i], target) > 0,
+// or len(s) if there is no such element.
+func UpperBound[S ~[]E, E, T any](s S, target T, cmp func(E, T) int) int {
+	return Search(len(s), func(i int) bool { return cmp(s[i], target) > 0 })
+}
+
+// EqualRange returns the [lo, hi) range of the elements of the sorted s which equal to target.
+func EqualRange[S ~[]E, E, T any](s S, target T, cmp func(E, T) int) (lo, hi int) {
+	lo = LowerBound(s, target, cmp)
+	return lo, lo + UpperBound(s[lo:], target, cmp)
+}
+
+// Exponential (galloping) search returns the smallest i >= 0 at which f(i) is true,
+// assuming that f(i) == true implies f(i+1) == true, and f is true for some i.
+//
+// It probes 0, 2, 6, 14, 30... (2^k - 2) then searches in the last range,
+// so it needs O(log i) calls - good for unbounded sequences,
+// or when the result is expected near the start.
+func Exponential(f func(int) bool) int {
+	lo, hi := 0, 1
+	for !f(hi - 1) {
+		lo, hi = hi, 2*hi+1
+	}
+	return lo + Search(hi-1-lo, func(i int) bool { return f(lo + i) })
+}
+
+// Number is the constraint of Interpolation.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Interpolation search returns the first index i in the sorted s where s[i] >= target,
+// or len(s) if there is no such element.
+//
+// It guesses the position from the values at the range ends, so needs
+// O(log log n) steps for uniformly distributed keys; it alternates with bisection
+// steps so the worst case is O(log n).
+func Interpolation[S ~[]E, E Number](s S, target E) int {
+	// the answer is in [lo, hi]
+	lo, hi := 0, len(s)
+	for bisect := false; lo < hi; bisect = !bisect {
+		var mid int
+		if a, b := s[lo], s[hi-1]; bisect || b <= a || target <= a || target > b {
+			if target <= a {
+				return lo
+			}
+			if target > b {
+				return hi
+			}
+			mid = lo + (hi-lo)/2
+		} else {
+			mid = lo + int(float64(hi-1-lo)*(float64(target)-float64(a))/(float64(b)-float64(a)))
+			mid = min(max(mid, lo), hi-1)
+		}
+		if s[mid] < target {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
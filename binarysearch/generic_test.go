@@ -0,0 +1,242 @@
+// Copyright 2026 Tamás Gulácsi.
+//
+// SPDX-License-Identifier: MIT
+
+package binarysearch
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/tgulacsi/go/iohlp"
+)
+
+func TestBounds(t *testing.T) {
+	for _, x := range []int{-20, -10, -7, 0, 2, 4, 100, 101, 10000, 20000} {
+		lo, hi := EqualRange(data, x, cmp.Compare[int])
+		wantLo := sort.SearchInts(data, x)
+		wantHi := sort.SearchInts(data, x+1)
+		if lo != wantLo || hi != wantHi {
+			t.Errorf("EqualRange(%d): got [%d, %d), wanted [%d, %d)", x, lo, hi, wantLo, wantHi)
+		}
+		if got := LowerBound(data, x, cmp.Compare[int]); got != wantLo {
+			t.Errorf("LowerBound(%d): got %d, wanted %d", x, got, wantLo)
+		}
+		if got := UpperBound(data, x, cmp.Compare[int]); got != wantHi {
+			t.Errorf("UpperBound(%d): got %d, wanted %d", x, got, wantHi)
+		}
+		if got := Interpolation(data, x); got != wantLo {
+			t.Errorf("Interpolation(%d): got %d, wanted %d", x, got, wantLo)
+		}
+	}
+	if lo, hi := EqualRange([]string(nil), "a", cmp.Compare[string]); lo != 0 || hi != 0 {
+		t.Errorf("empty: got [%d, %d)", lo, hi)
+	}
+
+	type rec struct {
+		Name string
+		Age  int
+	}
+	recs := []rec{{"a", 1}, {"b", 2}, {"c", 2}, {"d", 3}}
+	if lo, hi := EqualRange(recs, 2, func(r rec, age int) int { return cmp.Compare(r.Age, age) }); lo != 1 || hi != 3 {
+		t.Errorf("recs: got [%d, %d), wanted [1, 3)", lo, hi)
+	}
+}
+
+func TestExponential(t *testing.T) {
+	for _, want := range []int{0, 1, 2, 3, 4, 7, 8, 1000, 1 << 20} {
+		var calls int
+		if got := Exponential(func(i int) bool { calls++; return i >= want }); got != want {
+			t.Errorf("%d: got %d", want, got)
+		}
+		t.Logf("%d: %d calls", want, calls)
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for _, tC := range []struct {
+		Name string
+		Gen  func(i int) float64
+	}{
+		{"uniform", func(i int) float64 { return float64(i) * 3 }},
+		{"random", func(int) float64 { return rnd.Float64() }},
+		{"exponential", func(i int) float64 { return float64(int64(1) << (i % 60)) }},
+		{"constant", func(int) float64 { return 42 }},
+	} {
+		t.Run(tC.Name, func(t *testing.T) {
+			s := make([]float64, 1000)
+			for i := range s {
+				s[i] = tC.Gen(i)
+			}
+			slices.Sort(s)
+			for _, x := range append([]float64{-1, 0, 42, 1e30}, everyNth(s, 100)...) {
+				want, _ := slices.BinarySearch(s, x)
+				if got := Interpolation(s, x); got != want {
+					t.Errorf("%g: got %d, wanted %d", x, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchRecords(t *testing.T) {
+	const width = 8
+	var buf bytes.Buffer
+	for i := range 1000 {
+		fmt.Fprintf(&buf, "%07d\n", 2*i)
+	}
+	fh := writeTemp(t, buf.Bytes())
+	ra, err := iohlp.Mmap(fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ra.Close()
+	for _, x := range []int{-1, 0, 1, 2, 999, 1000, 1998, 1999, 3000} {
+		want := int64(min(1000, max(0, (x+1)/2)))
+		key := []byte(fmt.Sprintf("%07d", x))
+		got, err := SearchRecords(ra, int64(ra.Len()), width, func(rec []byte) bool {
+			return bytes.Compare(rec[:width-1], key) >= 0
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x < 0 {
+			want = 0
+		}
+		if got != want {
+			t.Errorf("%d: got %d, wanted %d", x, got, want)
+		}
+	}
+	if _, err := SearchRecords(bytes.NewReader(buf.Bytes()), int64(buf.Len())+width, width, func([]byte) bool { return false }); err == nil {
+		t.Error("short reader: no error")
+	}
+}
+
+func TestSearchLines(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(3, 4))
+	var lines []string
+	for range 1000 {
+		lines = append(lines, strconv.Itoa(rnd.IntN(1_000_000))+string(bytes.Repeat([]byte{'x'}, rnd.IntN(1000))))
+	}
+	slices.Sort(lines)
+	offsets := make([]int64, len(lines)+1)
+	var buf bytes.Buffer
+	for i, line := range lines {
+		offsets[i] = int64(buf.Len())
+		buf.WriteString(line)
+		if i < len(lines)-1 {
+			buf.WriteByte('\n')
+		}
+	}
+	offsets[len(lines)] = int64(buf.Len())
+	fh := writeTemp(t, buf.Bytes())
+	ra, err := iohlp.Mmap(fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ra.Close()
+
+	for _, key := range append([]string{"", "0", "5", "999999", "a"}, everyNth(lines, 97)...) {
+		i, _ := slices.BinarySearch(lines, key)
+		got, err := SearchLines(ra, int64(ra.Len()), func(line []byte) bool { return string(line) >= key })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != offsets[i] {
+			t.Errorf("%q: got %d, wanted %d (line %d)", key, got, offsets[i], i)
+		}
+	}
+
+	for _, tC := range []struct {
+		Text string
+		Key  string
+		Want int64
+	}{
+		{"", "a", 0},
+		{"\n", "", 0},
+		{"\n", "a", 1},
+		{"a\nb\nc\n", "b", 2},
+		{"a\nb\nc\n", "c", 4},
+		{"a\nb\nc\n", "d", 6},
+		{"a\n\nb", "b", 3},
+	} {
+		got, err := SearchLines(bytes.NewReader([]byte(tC.Text)), int64(len(tC.Text)),
+			func(line []byte) bool { return string(line) >= tC.Key })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tC.Want {
+			t.Errorf("%q in %q: got %d, wanted %d", tC.Key, tC.Text, got, tC.Want)
+		}
+	}
+}
+
+func writeTemp(t *testing.T, data []byte) *os.File {
+	t.Helper()
+	fn := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(fn, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fh, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fh.Close() })
+	return fh
+}
+
+func benchData() []int {
+	rnd := rand.New(rand.NewPCG(5, 6))
+	s := make([]int, 1<<20)
+	for i := range s {
+		s[i] = rnd.IntN(1 << 30)
+	}
+	slices.Sort(s)
+	return s
+}
+
+func BenchmarkBinarySearchFunc(b *testing.B) {
+	s := benchData()
+	var i, sum int
+	for b.Loop() {
+		j, _ := slices.BinarySearchFunc(s, s[i&(len(s)-1)], cmp.Compare[int])
+		sum += j
+		i += 7919
+	}
+	_ = sum
+}
+
+func BenchmarkLowerBound(b *testing.B) {
+	s := benchData()
+	var i int
+	for b.Loop() {
+		LowerBound(s, s[i&(len(s)-1)], cmp.Compare[int])
+		i += 7919
+	}
+}
+
+func BenchmarkInterpolation(b *testing.B) {
+	s := benchData()
+	var i int
+	for b.Loop() {
+		Interpolation(s, s[i&(len(s)-1)])
+		i += 7919
+	}
+}
+
+func everyNth[S ~[]E, E any](s S, n int) S {
+	var res S
+	for i := 0; i < len(s); i += n {
+		res = append(res, s[i])
+	}
+	return res
+}
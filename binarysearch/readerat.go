@@ -0,0 +1,108 @@
+// Copyright 2026 Tamás Gulácsi.
+//
+// SPDX-License-Identifier: MIT
+
+package binarysearch
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// SearchRecords searches in the sorted, fixed width records of r (of size bytes; such as a file mmapped with iohlp.Mmap),
+// and returns the smallest record index i at which f(record) is true,
+// or size/width if there is no such record.
+//
+// The record passed to f is valid only during the call.
+func SearchRecords(r io.ReaderAt, size int64, width int, f func(record []byte) bool) (int64, error) {
+	if width <= 0 {
+		return 0, errors.New("width must be positive")
+	}
+	buf := make([]byte, width)
+	var err error
+	i := Search(int(size/int64(width)), func(i int) bool {
+		if err != nil {
+			return true
+		}
+		var n int
+		if n, err = r.ReadAt(buf, int64(i)*int64(width)); n == width {
+			err = nil
+		} else if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err != nil || f(buf)
+	})
+	return int64(i), err
+}
+
+// SearchLines searches in the sorted newline-delimited text in r (of size bytes),
+// and returns the offset of the first line for which f(line) is true, or size if there is no such line.
+//
+// The line passed to f (without the newline) is valid only during the call.
+func SearchLines(r io.ReaderAt, size int64, f func(line []byte) bool) (int64, error) {
+	lr := lineReader{r: r, size: size, buf: make([]byte, 0, 512)}
+	var err error
+	// Search over the byte positions: p is mapped to the line starting at or after it.
+	p := Search(int(size), func(p int) bool {
+		if err != nil {
+			return true
+		}
+		var line []byte
+		var start int64
+		if start, line, err = lr.lineAfter(int64(p)); err != nil || start >= size {
+			return true
+		}
+		return f(line)
+	})
+	if err != nil {
+		return 0, err
+	}
+	start, _, err := lr.lineAfter(int64(p))
+	return start, err
+}
+
+type lineReader struct {
+	r    io.ReaderAt
+	buf  []byte
+	size int64
+}
+
+// lineAfter returns the start of the first line starting at or after p, and the line.
+func (lr *lineReader) lineAfter(p int64) (int64, []byte, error) {
+	start := p
+	if p > 0 {
+		// the line starts after the first '\n' at or after p-1
+		line, err := lr.readLine(p - 1)
+		if err != nil {
+			return 0, nil, err
+		}
+		start = p - 1 + int64(len(line)) + 1
+	}
+	if start >= lr.size {
+		return lr.size, nil, nil
+	}
+	line, err := lr.readLine(start)
+	return start, line, err
+}
+
+// readLine returns the bytes from off till the next '\n' (exclusive) or the end.
+func (lr *lineReader) readLine(off int64) ([]byte, error) {
+	lr.buf = lr.buf[:0]
+	var chunk [512]byte
+	for off < lr.size {
+		n, err := lr.r.ReadAt(chunk[:min(int64(len(chunk)), lr.size-off)], off)
+		if i := bytes.IndexByte(chunk[:n], '\n'); i >= 0 {
+			return append(lr.buf, chunk[:i]...), nil
+		}
+		lr.buf = append(lr.buf, chunk[:n]...)
+		off += int64(n)
+		if err == io.EOF && off < lr.size {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+	return lr.buf, nil
+}
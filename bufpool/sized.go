@@ -0,0 +1,301 @@
+/*
+  Copyright 2026 Tamás Gulácsi
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+*/
+
+package bufpool
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/bits"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/tgulacsi/go/promhlp"
+)
+
+// SizedOptions are the options of NewSized.
+type SizedOptions struct {
+	// OnError is called with the errors found in Debug mode; panics if nil.
+	OnError func(error)
+	// Name is the "pool" label of the metrics.
+	Name string
+	// MinSize and MaxSize are the smallest and the largest size classes,
+	// rounded up to powers of two (defaults: 64 and 1MiB).
+	MinSize, MaxSize int
+	// Debug mode catches double Puts and uses after Put,
+	// by poisoning the returned buffers and capturing the stack of each Put.
+	// This is slow, use it only in tests!
+	Debug bool
+}
+
+// Sized is a size-classed pool of []byte and *bytes.Buffer:
+// each class holds buffers of a power of two capacity, between MinSize and MaxSize.
+type Sized struct {
+	onError  func(error)
+	name     string
+	classes  []sizeClass
+	minShift int
+	oversize atomic.Uint64
+	debug    bool
+}
+
+// sizeClass is the pool of the buffers of the same capacity.
+type sizeClass struct {
+	pool sync.Pool
+	// free and putStack are used instead of pool in Debug mode.
+	free     []unsafe.Pointer
+	putStack map[unsafe.Pointer][]uintptr
+	mu       sync.Mutex
+	size     int
+
+	hits, misses, puts, drops atomic.Uint64
+}
+
+// ErrDoublePut is returned (through OnError) when a buffer is Put twice.
+var ErrDoublePut = errors.New("buffer is Put twice")
+
+// ErrUseAfterPut is returned (through OnError) when a buffer is modified after Put.
+var ErrUseAfterPut = errors.New("buffer is used after Put")
+
+// maxDebugFree is the maximal number of free buffers per class in Debug mode.
+const maxDebugFree = 1024
+
+const poison = 0xa5
+
+// DefaultSized is the default size-classed pool.
+var DefaultSized = NewSized(SizedOptions{Name: "default"})
+
+// NewSized returns a new size-classed pool.
+func NewSized(opts SizedOptions) *Sized {
+	if opts.MinSize <= 0 {
+		opts.MinSize = 64
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 1 << 20
+	}
+	opts.MaxSize = max(opts.MinSize, opts.MaxSize)
+	minShift, maxShift := ceilLog2(opts.MinSize), ceilLog2(opts.MaxSize)
+	p := Sized{
+		name: opts.Name, onError: opts.OnError, debug: opts.Debug,
+		minShift: minShift, classes: make([]sizeClass, maxShift-minShift+1),
+	}
+	for i := range p.classes {
+		c := &p.classes[i]
+		c.size = 1 << (minShift + i)
+		if p.debug {
+			c.putStack = make(map[unsafe.Pointer][]uintptr)
+		}
+	}
+	return &p
+}
+
+func ceilLog2(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	return bits.Len(uint(n - 1))
+}
+
+// class returns the class for a buffer of n bytes, nil if it is too large.
+func (p *Sized) class(n int) *sizeClass {
+	i := max(0, ceilLog2(n)-p.minShift)
+	if i >= len(p.classes) {
+		return nil
+	}
+	return &p.classes[i]
+}
+
+// GetBytes returns a slice of length n, with a capacity of the size class.
+// The contents of the slice are undefined.
+func (p *Sized) GetBytes(n int) []byte {
+	c := p.class(n)
+	if c == nil {
+		p.oversize.Add(1)
+		return make([]byte, n)
+	}
+	var ptr unsafe.Pointer
+	if p.debug {
+		ptr = p.debugGet(c)
+	} else if v := c.pool.Get(); v != nil {
+		ptr = v.(unsafe.Pointer)
+	}
+	if ptr == nil {
+		c.misses.Add(1)
+		return make([]byte, n, c.size)
+	}
+	c.hits.Add(1)
+	return unsafe.Slice((*byte)(ptr), c.size)[:n]
+}
+
+// PutBytes returns the slice to the pool.
+// Slices with a capacity out of the size classes are dropped.
+// The slice must not be used after this call!
+func (p *Sized) PutBytes(b []byte) {
+	n := cap(b)
+	if n < p.classes[0].size {
+		return
+	}
+	// the largest class which fits in the capacity
+	i := bits.Len(uint(n)) - 1 - p.minShift
+	if i >= len(p.classes) {
+		return
+	}
+	c := &p.classes[i]
+	ptr := unsafe.Pointer(unsafe.SliceData(b[:1]))
+	c.puts.Add(1)
+	if p.debug {
+		p.debugPut(c, ptr, b[:c.size])
+		return
+	}
+	c.pool.Put(ptr)
+}
+
+// GetBuffer returns an empty bytes.Buffer with at least n bytes capacity.
+func (p *Sized) GetBuffer(n int) *bytes.Buffer {
+	return bytes.NewBuffer(p.GetBytes(n)[:0])
+}
+
+// PutBuffer returns the buffer's underlying slice to the pool.
+// The buffer must not be used after this call!
+func (p *Sized) PutBuffer(buf *bytes.Buffer) {
+	if buf == nil {
+		return
+	}
+	buf.Reset()
+	p.PutBytes(buf.Bytes())
+}
+
+// Get implements Pool, returns a buffer of the smallest size class.
+func (p *Sized) Get() *bytes.Buffer { return p.GetBuffer(p.classes[0].size) }
+
+// Put implements Pool.
+func (p *Sized) Put(buf *bytes.Buffer) { p.PutBuffer(buf) }
+
+var _ Pool = (*Sized)(nil)
+
+func (p *Sized) debugGet(c *sizeClass) unsafe.Pointer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.free) == 0 {
+		return nil
+	}
+	ptr := c.free[len(c.free)-1]
+	c.free = c.free[:len(c.free)-1]
+	stack := c.putStack[ptr]
+	delete(c.putStack, ptr)
+	for i, b := range unsafe.Slice((*byte)(ptr), c.size) {
+		if b != poison {
+			p.report(fmt.Errorf("%w: byte %d of the %d-sized buffer %p is %#x; Put at\n%s",
+				ErrUseAfterPut, i, c.size, ptr, b, formatStack(stack)))
+			break
+		}
+	}
+	return ptr
+}
+
+func (p *Sized) debugPut(c *sizeClass, ptr unsafe.Pointer, b []byte) {
+	stack := make([]uintptr, 32)
+	stack = stack[:runtime.Callers(3, stack)]
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if prev, ok := c.putStack[ptr]; ok {
+		p.report(fmt.Errorf("%w: %d-sized buffer %p, first Put at\n%s\nthen at\n%s",
+			ErrDoublePut, c.size, ptr, formatStack(prev), formatStack(stack)))
+		return
+	}
+	if len(c.free) >= maxDebugFree {
+		c.drops.Add(1)
+		return
+	}
+	for i := range b {
+		b[i] = poison
+	}
+	c.putStack[ptr] = stack
+	c.free = append(c.free, ptr)
+}
+
+func (p *Sized) report(err error) {
+	if p.onError == nil {
+		panic(err)
+	}
+	p.onError(err)
+}
+
+func formatStack(stack []uintptr) string {
+	var buf strings.Builder
+	frames := runtime.CallersFrames(stack)
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(&buf, "\t%s\n\t\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			break
+		}
+	}
+	return buf.String()
+}
+
+// ClassStats are the counters of a size class.
+type ClassStats struct {
+	Size int
+	// Hits is the number of Gets served from the pool, Misses is the number of Gets which allocated.
+	Hits, Misses uint64
+	// Puts is the number of Puts, Drops the number of Puts which dropped the buffer.
+	Puts, Drops uint64
+}
+
+// Stats returns the counters of the size classes.
+func (p *Sized) Stats() []ClassStats {
+	stats := make([]ClassStats, len(p.classes))
+	for i := range p.classes {
+		c := &p.classes[i]
+		stats[i] = ClassStats{
+			Size: c.size,
+			Hits: c.hits.Load(), Misses: c.misses.Load(),
+			Puts: c.puts.Load(), Drops: c.drops.Load(),
+		}
+	}
+	return stats
+}
+
+var _ promhlp.Collector = (*Sized)(nil)
+
+// Collect implements promhlp.Collector.
+func (p *Sized) Collect() []promhlp.Metric {
+	gets := promhlp.Metric{Name: "bufpool_gets_total", Help: "Number of Gets, by size class and result.", Type: "counter"}
+	puts := promhlp.Metric{Name: "bufpool_puts_total", Help: "Number of Puts, by size class and result.", Type: "counter"}
+	allocs := promhlp.Metric{Name: "bufpool_allocated_bytes_total", Help: "Bytes allocated by the missed Gets.", Type: "counter"}
+	for _, s := range p.Stats() {
+		class := strconv.Itoa(s.Size)
+		gets.Samples = append(gets.Samples,
+			promhlp.Sample{Labels: []string{"pool", p.name, "class", class, "result", "hit"}, Value: float64(s.Hits)},
+			promhlp.Sample{Labels: []string{"pool", p.name, "class", class, "result", "miss"}, Value: float64(s.Misses)},
+		)
+		puts.Samples = append(puts.Samples,
+			promhlp.Sample{Labels: []string{"pool", p.name, "class", class, "result", "kept"}, Value: float64(s.Puts - s.Drops)},
+			promhlp.Sample{Labels: []string{"pool", p.name, "class", class, "result", "dropped"}, Value: float64(s.Drops)},
+		)
+		allocs.Samples = append(allocs.Samples,
+			promhlp.Sample{Labels: []string{"pool", p.name, "class", class}, Value: float64(s.Misses) * float64(s.Size)})
+	}
+	gets.Samples = append(gets.Samples,
+		promhlp.Sample{Labels: []string{"pool", p.name, "class", "oversize", "result", "miss"}, Value: float64(p.oversize.Load())})
+	return []promhlp.Metric{gets, puts, allocs}
+}
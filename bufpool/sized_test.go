@@ -0,0 +1,139 @@
+/*
+  Copyright 2026 Tamás Gulácsi
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+*/
+
+package bufpool_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tgulacsi/go/bufpool"
+	"github.com/tgulacsi/go/promhlp"
+)
+
+func TestSizedClasses(t *testing.T) {
+	p := bufpool.NewSized(bufpool.SizedOptions{MinSize: 100, MaxSize: 1000, Debug: true})
+	for _, tC := range []struct {
+		n, cap int
+	}{{0, 128}, {1, 128}, {128, 128}, {129, 256}, {1000, 1024}, {1025, 1025}} {
+		b := p.GetBytes(tC.n)
+		if len(b) != tC.n || cap(b) != tC.cap {
+			t.Errorf("GetBytes(%d): got %d/%d, wanted %d/%d", tC.n, len(b), cap(b), tC.n, tC.cap)
+		}
+		p.PutBytes(b)
+	}
+	// reuse
+	b := p.GetBytes(200)
+	b[0] = 1
+	p.PutBytes(b)
+	if b2 := p.GetBytes(150); &b2[0] != &b[0] {
+		t.Error("buffer is not reused")
+	}
+	// foreign slice, too small for its class
+	p.PutBytes(make([]byte, 300))
+	if b := p.GetBytes(256); cap(b) != 256 {
+		t.Errorf("got cap %d", cap(b))
+	}
+
+	buf := p.GetBuffer(10)
+	buf.WriteString(strings.Repeat("x", 500))
+	p.PutBuffer(buf)
+
+	var hits, misses uint64
+	for _, s := range p.Stats() {
+		hits, misses = hits+s.Hits, misses+s.Misses
+	}
+	if hits < 2 || misses == 0 {
+		t.Errorf("hits=%d misses=%d", hits, misses)
+	}
+}
+
+func TestSizedDebug(t *testing.T) {
+	var errs []error
+	p := bufpool.NewSized(bufpool.SizedOptions{Debug: true, OnError: func(err error) { errs = append(errs, err) }})
+
+	b := p.GetBytes(100)
+	p.PutBytes(b)
+	p.PutBytes(b)
+	if len(errs) != 1 || !errors.Is(errs[0], bufpool.ErrDoublePut) {
+		t.Fatalf("double Put: got %v", errs)
+	}
+	if !strings.Contains(errs[0].Error(), "TestSizedDebug") {
+		t.Errorf("no stack: %v", errs[0])
+	}
+
+	errs = errs[:0]
+	b[10] = 'x'
+	p.GetBytes(100)
+	if len(errs) != 1 || !errors.Is(errs[0], bufpool.ErrUseAfterPut) {
+		t.Fatalf("use after Put: got %v", errs)
+	}
+	t.Log(errs[0])
+}
+
+func TestSizedCollect(t *testing.T) {
+	p := bufpool.NewSized(bufpool.SizedOptions{Name: "test", MinSize: 64, MaxSize: 128})
+	p.PutBytes(p.GetBytes(64))
+	var buf strings.Builder
+	if err := promhlp.WriteText(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	t.Log(buf.String())
+	for _, want := range []string{
+		"# TYPE bufpool_gets_total counter\n",
+		`bufpool_gets_total{pool="test",class="64",result="miss"} 1` + "\n",
+		`bufpool_puts_total{pool="test",class="64",result="kept"} 1` + "\n",
+		`bufpool_allocated_bytes_total{pool="test",class="128"} 0` + "\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
+
+func TestSizedCollectTwo(t *testing.T) {
+	p1 := bufpool.NewSized(bufpool.SizedOptions{Name: "one", MinSize: 64, MaxSize: 64})
+	p2 := bufpool.NewSized(bufpool.SizedOptions{Name: "tw\"o\t", MinSize: 64, MaxSize: 64})
+	var buf strings.Builder
+	if err := promhlp.WriteText(&buf, p1, p2); err != nil {
+		t.Fatal(err)
+	}
+	t.Log(buf.String())
+	if n := strings.Count(buf.String(), "# TYPE bufpool_gets_total "); n != 1 {
+		t.Errorf("got %d TYPE lines for bufpool_gets_total, wanted 1", n)
+	}
+	for _, want := range []string{
+		`bufpool_gets_total{pool="one",class="64",result="hit"} 0` + "\n",
+		`bufpool_gets_total{pool="tw\"o` + "\t" + `",class="64",result="hit"} 0` + "\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
+
+func BenchmarkSized(b *testing.B) {
+	p := bufpool.NewSized(bufpool.SizedOptions{})
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buf := p.GetBytes(4000)
+			buf[0] = 1
+			p.PutBytes(buf)
+		}
+	})
+}
@@ -4,6 +4,8 @@
 
 package checksum
 
+import "strings"
+
 type Calculator interface {
 	Calculate(string) uint8
 }
@@ -69,22 +71,58 @@ func (cs csEAN8) Calculate(text string) uint8 {
 
 type checksum struct {
 	Calculator
+	// Prefix is the required prefix of the valid (decorated) text.
+	Prefix string
+	// Digits are the check digits for each Calculate result, "0123456789" if empty.
+	Digits    string
+	Length    int // Length is the length of the valid (decorated) text, if not zero.
 	Direction direction
 }
 
 func (c checksum) IsValid(text string) bool {
-	return text == c.Decorate(c.Undecorate(text))
+	if len(text) < 2 || c.Length != 0 && len(text) != c.Length || !strings.HasPrefix(text, c.Prefix) {
+		return false
+	}
+	body := c.Undecorate(text)
+	for i := 0; i < len(body); i++ {
+		if !('0' <= body[i] && body[i] <= '9') {
+			return false
+		}
+	}
+	return text == c.Decorate(body)
 }
 
+// Decorate adds the check digit to the text.
+// The text is returned as is if it has no valid check digit.
 func (c checksum) Decorate(text string) string {
+	digits := c.Digits
+	if digits == "" {
+		digits = "0123456789"
+	}
+	i := int(c.Calculate(text))
+	if i >= len(digits) {
+		return text
+	}
 	if c.Direction == dirPrepend {
-		return string([]byte{c.Calculate(text) + '0'}) + text
+		return digits[i:i+1] + text
 	}
-	return text + string([]byte{c.Calculate(text) + '0'})
+	return text + digits[i:i+1]
 }
 func (c checksum) Undecorate(text string) string {
+	if text == "" {
+		return text
+	}
 	if c.Direction == dirPrepend {
 		return text[1:]
 	}
 	return text[:len(text)-1]
 }
+
+// weighted returns the sum of the digits of text multiplied by the weights (cycled).
+func weighted(text string, weights ...int) int {
+	var sum int
+	for i := 0; i < len(text); i++ {
+		sum += int(text[i]-'0') * weights[i%len(weights)]
+	}
+	return sum
+}
@@ -0,0 +1,48 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+// Use of this source code is governed by an Apache 2.0
+// license that can be found in the LICENSE file.
+
+package checksum
+
+var (
+	// GTIN13 is the EAN-13 article number.
+	GTIN13 = checksum{Calculator: csGTIN{}, Direction: dirAppend, Length: 13}
+	// GTIN14 is the trade unit (ITF-14) number.
+	GTIN14 = checksum{Calculator: csGTIN{}, Direction: dirAppend, Length: 14}
+	// UPCA is the 12 digits long Universal Product Code (GTIN-12).
+	UPCA = checksum{Calculator: csGTIN{}, Direction: dirAppend, Length: 12}
+
+	// Luhn is the mod 10 check digit of the payment card numbers (ISO/IEC 7812).
+	Luhn = checksum{Calculator: csLuhn{}, Direction: dirAppend}
+)
+
+// csGTIN is the GS1 check digit: the digits are weighted 3, 1, 3... from the right.
+type csGTIN struct{}
+
+func (cs csGTIN) Calculate(text string) uint8 {
+	var sum int
+	mul := 3
+	for i := len(text) - 1; i >= 0; i-- {
+		sum += int(text[i]-'0') * mul
+		mul = 4 - mul
+	}
+	return uint8((10 - sum%10) % 10)
+}
+
+type csLuhn struct{}
+
+func (cs csLuhn) Calculate(text string) uint8 {
+	var sum int
+	double := true
+	for i := len(text) - 1; i >= 0; i-- {
+		d := int(text[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return uint8((10 - sum%10) % 10)
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+// Use of this source code is governed by an Apache 2.0
+// license that can be found in the LICENSE file.
+
+package checksum
+
+import "strings"
+
+// Hungarian identifiers.
+var (
+	// AdoazonositoJel is the 10 digits tax identification number of a person (adóazonosító jel).
+	// Decorate returns the text as is if the number cannot have a check digit.
+	AdoazonositoJel = checksum{Calculator: csAdoazonosito{}, Direction: dirAppend, Length: 10, Prefix: "8"}
+	// TAJ is the 9 digits social security number (TAJ szám).
+	TAJ = checksum{Calculator: csTAJ{}, Direction: dirAppend, Length: 9}
+	// Torzsszam is the first 8 digits of the company tax number (adószám törzsszáma).
+	Torzsszam = checksum{Calculator: csTorzsszam{}, Direction: dirAppend, Length: 8}
+
+	// Adoszam is the 11 digits company tax number (adószám), with or without dashes (12345678-1-42).
+	// The check digit is the last digit of the törzsszám, before the VAT code and the county code.
+	Adoszam = adoszam{}
+)
+
+type csAdoazonosito struct{}
+
+func (cs csAdoazonosito) Calculate(text string) uint8 {
+	return uint8(weighted(text, 1, 2, 3, 4, 5, 6, 7, 8, 9) % 11)
+}
+
+type csTAJ struct{}
+
+func (cs csTAJ) Calculate(text string) uint8 {
+	return uint8(weighted(text, 3, 7) % 10)
+}
+
+type csTorzsszam struct{}
+
+func (cs csTorzsszam) Calculate(text string) uint8 {
+	return uint8((10 - weighted(text, 9, 7, 3, 1)%10) % 10)
+}
+
+type adoszam struct{}
+
+func (adoszam) Calculate(text string) uint8 {
+	return Torzsszam.Calculate(text[:min(7, len(text))])
+}
+
+// Decorate inserts the check digit after the first 7 digits.
+func (a adoszam) Decorate(text string) string {
+	if len(text) < 7 {
+		return text
+	}
+	return text[:7] + string(rune('0'+a.Calculate(text))) + text[7:]
+}
+
+func (adoszam) Undecorate(text string) string {
+	if len(text) < 8 {
+		return text
+	}
+	return text[:7] + text[8:]
+}
+
+func (a adoszam) IsValid(text string) bool {
+	if len(text) == 13 && text[8] == '-' && text[10] == '-' {
+		text = text[:8] + text[9:10] + text[11:]
+	}
+	if len(text) != 11 || strings.Trim(text, "0123456789") != "" ||
+		!('1' <= text[8] && text[8] <= '5') || !adoszamCounty(text[9:]) {
+		return false
+	}
+	return Torzsszam.IsValid(text[:8])
+}
+
+// adoszamCounty reports whether code is a valid county code (area of the tax authority).
+func adoszamCounty(code string) bool {
+	switch code {
+	case "22", "41", "42", "43", "44", "51":
+		return true
+	}
+	return "02" <= code && code <= "20"
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+// Use of this source code is governed by an Apache 2.0
+// license that can be found in the LICENSE file.
+
+package checksum
+
+import (
+	"strconv"
+	"strings"
+)
+
+// IBAN is the International Bank Account Number (ISO 13616), with the MOD 97-10 check digits
+// after the country code.
+//
+// The undecorated form is the country code followed by the BBAN.
+// The text may contain spaces (as in the printed form), and lowercase letters.
+var IBAN = iban{}
+
+type iban struct{}
+
+// Calculate the check digits of the country code + BBAN.
+func (iban) Calculate(text string) uint8 {
+	text = compactIBAN(text)
+	if len(text) < 2 {
+		return 0
+	}
+	r, ok := ibanMod97(text[2:] + text[:2] + "00")
+	if !ok {
+		return 0
+	}
+	return uint8(98 - r)
+}
+
+func (c iban) Decorate(text string) string {
+	text = compactIBAN(text)
+	if len(text) < 2 {
+		return text
+	}
+	return text[:2] + twoDigits(c.Calculate(text)) + text[2:]
+}
+
+func (iban) Undecorate(text string) string {
+	text = compactIBAN(text)
+	if len(text) < 4 {
+		return text
+	}
+	return text[:2] + text[4:]
+}
+
+func (iban) IsValid(text string) bool {
+	text = compactIBAN(text)
+	if len(text) < 5 || ibanLengths[text[:2]] != len(text) ||
+		!('0' <= text[2] && text[2] <= '9' && '0' <= text[3] && text[3] <= '9') {
+		return false
+	}
+	r, ok := ibanMod97(text[4:] + text[:4])
+	return ok && r == 1
+}
+
+func compactIBAN(text string) string {
+	return strings.ToUpper(strings.ReplaceAll(text, " ", ""))
+}
+
+// ibanMod97 returns the number represented by text (with A=10, B=11 ... Z=35) modulo 97.
+func ibanMod97(text string) (int, bool) {
+	var r int
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case '0' <= c && c <= '9':
+			r = (r*10 + int(c-'0')) % 97
+		case 'A' <= c && c <= 'Z':
+			r = mod97Digits(r, strconv.Itoa(int(c-'A')+10))
+		default:
+			return 0, false
+		}
+	}
+	return r, true
+}
+
+// ibanLengths is the length of the IBAN for each country, from the SWIFT IBAN registry.
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22,
+	"BH": 22, "BI": 27, "BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24,
+	"DE": 22, "DJ": 27, "DK": 18, "DO": 28, "EE": 20, "EG": 29, "ES": 24, "FI": 18,
+	"FK": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23, "GL": 18, "GR": 27,
+	"GT": 28, "HN": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23, "IS": 26,
+	"IT": 27, "JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32, "LI": 21, "LT": 20,
+	"LU": 20, "LV": 21, "LY": 25, "MC": 27, "MD": 24, "ME": 22, "MK": 19, "MN": 20,
+	"MR": 27, "MT": 31, "MU": 30, "NI": 28, "NL": 18, "NO": 15, "OM": 23, "PK": 24,
+	"PL": 28, "PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "RU": 33, "SA": 24,
+	"SC": 31, "SD": 18, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "SO": 23, "ST": 25,
+	"SV": 28, "TL": 23, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20,
+	"YE": 30,
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+// Use of this source code is governed by an Apache 2.0
+// license that can be found in the LICENSE file.
+
+package checksum
+
+import "strconv"
+
+var (
+	// Mod11_2 is the ISO 7064 MOD 11-2 check character (0-9 or X), as used by ISNI and ORCID.
+	Mod11_2 = checksum{Calculator: csMod11_2{}, Direction: dirAppend, Digits: "0123456789X"}
+
+	// Mod97_10 is the two digits ISO 7064 MOD 97-10 check.
+	Mod97_10 = mod97{}
+)
+
+type csMod11_2 struct{}
+
+func (cs csMod11_2) Calculate(text string) uint8 {
+	var p int
+	for i := 0; i < len(text); i++ {
+		p = (p + int(text[i]-'0')) * 2 % 11
+	}
+	return uint8((12 - p) % 11)
+}
+
+type mod97 struct{}
+
+// Calculate returns the two check digits (2-98) of the digits.
+func (m mod97) Calculate(text string) uint8 {
+	return uint8(98 - mod97Digits(0, text)*100%97)
+}
+
+func (m mod97) Decorate(text string) string {
+	return text + twoDigits(m.Calculate(text))
+}
+
+func (m mod97) Undecorate(text string) string {
+	return text[:max(0, len(text)-2)]
+}
+
+func (m mod97) IsValid(text string) bool {
+	if len(text) < 3 {
+		return false
+	}
+	for i := 0; i < len(text); i++ {
+		if !('0' <= text[i] && text[i] <= '9') {
+			return false
+		}
+	}
+	return mod97Digits(0, text) == 1
+}
+
+// mod97Digits returns the number of (the decimal digits of) text, prefixed by the number r, modulo 97.
+func mod97Digits(r int, text string) int {
+	for i := 0; i < len(text); i++ {
+		r = (r*10 + int(text[i]-'0')) % 97
+	}
+	return r
+}
+
+func twoDigits(n uint8) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(int(n))
+	}
+	return strconv.Itoa(int(n))
+}
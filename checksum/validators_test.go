@@ -0,0 +1,103 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+// Use of this source code is governed by an Apache 2.0
+// license that can be found in the LICENSE file.
+
+package checksum_test
+
+import (
+	"testing"
+
+	"github.com/tgulacsi/go/checksum"
+)
+
+func TestValidators(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		cs      checksum.Checksum
+		valid   []string
+		invalid []string
+	}{
+		{"GTIN13", checksum.GTIN13,
+			[]string{"4006381333931", "5901234123457", "5998817100028"},
+			[]string{"4006381333932", "400638133393", "40063813339311", "400638133393a", ""}},
+		{"GTIN14", checksum.GTIN14,
+			[]string{"10012345000017", "00012345600012"},
+			[]string{"10012345000018", "0012345600012"}},
+		{"UPCA", checksum.UPCA,
+			[]string{"036000291452", "012345678905"},
+			[]string{"036000291453", "0360002914521"}},
+		{"Luhn", checksum.Luhn,
+			[]string{"79927398713", "4111111111111111", "5500005555555559", "378282246310005"},
+			[]string{"79927398710", "4111111111111112", "4111-1111-1111-1111", "1", ""}},
+		{"Mod11_2", checksum.Mod11_2,
+			[]string{"0000000218250097", "000000021694233X", "0000000073669144"},
+			[]string{"0000000218250098", "0000000218250X97", "000000021694233x"}},
+		{"Mod97_10", checksum.Mod97_10,
+			[]string{"79444", "12345678978", "3214282912345698765432161182"},
+			[]string{"79445", "7944a", "44", ""}},
+		{"IBAN", checksum.IBAN,
+			[]string{
+				"HU42117730161111101800000000", "HU42 1177 3016 1111 1018 0000 0000",
+				"DE89370400440532013000", "GB29NWBK60161331926819", "gb29 nwbk 6016 1331 9268 19",
+				"NO9386011117947", "BE68539007547034", "FR1420041010050500013M02606",
+			},
+			[]string{
+				"HU42117730161111101800000001", "HU4211773016111110180000000",
+				"DE89370400440532013001", "XX89370400440532013000", "GB29NWBK6016133192681!",
+				"HUAA117730161111101800000000", "HU42", "",
+			}},
+		{"AdoazonositoJel", checksum.AdoazonositoJel,
+			[]string{"8123456786", "8000000008"},
+			[]string{"8123456787", "7123456786", "812345678", "81234567860"}},
+		{"TAJ", checksum.TAJ,
+			[]string{"123456788", "111111110"},
+			[]string{"123456789", "12345678", "1234567888"}},
+		{"Torzsszam", checksum.Torzsszam,
+			[]string{"10625790", "10773381"},
+			[]string{"10625791", "1062579"}},
+		{"Adoszam", checksum.Adoszam,
+			[]string{"10625790-4-44", "10625790444", "10773381-2-44", "12345676-1-42"},
+			[]string{"10625791-4-44", "10625790-6-44", "10625790-4-21", "10625790-4-01", "106257904-4-4", "1062579044"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, s := range tc.valid {
+				if !tc.cs.IsValid(s) {
+					t.Errorf("%q shall be valid", s)
+				}
+				if got := tc.cs.Decorate(tc.cs.Undecorate(s)); got != s && tc.name != "IBAN" {
+					t.Errorf("Decorate(Undecorate(%q)) = %q", s, got)
+				}
+			}
+			for _, s := range tc.invalid {
+				if tc.cs.IsValid(s) {
+					t.Errorf("%q shall be invalid", s)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckDigits(t *testing.T) {
+	for i, tc := range []struct {
+		cs   checksum.Checksum
+		text string
+		want string
+	}{
+		{checksum.GTIN13, "400638133393", "4006381333931"},
+		{checksum.Luhn, "7992739871", "79927398713"},
+		{checksum.Mod11_2, "000000021694233", "000000021694233X"},
+		{checksum.Mod97_10, "794", "79444"},
+		{checksum.Mod97_10, "1", "195"},
+		{checksum.IBAN, "HU117730161111101800000000", "HU42117730161111101800000000"},
+		{checksum.IBAN, "gb NWBK60161331926819", "GB29NWBK60161331926819"},
+		{checksum.AdoazonositoJel, "812345678", "8123456786"},
+		// the remainder is 10, there is no valid check digit
+		{checksum.AdoazonositoJel, "800000030", "800000030"},
+		{checksum.TAJ, "12345678", "123456788"},
+		{checksum.Adoszam, "1062579-4-44", "10625790-4-44"},
+	} {
+		if got := tc.cs.Decorate(tc.text); got != tc.want {
+			t.Errorf("%d. Decorate(%q): got %q, want %q.", i, tc.text, got, tc.want)
+		}
+	}
+}
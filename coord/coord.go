@@ -80,6 +80,10 @@ var (
 	// MapsCoAPIKey isd the API_Key served to maps.co
 	// It is set by default to the contents of the MAPSCO_API_KEY env var.
 	MapsCoAPIKey = os.Getenv("MAPSCO_API_KEY")
+
+	// HTTPClient is used for the lookups - set it to a caching client
+	// (see github.com/tgulacsi/go/httpclient.WithCache) to spare repeated requests.
+	HTTPClient = http.DefaultClient
 )
 
 type Location struct {
@@ -135,7 +139,7 @@ func (ut urlTemplate) get(ctx context.Context, address string) (Location, error)
 			return loc, fmt.Errorf("%s: %w", aURL, err)
 		}
 		if err = func() error {
-			resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+			resp, err := HTTPClient.Do(req.WithContext(ctx))
 			if err != nil {
 				logger.Error("coord request", "url", aURL, "error", err)
 				return fmt.Errorf("%s: %w", aURL, err)
@@ -0,0 +1,149 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+// Use of this source code is governed by an Apache 2.0
+// license that can be found in the LICENSE file.
+
+package crypthlp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+const (
+	// AgeHeader is the first line of an age v1 file.
+	AgeHeader = "age-encryption.org/v1"
+
+	// ageMaxWorkFactor is the maximum scrypt work factor used when encrypting and accepted when decrypting,
+	// as the age CLI rejects higher ones by default.
+	ageMaxWorkFactor = 22
+)
+
+// AgeScryptRecipient returns an age scrypt recipient for the passphrase,
+// with a work factor tuned to take about timeout (DefaultTimeout if <= 0),
+// but at most 22.
+//
+// The tuning reuses the measurements of GenKey and the previous calls,
+// so only the first call spends time on it.
+func AgeScryptRecipient(passphrase []byte, timeout time.Duration) (*age.ScryptRecipient, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	r, err := age.NewScryptRecipient(string(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	l2n, err := calibrateWorkFactor(timeout, ageMaxWorkFactor)
+	if err != nil {
+		return nil, err
+	}
+	r.SetWorkFactor(int(l2n))
+	return r, nil
+}
+
+// AgeScryptIdentity returns an age scrypt identity for the passphrase.
+func AgeScryptIdentity(passphrase []byte) (*age.ScryptIdentity, error) {
+	id, err := age.NewScryptIdentity(string(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	id.SetMaxWorkFactor(ageMaxWorkFactor)
+	return id, nil
+}
+
+// GenerateAgeIdentity generates a new X25519 identity.
+// Its Recipient() is the public key to encrypt to.
+func GenerateAgeIdentity() (*age.X25519Identity, error) { return age.GenerateX25519Identity() }
+
+// ParseAgeRecipients parses the X25519 recipients ("age1...").
+func ParseAgeRecipients(recipients ...string) ([]age.Recipient, error) {
+	rr := make([]age.Recipient, 0, len(recipients))
+	for _, s := range recipients {
+		r, err := age.ParseX25519Recipient(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", s, err)
+		}
+		rr = append(rr, r)
+	}
+	return rr, nil
+}
+
+// ReadAgeIdentities reads the identities from the file, in the format of age-keygen.
+func ReadAgeIdentities(filename string) ([]age.Identity, error) {
+	fh, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	ids, err := age.ParseIdentities(fh)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return ids, nil
+}
+
+// CreateAgeWriter returns a WriteCloser which encrypts to the recipients in the age v1 format,
+// ASCII armored if armored is true.
+//
+// Close must be called to write the final chunk.
+func CreateAgeWriter(w io.Writer, armored bool, recipients ...age.Recipient) (io.WriteCloser, error) {
+	if !armored {
+		return age.Encrypt(w, recipients...)
+	}
+	aw := armor.NewWriter(w)
+	ew, err := age.Encrypt(aw, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	return &ageArmoredWriter{WriteCloser: ew, armor: aw}, nil
+}
+
+// CreateAgePassphraseWriter is CreateAgeWriter with an AgeScryptRecipient.
+func CreateAgePassphraseWriter(w io.Writer, passphrase []byte, timeout time.Duration, armored bool) (io.WriteCloser, error) {
+	r, err := AgeScryptRecipient(passphrase, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return CreateAgeWriter(w, armored, r)
+}
+
+type ageArmoredWriter struct {
+	io.WriteCloser
+	armor io.WriteCloser
+}
+
+func (w *ageArmoredWriter) Close() error {
+	return errors.Join(w.WriteCloser.Close(), w.armor.Close())
+}
+
+// OpenAgeReader decrypts the age v1 file (binary or ASCII armored) with the identities.
+func OpenAgeReader(r io.Reader, identities ...age.Identity) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if isAgeArmored(br) {
+		return age.Decrypt(armor.NewReader(br), identities...)
+	}
+	return age.Decrypt(br, identities...)
+}
+
+// isAgeArmored reports whether the reader starts with the armor header (after whitespace).
+func isAgeArmored(br *bufio.Reader) bool {
+	b, _ := br.Peek(len(armor.Header) + 1024)
+	return bytes.HasPrefix(bytes.TrimLeft(b, " \t\r\n"), []byte(armor.Header))
+}
+
+// isAge reports whether the reader is an age file.
+func isAge(br *bufio.Reader) bool {
+	if isAgeArmored(br) {
+		return true
+	}
+	b, _ := br.Peek(len(AgeHeader))
+	return string(b) == AgeHeader
+}
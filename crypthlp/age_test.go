@@ -0,0 +1,215 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+// Use of this source code is governed by an Apache 2.0
+// license that can be found in the LICENSE file.
+
+package crypthlp_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"github.com/tgulacsi/go/crypthlp"
+)
+
+func TestAgeVectors(t *testing.T) {
+	ids, err := crypthlp.ReadAgeIdentities("testdata/age_example_keys.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fh, err := os.Open("testdata/age_example.age")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+	r, err := crypthlp.OpenAgeReader(fh, ids...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := io.ReadAll(r); err != nil {
+		t.Fatal(err)
+	} else if got, want := string(b), "Black lives matter."; got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+
+	// from age's cmd/age/testdata/x25519.txt
+	id, err := age.ParseX25519Identity("AGE-SECRET-KEY-1EGTZVFFV20835NWYV6270LXYVK2VKNX2MMDKWYKLMGR48UAWX40Q2P2LM0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := id.Recipient().String(), "age1xmwwc06ly3ee5rytxm9mflaz2u56jjj36s0mypdrwsvlul66mv4q47ryef"; got != want {
+		t.Errorf("got recipient %q, wanted %q", got, want)
+	}
+}
+
+func TestAgeRoundTrip(t *testing.T) {
+	id, err := crypthlp.GenerateAgeIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, err := crypthlp.ParseAgeRecipients(id.Recipient().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := strings.Repeat("árvíztűrő tükörfúrógép\n", 5000)
+	for _, armored := range []bool{false, true} {
+		var buf bytes.Buffer
+		w, err := crypthlp.CreateAgeWriter(&buf, armored, rr...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, data)
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if armored != strings.HasPrefix(buf.String(), "-----BEGIN AGE ENCRYPTED FILE-----") {
+			t.Errorf("armored=%t, got %q", armored, buf.Bytes()[:64])
+		}
+		r, err := crypthlp.OpenAgeReader(bytes.NewReader(buf.Bytes()), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b, err := io.ReadAll(r); err != nil {
+			t.Fatal(err)
+		} else if string(b) != data {
+			t.Errorf("armored=%t: got %d bytes, wanted %d", armored, len(b), len(data))
+		}
+	}
+
+	passphrase := []byte("passphrase")
+	var buf bytes.Buffer
+	w, err := crypthlp.CreateAgePassphraseWriter(&buf, passphrase, 100*time.Millisecond, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, data)
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_, r, err := crypthlp.OpenReader(bytes.NewReader(buf.Bytes()), passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := io.ReadAll(r); err != nil {
+		t.Fatal(err)
+	} else if string(b) != data {
+		t.Errorf("passphrase: got %d bytes, wanted %d", len(b), len(data))
+	}
+	if _, _, err = crypthlp.OpenReader(bytes.NewReader(buf.Bytes()), []byte("wrong")); err == nil {
+		t.Error("wrong passphrase succeeded")
+	}
+}
+
+// ageTestkitDeviations are the vectors the used filippo.io/age version fails.
+var ageTestkitDeviations = map[string]string{
+	"armor_empty_last_line": "filippo.io/age v1.2.1 accepts an empty line before the armor footer",
+}
+
+// TestAgeTestkit runs the C2SP age test vectors (testdata/age-testkit).
+func TestAgeTestkit(t *testing.T) {
+	dis, err := os.ReadDir("testdata/age-testkit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, di := range dis {
+		t.Run(di.Name(), func(t *testing.T) {
+			if reason := ageTestkitDeviations[di.Name()]; reason != "" {
+				t.Skip(reason)
+			}
+			b, err := os.ReadFile(filepath.Join("testdata", "age-testkit", di.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			hdr, body, ok := bytes.Cut(b, []byte("\n\n"))
+			if !ok {
+				t.Fatal("no header")
+			}
+			var expect, payload, compressed string
+			var ids []age.Identity
+			for line := range strings.SplitSeq(string(hdr), "\n") {
+				k, v, _ := strings.Cut(line, ": ")
+				switch k {
+				case "expect":
+					expect = v
+				case "payload":
+					payload = v
+				case "compressed":
+					compressed = v
+				case "identity":
+					if strings.HasPrefix(v, "AGE-SECRET-KEY-PQ-") {
+						t.Skip("hybrid identities are not supported")
+					}
+					id, err := age.ParseX25519Identity(v)
+					if err != nil {
+						t.Fatal(err)
+					}
+					ids = append(ids, id)
+				case "passphrase":
+					id, err := crypthlp.AgeScryptIdentity([]byte(v))
+					if err != nil {
+						t.Fatal(err)
+					}
+					ids = append(ids, id)
+				}
+			}
+			var r io.Reader = bytes.NewReader(body)
+			if compressed == "zlib" {
+				zr, err := zlib.NewReader(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer zr.Close()
+				r = zr
+			}
+
+			dr, err := crypthlp.OpenAgeReader(r, ids...)
+			var got []byte
+			if err == nil {
+				got, err = io.ReadAll(dr)
+			}
+			var noMatch *age.NoIdentityMatchError
+			switch expect {
+			case "success":
+				if err != nil {
+					t.Fatalf("%+v", err)
+				}
+			case "payload failure":
+				if err == nil || dr == nil {
+					t.Fatalf("got %v, wanted payload failure", err)
+				}
+			case "no match":
+				if !errors.As(err, &noMatch) {
+					t.Fatalf("got %v, wanted no match", err)
+				}
+				return
+			case "HMAC failure", "header failure":
+				if dr != nil {
+					t.Fatalf("got %v after the header, wanted %s", err, expect)
+				}
+				if err == nil || errors.As(err, &noMatch) {
+					t.Fatalf("got %v, wanted %s", err, expect)
+				}
+				return
+			case "armor failure":
+				if err == nil {
+					t.Fatal("wanted armor failure")
+				}
+				return
+			default:
+				t.Fatalf("unknown expect %q", expect)
+			}
+			if sum := sha256.Sum256(got); hex.EncodeToString(sum[:]) != payload {
+				t.Errorf("got payload hash %x, wanted %s", sum, payload)
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package main of keystore converts between PEM, PKCS#12 and JKS/JCEKS keystores,
+// lists their entries and checks the expiry of their certificates.
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+	"software.sslmate.com/src/go-pkcs12"
+
+	"github.com/tgulacsi/go/crypthlp"
+)
+
+func main() {
+	if err := Main(); err != nil {
+		slog.Error("main", "error", err)
+		os.Exit(1)
+	}
+}
+
+func Main() error {
+	var flagAlias string
+	aliasFlag := func(fs *flag.FlagSet) {
+		fs.StringVar(&flagAlias, "alias", "", "alias of the key entry for PEM and P12 inputs (default: the CN of the leaf)")
+	}
+
+	FS := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flagInPass := FS.String("in-pass", "", "password of the input (see openssl-passphrase-options, or prompt)")
+	flagOutPass := FS.String("out-pass", "", "password of the output (default: the input password)")
+	flagOut := FS.String("out", "-", "output file")
+	flagTo := FS.String("to", "p12", "output format: pem, p12, jks or jceks")
+	aliasFlag(FS)
+	convertCmd := ffcli.Command{Name: "convert", FlagSet: FS,
+		ShortUsage: "convert [flags] input...",
+		ShortHelp:  "convert the inputs (PEM files, or one P12/JKS/JCEKS keystore) to the output format",
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return flag.ErrHelp
+			}
+			inPass, err := crypthlp.ReadPassword(*flagInPass)
+			if err != nil {
+				return err
+			}
+			outPass := inPass
+			if *flagOutPass != "" {
+				if outPass, err = crypthlp.ReadPassword(*flagOutPass); err != nil {
+					return err
+				}
+			}
+			entries, err := load(ctx, args, inPass, flagAlias)
+			if err != nil {
+				return err
+			}
+			var b []byte
+			switch strings.ToLower(*flagTo) {
+			case "pem":
+				b, err = encodePEM(entries)
+			case "p12", "pkcs12", "pfx":
+				b, err = encodeP12(entries, outPass)
+			case "jks":
+				b, err = crypthlp.EncodeJKS(outPass, entries...)
+			case "jceks":
+				b, err = crypthlp.EncodeJCEKS(outPass, entries...)
+			default:
+				return fmt.Errorf("unknown output format %q", *flagTo)
+			}
+			if err != nil {
+				return err
+			}
+			if *flagOut == "" || *flagOut == "-" {
+				_, err = os.Stdout.Write(b)
+				return err
+			}
+			return os.WriteFile(*flagOut, b, 0600)
+		},
+	}
+
+	FS = flag.NewFlagSet("list", flag.ContinueOnError)
+	flagListPass := FS.String("pass", "", "password of the inputs (see openssl-passphrase-options)")
+	aliasFlag(FS)
+	listCmd := ffcli.Command{Name: "list", FlagSet: FS,
+		ShortUsage: "list [flags] keystore...",
+		ShortHelp:  "list the entries of the keystores",
+		Exec: func(ctx context.Context, args []string) error {
+			pass, err := crypthlp.ReadPassword(*flagListPass)
+			if err != nil {
+				return err
+			}
+			tw := tabwriter.NewWriter(os.Stdout, 4, 2, 1, ' ', 0)
+			for _, fn := range args {
+				entries, err := load(ctx, []string{fn}, pass, flagAlias)
+				if err != nil {
+					return fmt.Errorf("%s: %w", fn, err)
+				}
+				fmt.Fprintf(tw, "# %s: %d entries\n", fn, len(entries))
+				for _, e := range entries {
+					typ := "trustedCertEntry"
+					if e.PrivateKey != nil {
+						typ = "PrivateKeyEntry"
+					}
+					fmt.Fprintf(tw, "%s\t%s\t%s\n", e.Alias, typ, e.Created.Format(time.DateTime))
+					for _, c := range e.Chain {
+						fmt.Fprintf(tw, "\t%s\t%s\t%s\t%s\n",
+							c.Subject, c.Issuer, c.NotAfter.Format(time.DateTime), c.SerialNumber)
+					}
+				}
+			}
+			return tw.Flush()
+		},
+	}
+
+	FS = flag.NewFlagSet("check", flag.ContinueOnError)
+	flagCheckPass := FS.String("pass", "", "password of the inputs (see openssl-passphrase-options)")
+	flagWithin := FS.Duration("within", 30*24*time.Hour, "report certificates expiring within this duration")
+	aliasFlag(FS)
+	checkCmd := ffcli.Command{Name: "check", FlagSet: FS,
+		ShortUsage: "check [flags] keystore...",
+		ShortHelp:  "check the expiry of the certificates, fail if any expires within the given duration",
+		Exec: func(ctx context.Context, args []string) error {
+			pass, err := crypthlp.ReadPassword(*flagCheckPass)
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			deadline := now.Add(*flagWithin)
+			var n int
+			for _, fn := range args {
+				entries, err := load(ctx, []string{fn}, pass, flagAlias)
+				if err != nil {
+					return fmt.Errorf("%s: %w", fn, err)
+				}
+				for _, e := range entries {
+					for _, c := range e.Chain {
+						if c.NotAfter.After(deadline) {
+							continue
+						}
+						n++
+						state := "expires"
+						if c.NotAfter.Before(now) {
+							state = "EXPIRED"
+						}
+						fmt.Printf("%s\t%s\t%s\t%s %s\n", fn, e.Alias, c.Subject, state, c.NotAfter.Format(time.DateTime))
+					}
+				}
+			}
+			if n != 0 {
+				return fmt.Errorf("%d certificates expire before %s", n, deadline.Format(time.DateTime))
+			}
+			return nil
+		},
+	}
+
+	app := ffcli.Command{Name: "keystore",
+		ShortUsage:  "keystore <convert|list|check> [flags] files...",
+		Subcommands: []*ffcli.Command{&convertCmd, &listCmd, &checkCmd},
+		Exec:        func(ctx context.Context, args []string) error { return flag.ErrHelp },
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	if err := app.ParseAndRun(ctx, os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// load reads the keystore, or the PEM files.
+func load(ctx context.Context, files []string, password, alias string) ([]crypthlp.KeyStoreEntry, error) {
+	var key crypto.Signer
+	var certs []*x509.Certificate
+	for _, fn := range files {
+		var b []byte
+		var err error
+		if fn == "-" {
+			b, err = io.ReadAll(os.Stdin)
+		} else {
+			b, err = os.ReadFile(fn)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(b) >= 4 {
+			if magic := binary.BigEndian.Uint32(b); magic == crypthlp.MagicJKS || magic == crypthlp.MagicJCEKS {
+				if len(files) != 1 {
+					return nil, fmt.Errorf("%s: only one keystore can be converted at once", fn)
+				}
+				return crypthlp.DecodeKeyStore(b, password)
+			}
+		}
+		if !bytes.Contains(b, []byte("-----BEGIN ")) {
+			if len(files) != 1 {
+				return nil, fmt.Errorf("%s: only one keystore can be converted at once", fn)
+			}
+			priv, leaf, cas, err := crypthlp.ParseP12Bytes(ctx, b, password)
+			if err != nil {
+				if certs, tsErr := pkcs12.DecodeTrustStore(b, password); tsErr == nil {
+					return newEntries(alias, nil, certs)
+				}
+				return nil, fmt.Errorf("%s: %w", fn, err)
+			}
+			signer, ok := priv.(crypto.Signer)
+			if !ok {
+				return nil, fmt.Errorf("%s: unknown private key type %T", fn, priv)
+			}
+			return newEntries(alias, signer, append([]*x509.Certificate{leaf}, cas...))
+		}
+
+		for {
+			var block *pem.Block
+			if block, b = pem.Decode(b); block == nil {
+				break
+			}
+			switch {
+			case block.Type == "CERTIFICATE":
+				c, err := x509.ParseCertificate(block.Bytes)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", fn, err)
+				}
+				certs = append(certs, c)
+			case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+				if key != nil {
+					return nil, fmt.Errorf("%s: more than one private key", fn)
+				}
+				if key, err = parsePrivateKey(block.Bytes); err != nil {
+					return nil, fmt.Errorf("%s: %w", fn, err)
+				}
+			}
+		}
+	}
+	return newEntries(alias, key, certs)
+}
+
+// newEntries returns the entries for the key and certificates:
+// the certificate of the key's public key is the leaf, the others are the CA chain.
+// Without a key, all certificates are trusted certificate entries.
+func newEntries(alias string, key crypto.Signer, certs []*x509.Certificate) ([]crypthlp.KeyStoreEntry, error) {
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate found")
+	}
+	if key == nil {
+		entries := make([]crypthlp.KeyStoreEntry, 0, len(certs))
+		now := time.Now()
+		for i, c := range certs {
+			a := certAlias(c)
+			if alias != "" {
+				a = fmt.Sprintf("%s%d", alias, i+1)
+			}
+			entries = append(entries, crypthlp.KeyStoreEntry{Created: now, Alias: a, Chain: []*x509.Certificate{c}})
+		}
+		return entries, nil
+	}
+	type equaler interface{ Equal(crypto.PublicKey) bool }
+	pub, ok := key.Public().(equaler)
+	if !ok {
+		return nil, fmt.Errorf("unknown public key type %T", key.Public())
+	}
+	for i, c := range certs {
+		if !pub.Equal(c.PublicKey) {
+			continue
+		}
+		if alias == "" {
+			alias = certAlias(c)
+		}
+		cas := append(append(make([]*x509.Certificate, 0, len(certs)-1), certs[:i]...), certs[i+1:]...)
+		return crypthlp.NewKeyStoreEntries(alias, key, c, cas)[:1:1], nil
+	}
+	return nil, errors.New("no certificate found for the private key")
+}
+
+func certAlias(c *x509.Certificate) string {
+	if c.Subject.CommonName != "" {
+		return strings.ToLower(c.Subject.CommonName)
+	}
+	return c.SerialNumber.String()
+}
+
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	var firstErr error
+	for _, f := range []func([]byte) (any, error){
+		x509.ParsePKCS8PrivateKey,
+		func(b []byte) (any, error) { return x509.ParsePKCS1PrivateKey(b) },
+		func(b []byte) (any, error) { return x509.ParseECPrivateKey(b) },
+	} {
+		k, err := f(der)
+		if err == nil {
+			if signer, ok := k.(crypto.Signer); ok {
+				return signer, nil
+			}
+			return nil, fmt.Errorf("unknown private key type %T", k)
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+func encodePEM(entries []crypthlp.KeyStoreEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		if e.PrivateKey != nil {
+			der, err := x509.MarshalPKCS8PrivateKey(e.PrivateKey)
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", e.Alias, err)
+			}
+			if err = pem.Encode(&buf, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+				return nil, err
+			}
+		}
+		for _, c := range e.Chain {
+			if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeP12 encodes the first private key entry with its chain,
+// or all the certificates as a trust store if there's no private key entry.
+func encodeP12(entries []crypthlp.KeyStoreEntry, password string) ([]byte, error) {
+	var certs []*x509.Certificate
+	for _, e := range entries {
+		if e.PrivateKey != nil {
+			if len(e.Chain) == 0 {
+				return nil, fmt.Errorf("%q: no certificate", e.Alias)
+			}
+			return crypthlp.EncodeP12(e.PrivateKey, e.Chain[0], e.Chain[1:], password)
+		}
+		certs = append(certs, e.Chain...)
+	}
+	return pkcs12.Modern2023.EncodeTrustStore(certs, password)
+}
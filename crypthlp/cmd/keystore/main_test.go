@@ -0,0 +1,46 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tgulacsi/go/crypthlp"
+)
+
+func runMain(t *testing.T, args ...string) error {
+	t.Helper()
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = append([]string{"keystore"}, args...)
+	return Main()
+}
+
+func TestConvert(t *testing.T) {
+	testdata := filepath.Join("..", "..", "testdata")
+	out := filepath.Join(t.TempDir(), "out.p12")
+
+	// a private key entry without certificates
+	if err := runMain(t, "convert", "-in-pass", "password", "-to", "p12", "-out", out,
+		filepath.Join(testdata, "keystore-go-nochain.jks"),
+	); err == nil {
+		t.Error("no certificate: no error")
+	}
+
+	if err := runMain(t, "convert", "-in-pass", "password", "-to", "p12", "-out", out,
+		filepath.Join(testdata, "certigo-example-leaf.jceks"),
+	); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err = crypthlp.ParseP12Bytes(t.Context(), b, "password"); err != nil {
+		t.Errorf("decode the converted: %+v", err)
+	}
+}
@@ -0,0 +1,525 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+// Use of this source code is governed by an Apache 2.0
+// license that can be found in the LICENSE file.
+
+package crypthlp
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf16"
+)
+
+// Magic numbers of the Java keystore formats.
+const (
+	MagicJKS   = 0xFEEDFEED
+	MagicJCEKS = 0xCECECECE
+)
+
+const (
+	jksVersion = 2
+
+	jksTagPrivateKey  = 1
+	jksTagTrustedCert = 2
+	jksTagSecretKey   = 3
+
+	// jceksIterations is the PBEWithMD5AndTripleDES iteration count used by keytool.
+	jceksIterations = 200_000
+	// jceksMaxIterations is the maximal accepted iteration count, as it comes from the (untrusted) keystore.
+	jceksMaxIterations = 5 * jceksIterations
+)
+
+var (
+	ErrKeyStoreIntegrity = errors.New("keystore password was incorrect or the keystore is corrupted")
+	ErrKeyStoreFormat    = errors.New("not a JKS/JCEKS keystore")
+)
+
+var (
+	// oidJKSKeyProtector is Sun's proprietary key protection algorithm of JKS.
+	oidJKSKeyProtector = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 17, 1, 1}
+	// oidPBEWithMD5AndTripleDES is Sun's key protection algorithm of JCEKS.
+	oidPBEWithMD5AndTripleDES = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 19, 1}
+)
+
+// KeyStoreEntry is an entry of a Java keystore.
+type KeyStoreEntry struct {
+	// Created is the creation date of the entry (now if zero when encoding).
+	Created time.Time
+	// PrivateKey is nil for trusted certificate entries.
+	PrivateKey any
+	Alias      string
+	// Chain is the certificate chain, leaf first, for private key entries,
+	// and the one certificate for trusted certificate entries.
+	Chain []*x509.Certificate
+}
+
+// NewKeyStoreEntries returns a private key entry for the key, leaf and CA chain,
+// and a trusted certificate entry for each CA (named alias-ca1, alias-ca2...).
+func NewKeyStoreEntries(alias string, privateKey any, leaf *x509.Certificate, cas []*x509.Certificate) []KeyStoreEntry {
+	now := time.Now()
+	entries := make([]KeyStoreEntry, 0, 1+len(cas))
+	entries = append(entries, KeyStoreEntry{
+		Created: now, PrivateKey: privateKey, Alias: alias,
+		Chain: append([]*x509.Certificate{leaf}, cas...),
+	})
+	for i, ca := range cas {
+		entries = append(entries, KeyStoreEntry{
+			Created: now, Alias: fmt.Sprintf("%s-ca%d", alias, i+1),
+			Chain: []*x509.Certificate{ca},
+		})
+	}
+	return entries
+}
+
+// EncodeJKS encodes the entries as a JKS keystore,
+// protecting both the keystore and the private keys with the password.
+func EncodeJKS(password string, entries ...KeyStoreEntry) ([]byte, error) {
+	return encodeKeyStore(MagicJKS, password, entries)
+}
+
+// EncodeJCEKS encodes the entries as a JCEKS keystore,
+// protecting both the keystore and the private keys (with PBEWithMD5AndTripleDES) with the password.
+func EncodeJCEKS(password string, entries ...KeyStoreEntry) ([]byte, error) {
+	return encodeKeyStore(MagicJCEKS, password, entries)
+}
+
+func encodeKeyStore(magic uint32, password string, entries []KeyStoreEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	w := jksWriter{w: &buf}
+	w.uint32(magic)
+	w.uint32(jksVersion)
+	w.uint32(uint32(len(entries)))
+	seen := make(map[string]struct{}, len(entries))
+	for _, e := range entries {
+		if _, ok := seen[e.Alias]; ok {
+			return nil, fmt.Errorf("duplicate alias %q", e.Alias)
+		}
+		seen[e.Alias] = struct{}{}
+		if len(e.Chain) == 0 {
+			return nil, fmt.Errorf("%q: no certificate", e.Alias)
+		}
+		created := e.Created
+		if created.IsZero() {
+			created = time.Now()
+		}
+		if e.PrivateKey == nil {
+			w.uint32(jksTagTrustedCert)
+			w.utf(e.Alias)
+			w.uint64(uint64(created.UnixMilli()))
+			w.cert(e.Chain[0])
+			continue
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(e.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", e.Alias, err)
+		}
+		var protected []byte
+		if magic == MagicJCEKS {
+			protected, err = jceksProtect(der, password)
+		} else {
+			protected, err = jksProtect(der, password)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", e.Alias, err)
+		}
+		w.uint32(jksTagPrivateKey)
+		w.utf(e.Alias)
+		w.uint64(uint64(created.UnixMilli()))
+		w.bytes(protected)
+		w.uint32(uint32(len(e.Chain)))
+		for _, c := range e.Chain {
+			w.cert(c)
+		}
+	}
+	if w.err != nil {
+		return nil, w.err
+	}
+	buf.Write(keyStoreDigest(password, buf.Bytes()))
+	return buf.Bytes(), nil
+}
+
+// DecodeKeyStore decodes the JKS or JCEKS keystore,
+// checking its integrity and recovering the private keys with the password.
+func DecodeKeyStore(data []byte, password string) ([]KeyStoreEntry, error) {
+	return DecodeKeyStoreKeyPassword(data, password, password)
+}
+
+// DecodeKeyStoreKeyPassword decodes the JKS or JCEKS keystore,
+// checking its integrity with the store password and recovering the private keys with the key password
+// (keytool's -storepass and -keypass).
+func DecodeKeyStoreKeyPassword(data []byte, password, keyPassword string) ([]KeyStoreEntry, error) {
+	if len(data) < 12+sha1.Size {
+		return nil, ErrKeyStoreFormat
+	}
+	magic := binary.BigEndian.Uint32(data)
+	if magic != MagicJKS && magic != MagicJCEKS {
+		return nil, ErrKeyStoreFormat
+	}
+	body, digest := data[:len(data)-sha1.Size], data[len(data)-sha1.Size:]
+	if subtle.ConstantTimeCompare(keyStoreDigest(password, body), digest) != 1 {
+		return nil, ErrKeyStoreIntegrity
+	}
+	r := jksReader{b: body[4:]}
+	if v := r.uint32(); v != 1 && v != jksVersion {
+		return nil, fmt.Errorf("unsupported keystore version %d", v)
+	} else if v == 1 {
+		r.noCertType = true
+	}
+	n := r.uint32()
+	entries := make([]KeyStoreEntry, 0, min(n, 1024))
+	for range n {
+		if r.err != nil {
+			break
+		}
+		tag := r.uint32()
+		e := KeyStoreEntry{Alias: r.utf()}
+		e.Created = time.UnixMilli(int64(r.uint64()))
+		switch tag {
+		case jksTagTrustedCert:
+			c := r.cert()
+			if r.err != nil {
+				return entries, r.err
+			}
+			e.Chain = []*x509.Certificate{c}
+
+		case jksTagPrivateKey:
+			protected := r.bytes()
+			m := r.uint32()
+			for range m {
+				c := r.cert()
+				if r.err != nil {
+					return entries, r.err
+				}
+				e.Chain = append(e.Chain, c)
+			}
+			if r.err != nil {
+				return entries, r.err
+			}
+			if len(e.Chain) == 0 {
+				return entries, fmt.Errorf("%q: no certificate", e.Alias)
+			}
+			der, err := recoverKey(protected, keyPassword)
+			if err != nil {
+				return entries, fmt.Errorf("%q: %w", e.Alias, err)
+			}
+			if e.PrivateKey, err = x509.ParsePKCS8PrivateKey(der); err != nil {
+				return entries, fmt.Errorf("%q: %w", e.Alias, err)
+			}
+
+		case jksTagSecretKey:
+			return entries, fmt.Errorf("%q: secret key entries are not supported", e.Alias)
+		default:
+			return entries, fmt.Errorf("%q: unknown entry tag %d", e.Alias, tag)
+		}
+		entries = append(entries, e)
+	}
+	return entries, r.err
+}
+
+// keyStoreDigest is the integrity check of the keystore:
+// SHA1(password as UTF-16BE || "Mighty Aphrodite" || data).
+func keyStoreDigest(password string, data []byte) []byte {
+	h := sha1.New()
+	h.Write(passwordUTF16BE(password))
+	h.Write([]byte("Mighty Aphrodite"))
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func passwordUTF16BE(password string) []byte {
+	u := utf16.Encode([]rune(password))
+	b := make([]byte, 2*len(u))
+	for i, c := range u {
+		binary.BigEndian.PutUint16(b[2*i:], c)
+	}
+	return b
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+// jksProtect protects the key with Sun's JKS key protector:
+// salt || key XOR keystream || SHA1(password || key),
+// where the keystream is the chain of SHA1(password || previous), starting with the salt.
+func jksProtect(key []byte, password string) ([]byte, error) {
+	salt := make([]byte, sha1.Size)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	pw := passwordUTF16BE(password)
+	out := make([]byte, 0, len(salt)+len(key)+sha1.Size)
+	out = append(out, salt...)
+	out = append(out, key...)
+	jksXOR(out[len(salt):], pw, salt)
+	out = append(out, jksCheck(pw, key)...)
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidJKSKeyProtector, Parameters: asn1.NullRawValue},
+		EncryptedData: out,
+	})
+}
+
+func jksXOR(dst, pw, salt []byte) {
+	h := sha1.New()
+	digest := salt
+	for i := 0; i < len(dst); i += sha1.Size {
+		h.Reset()
+		h.Write(pw)
+		h.Write(digest)
+		digest = h.Sum(nil)
+		subtle.XORBytes(dst[i:], dst[i:], digest)
+	}
+}
+
+func jksCheck(pw, key []byte) []byte {
+	h := sha1.New()
+	h.Write(pw)
+	h.Write(key)
+	return h.Sum(nil)
+}
+
+// jceksProtect protects the key with PBEWithMD5AndTripleDES.
+func jceksProtect(key []byte, password string) ([]byte, error) {
+	params := pbeParams{Salt: make([]byte, 8), Iterations: jceksIterations}
+	if _, err := rand.Read(params.Salt); err != nil {
+		return nil, err
+	}
+	block, iv, err := pbeMD5TripleDES(password, params)
+	if err != nil {
+		return nil, err
+	}
+	padLen := des.BlockSize - len(key)%des.BlockSize
+	data := append(append(make([]byte, 0, len(key)+padLen), key...), bytes.Repeat([]byte{byte(padLen)}, padLen)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+	paramsDER, err := asn1.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBEWithMD5AndTripleDES, Parameters: asn1.RawValue{FullBytes: paramsDER}},
+		EncryptedData: data,
+	})
+}
+
+// pbeMD5TripleDES derives the DES-EDE3 cipher and IV the way Sun's PBEWithMD5AndTripleDES does:
+// each half of the salt is hashed iteratively with the password,
+// the 32 bytes of the two digests are the 24 bytes key and the 8 bytes IV.
+func pbeMD5TripleDES(password string, params pbeParams) (cipher.Block, []byte, error) {
+	if len(params.Salt) != 8 {
+		return nil, nil, fmt.Errorf("salt must be 8 bytes, got %d", len(params.Salt))
+	}
+	if params.Iterations < 1 || params.Iterations > jceksMaxIterations {
+		return nil, nil, fmt.Errorf("iteration count %d is not in [1, %d]", params.Iterations, jceksMaxIterations)
+	}
+	salt := bytes.Clone(params.Salt)
+	if bytes.Equal(salt[:4], salt[4:]) {
+		// the same halves would produce the same keys, so the first half is reversed
+		salt[0], salt[1], salt[2], salt[3] = salt[3], salt[2], salt[1], salt[0]
+	}
+	pw := make([]byte, 0, len(password))
+	for _, r := range password {
+		pw = append(pw, byte(r))
+	}
+	derived := make([]byte, 0, 2*md5.Size)
+	for half := range 2 {
+		digest := salt[half*4 : half*4+4]
+		for range params.Iterations {
+			h := md5.New()
+			h.Write(digest)
+			h.Write(pw)
+			digest = h.Sum(nil)
+		}
+		derived = append(derived, digest...)
+	}
+	block, err := des.NewTripleDESCipher(derived[:24])
+	return block, derived[24:], err
+}
+
+// recoverKey returns the PKCS#8 key protected by jksProtect or jceksProtect.
+func recoverKey(protected []byte, password string) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(protected, &info); err != nil {
+		return nil, err
+	}
+	data := info.EncryptedData
+	switch alg := info.Algorithm.Algorithm; {
+	case alg.Equal(oidJKSKeyProtector):
+		if len(data) < 2*sha1.Size {
+			return nil, fmt.Errorf("protected key too short (%d)", len(data))
+		}
+		pw := passwordUTF16BE(password)
+		salt, check := data[:sha1.Size], data[len(data)-sha1.Size:]
+		key := bytes.Clone(data[sha1.Size : len(data)-sha1.Size])
+		jksXOR(key, pw, salt)
+		if subtle.ConstantTimeCompare(jksCheck(pw, key), check) != 1 {
+			return nil, ErrKeyStoreIntegrity
+		}
+		return key, nil
+
+	case alg.Equal(oidPBEWithMD5AndTripleDES):
+		var params pbeParams
+		if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+			return nil, err
+		}
+		if len(data) == 0 || len(data)%des.BlockSize != 0 {
+			return nil, fmt.Errorf("protected key length %d is not a multiple of %d", len(data), des.BlockSize)
+		}
+		block, iv, err := pbeMD5TripleDES(password, params)
+		if err != nil {
+			return nil, err
+		}
+		key := make([]byte, len(data))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(key, data)
+		padLen := int(key[len(key)-1])
+		if padLen == 0 || padLen > des.BlockSize ||
+			!bytes.Equal(key[len(key)-padLen:], bytes.Repeat([]byte{byte(padLen)}, padLen)) {
+			return nil, ErrKeyStoreIntegrity
+		}
+		return key[:len(key)-padLen], nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key protection algorithm %s", alg)
+	}
+}
+
+type jksWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *jksWriter) write(p []byte) {
+	if w.err == nil {
+		_, w.err = w.w.Write(p)
+	}
+}
+func (w *jksWriter) uint32(n uint32) { w.write(binary.BigEndian.AppendUint32(nil, n)) }
+func (w *jksWriter) uint64(n uint64) { w.write(binary.BigEndian.AppendUint64(nil, n)) }
+func (w *jksWriter) bytes(p []byte) {
+	w.uint32(uint32(len(p)))
+	w.write(p)
+}
+
+// utf writes the string as Java's DataOutput.writeUTF: length-prefixed modified UTF-8.
+func (w *jksWriter) utf(s string) {
+	b := make([]byte, 2, 2+len(s))
+	for _, c := range utf16.Encode([]rune(s)) {
+		switch {
+		case c != 0 && c < 0x80:
+			b = append(b, byte(c))
+		case c < 0x800:
+			b = append(b, 0xc0|byte(c>>6), 0x80|byte(c&0x3f))
+		default:
+			b = append(b, 0xe0|byte(c>>12), 0x80|byte(c>>6&0x3f), 0x80|byte(c&0x3f))
+		}
+	}
+	if len(b)-2 > 0xffff {
+		if w.err == nil {
+			w.err = fmt.Errorf("string too long (%d)", len(b)-2)
+		}
+		return
+	}
+	binary.BigEndian.PutUint16(b, uint16(len(b)-2))
+	w.write(b)
+}
+
+func (w *jksWriter) cert(c *x509.Certificate) {
+	w.utf("X.509")
+	w.bytes(c.Raw)
+}
+
+type jksReader struct {
+	err        error
+	b          []byte
+	noCertType bool
+}
+
+func (r *jksReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.b) < n {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	p := r.b[:n]
+	r.b = r.b[n:]
+	return p
+}
+func (r *jksReader) uint32() uint32 {
+	if p := r.next(4); p != nil {
+		return binary.BigEndian.Uint32(p)
+	}
+	return 0
+}
+func (r *jksReader) uint64() uint64 {
+	if p := r.next(8); p != nil {
+		return binary.BigEndian.Uint64(p)
+	}
+	return 0
+}
+func (r *jksReader) bytes() []byte { return r.next(int(r.uint32())) }
+
+// utf reads a string written by Java's DataOutput.writeUTF.
+func (r *jksReader) utf() string {
+	var n int
+	if p := r.next(2); p != nil {
+		n = int(binary.BigEndian.Uint16(p))
+	}
+	p := r.next(n)
+	u := make([]uint16, 0, len(p))
+	for i := 0; i < len(p); {
+		switch c := p[i]; {
+		case c < 0x80:
+			u = append(u, uint16(c))
+			i++
+		case c&0xe0 == 0xc0 && i+1 < len(p):
+			u = append(u, uint16(c&0x1f)<<6|uint16(p[i+1]&0x3f))
+			i += 2
+		case c&0xf0 == 0xe0 && i+2 < len(p):
+			u = append(u, uint16(c&0x0f)<<12|uint16(p[i+1]&0x3f)<<6|uint16(p[i+2]&0x3f))
+			i += 3
+		default:
+			if r.err == nil {
+				r.err = fmt.Errorf("bad modified UTF-8 %q", p)
+			}
+			return ""
+		}
+	}
+	return string(utf16.Decode(u))
+}
+
+func (r *jksReader) cert() *x509.Certificate {
+	if !r.noCertType {
+		if typ := r.utf(); r.err == nil && typ != "X.509" {
+			r.err = fmt.Errorf("unsupported certificate type %q", typ)
+		}
+	}
+	der := r.bytes()
+	if r.err != nil {
+		return nil
+	}
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		r.err = err
+	}
+	return c
+}
@@ -0,0 +1,357 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+// Use of this source code is governed by an Apache 2.0
+// license that can be found in the LICENSE file.
+
+package crypthlp_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tgulacsi/go/crypthlp"
+)
+
+func newTestChain(t *testing.T) (*ecdsa.PrivateKey, *x509.Certificate, []*x509.Certificate) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Truncate(time.Second)
+	caTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "Test CA"},
+		NotBefore: now.Add(-time.Hour), NotAfter: now.Add(24 * time.Hour),
+		IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "leaf"},
+		NotBefore: now.Add(-time.Hour), NotAfter: now.Add(time.Hour),
+		KeyUsage: x509.KeyUsageDigitalSignature,
+	}
+	if der, err = x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey); err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, leaf, []*x509.Certificate{ca}
+}
+
+func TestKeyStoreRoundTrip(t *testing.T) {
+	key, leaf, cas := newTestChain(t)
+	const password = "changeit"
+	entries := crypthlp.NewKeyStoreEntries("árvíztűrő", key, leaf, cas)
+	for _, tC := range []struct {
+		Name   string
+		Encode func(string, ...crypthlp.KeyStoreEntry) ([]byte, error)
+	}{
+		{"JKS", crypthlp.EncodeJKS},
+		{"JCEKS", crypthlp.EncodeJCEKS},
+	} {
+		t.Run(tC.Name, func(t *testing.T) {
+			b, err := tC.Encode(password, entries...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := crypthlp.DecodeKeyStore(b, password)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(entries) {
+				t.Fatalf("got %d entries, wanted %d", len(got), len(entries))
+			}
+			for i, e := range got {
+				want := entries[i]
+				if e.Alias != want.Alias {
+					t.Errorf("%d. got alias %q, wanted %q", i, e.Alias, want.Alias)
+				}
+				if !e.Created.Equal(want.Created.Truncate(time.Millisecond)) {
+					t.Errorf("%d. got created %s, wanted %s", i, e.Created, want.Created)
+				}
+				if len(e.Chain) != len(want.Chain) {
+					t.Errorf("%d. got %d certs, wanted %d", i, len(e.Chain), len(want.Chain))
+				} else {
+					for j, c := range e.Chain {
+						if !c.Equal(want.Chain[j]) {
+							t.Errorf("%d. cert %d mismatch", i, j)
+						}
+					}
+				}
+				if want.PrivateKey == nil {
+					if e.PrivateKey != nil {
+						t.Errorf("%d. got key for a trusted certificate entry", i)
+					}
+				} else if k, ok := e.PrivateKey.(*ecdsa.PrivateKey); !ok || !k.Equal(key) {
+					t.Errorf("%d. key mismatch: %T", i, e.PrivateKey)
+				}
+			}
+
+			if _, err = crypthlp.DecodeKeyStore(b, "bad"); !errors.Is(err, crypthlp.ErrKeyStoreIntegrity) {
+				t.Errorf("bad password: got %+v, wanted %v", err, crypthlp.ErrKeyStoreIntegrity)
+			}
+			b[len(b)/2] ^= 1
+			if _, err = crypthlp.DecodeKeyStore(b, password); !errors.Is(err, crypthlp.ErrKeyStoreIntegrity) {
+				t.Errorf("tampered: got %+v, wanted %v", err, crypthlp.ErrKeyStoreIntegrity)
+			}
+		})
+	}
+}
+
+func TestJCEKSIterations(t *testing.T) {
+	key, leaf, cas := newTestChain(t)
+	b, err := crypthlp.EncodeJCEKS("changeit", crypthlp.NewKeyStoreEntries("test", key, leaf, cas)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// replace the iteration count (INTEGER 200000) with 0x7fffff, and fix the digest
+	body := b[:len(b)-sha1.Size]
+	i := bytes.Index(body, []byte{0x02, 0x03, 0x03, 0x0d, 0x40})
+	if i < 0 {
+		t.Fatal("no iteration count found")
+	}
+	copy(body[i+2:], []byte{0x7f, 0xff, 0xff})
+	h := sha1.New()
+	for _, c := range "changeit" {
+		h.Write([]byte{0, byte(c)})
+	}
+	h.Write([]byte("Mighty Aphrodite"))
+	h.Write(body)
+	b = h.Sum(body)
+	if _, err = crypthlp.DecodeKeyStore(b, "changeit"); err == nil || !strings.Contains(err.Error(), "iteration count") {
+		t.Errorf("got %+v, wanted iteration count error", err)
+	}
+}
+
+func TestReadJKSBytes(t *testing.T) {
+	key, leaf, cas := newTestChain(t)
+	b, err := crypthlp.EncodeJKS("secret", crypthlp.NewKeyStoreEntries("test", key, leaf, cas)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bag, err := crypthlp.ReadJKSBytes(t.Context(), b, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, cert, gotCAs, err := bag.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k, ok := priv.(*ecdsa.PrivateKey); !ok || !k.Equal(key) {
+		t.Errorf("key mismatch: %T", priv)
+	}
+	if !cert.Equal(leaf) {
+		t.Error("leaf mismatch")
+	}
+	if len(gotCAs) != 1 || !gotCAs[0].Equal(cas[0]) {
+		t.Errorf("got CAs %v", gotCAs)
+	}
+}
+
+// TestKeyStoreKeytool reads keystores written by keytool.
+func TestKeyStoreKeytool(t *testing.T) {
+	for _, tC := range []struct {
+		File, StorePass, KeyPass string
+		Cert, Key                string
+		Alias                    string
+	}{
+		{File: "certigo-example-leaf.jceks", StorePass: "password", KeyPass: "password",
+			Cert: "certigo-example-leaf.crt", Alias: "example-leaf"},
+		{File: "certigo-example-elliptic-sha1.jceks", StorePass: "password", KeyPass: "password",
+			Cert: "certigo-example-elliptic-sha1.crt", Key: "certigo-example-elliptic-sha1.key", Alias: "example-elliptic-sha1"},
+		{File: "keystore-go-keypass.jks", StorePass: "password", KeyPass: "keypassword",
+			Key: "keystore-go-keypass.key", Alias: "alias"},
+	} {
+		t.Run(tC.File, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("testdata", tC.File))
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries, err := crypthlp.DecodeKeyStoreKeyPassword(b, tC.StorePass, tC.KeyPass)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, wanted 1", len(entries))
+			}
+			e := entries[0]
+			if e.Alias != tC.Alias || e.PrivateKey == nil || len(e.Chain) == 0 {
+				t.Fatalf("got %q with key %T and %d certs", e.Alias, e.PrivateKey, len(e.Chain))
+			}
+			if tC.Cert != "" {
+				if want := readPEM(t, tC.Cert, "CERTIFICATE"); !bytes.Equal(e.Chain[0].Raw, want) {
+					t.Error("certificate mismatch")
+				}
+			}
+			if !e.Chain[0].PublicKey.(interface{ Equal(crypto.PublicKey) bool }).Equal(
+				e.PrivateKey.(crypto.Signer).Public()) {
+				t.Error("the private key does not match the certificate")
+			}
+			if tC.Key != "" {
+				var want any
+				if der := readPEM(t, tC.Key, "EC PRIVATE KEY"); der != nil {
+					want, err = x509.ParseECPrivateKey(der)
+				} else {
+					want, err = x509.ParsePKCS8PrivateKey(readPEM(t, tC.Key, "PRIVATE KEY"))
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !want.(interface{ Equal(crypto.PrivateKey) bool }).Equal(e.PrivateKey) {
+					t.Error("private key mismatch")
+				}
+			}
+			if tC.StorePass == tC.KeyPass {
+				bag, err := crypthlp.ReadJKSBytes(t.Context(), b, tC.StorePass)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(bag.Cert, e.Chain[0].Raw) {
+					t.Error("ReadJKSBytes: certificate mismatch")
+				}
+			}
+			if _, err = crypthlp.DecodeKeyStore(b, "bad"); !errors.Is(err, crypthlp.ErrKeyStoreIntegrity) {
+				t.Errorf("bad password: got %+v", err)
+			}
+		})
+	}
+
+	// a private key entry without certificates
+	b, err := os.ReadFile(filepath.Join("testdata", "keystore-go-nochain.jks"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = crypthlp.ReadJKSBytes(t.Context(), b, "password"); err == nil {
+		t.Error("no certificate: no error")
+	}
+	if _, err = crypthlp.DecodeKeyStore(b, "password"); err == nil {
+		t.Error("DecodeKeyStore no certificate: no error")
+	}
+}
+
+// TestKeytoolAccepts checks that keytool reads the keystores written by EncodeKeyStore.
+func TestKeytoolAccepts(t *testing.T) {
+	if _, err := exec.LookPath("keytool"); err != nil {
+		t.Skip(err)
+	}
+	key, leaf, cas := newTestChain(t)
+	for _, tC := range []struct {
+		Type   string
+		Encode func(string, ...crypthlp.KeyStoreEntry) ([]byte, error)
+	}{
+		{"JKS", crypthlp.EncodeJKS},
+		{"JCEKS", crypthlp.EncodeJCEKS},
+	} {
+		t.Run(tC.Type, func(t *testing.T) {
+			b, err := tC.Encode("changeit", crypthlp.NewKeyStoreEntries("test", key, leaf, cas)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fn := filepath.Join(t.TempDir(), "test."+strings.ToLower(tC.Type))
+			if err = os.WriteFile(fn, b, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			// -v lists the key entries only if the key can be recovered
+			cmd := exec.CommandContext(t.Context(), "keytool", "-list", "-v",
+				"-storetype", tC.Type, "-keystore", fn, "-storepass", "changeit")
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("%s: %+v", out, err)
+			}
+			t.Logf("%s", out)
+			for _, want := range []string{"Your keystore contains 2 entries", "PrivateKeyEntry", "trustedCertEntry", "CN=leaf"} {
+				if !strings.Contains(string(out), want) {
+					t.Errorf("keytool output does not contain %q", want)
+				}
+			}
+			// export the key, too
+			cmd = exec.CommandContext(t.Context(), "keytool", "-importkeystore",
+				"-srcstoretype", tC.Type, "-srckeystore", fn, "-srcstorepass", "changeit",
+				"-destkeystore", fn+".p12", "-deststoretype", "PKCS12", "-deststorepass", "changeit", "-noprompt")
+			if out, err = cmd.CombinedOutput(); err != nil {
+				t.Fatalf("%s: %+v", out, err)
+			}
+		})
+	}
+}
+
+func readPEM(t *testing.T, fn, typ string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", fn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		var block *pem.Block
+		if block, b = pem.Decode(b); block == nil {
+			return nil
+		}
+		if block.Type == typ {
+			return block.Bytes
+		}
+	}
+}
+
+func TestEncodeP12(t *testing.T) {
+	key, leaf, cas := newTestChain(t)
+	b, err := crypthlp.EncodeP12(key, leaf, cas, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, cert, gotCAs, err := crypthlp.ParseP12Bytes(t.Context(), b, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k, ok := priv.(*ecdsa.PrivateKey); !ok || !k.Equal(key) {
+		t.Errorf("key mismatch: %T", priv)
+	}
+	if !cert.Equal(leaf) {
+		t.Error("leaf mismatch")
+	}
+	if len(gotCAs) != 1 || !gotCAs[0].Equal(cas[0]) {
+		t.Errorf("got CAs %v", gotCAs)
+	}
+
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip(err)
+	}
+	cmd := exec.CommandContext(t.Context(), "openssl", "pkcs12", "-info", "-noout", "-passin", "pass:secret")
+	cmd.Stdin = strings.NewReader(string(b))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %+v", out, err)
+	}
+	t.Logf("%s", out)
+	for _, want := range []string{"MAC: sha256", "AES-256-CBC"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("openssl info does not contain %q", want)
+		}
+	}
+}
@@ -24,13 +24,22 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/tgulacsi/go/term"
 	"software.sslmate.com/src/go-pkcs12"
 )
 
 // ReadPassword: see man openssl-passphrase-options
+//
+// Additionally "prompt" (or "prompt:text") asks for the password on the terminal, without echo.
 func ReadPassword(s string) (string, error) {
 	var fh *os.File
-	if s == "stdin" {
+	if s == "prompt" || strings.HasPrefix(s, "prompt:") {
+		prompt := strings.TrimPrefix(strings.TrimPrefix(s, "prompt"), ":")
+		if prompt == "" {
+			prompt = "Password: "
+		}
+		return term.NewConsoleOf(os.Stdin, os.Stderr).ReadPassword(prompt)
+	} else if s == "stdin" {
 		fh = os.Stdin
 	} else if typ, val, ok := strings.Cut(s, ":"); !ok {
 		return s, nil
@@ -108,7 +117,7 @@ func (bag Bag) ToTLSCertificate(caCertPool *x509.CertPool) (tls.Certificate, err
 
 // ParseP12Bytes parses the private key and certificate from the .p12 file using openssl.
 func ParseP12Bytes(ctx context.Context, p12Bytes []byte, p12Password string) (privateKey any, certificate *x509.Certificate, CAs []*x509.Certificate, err error) {
-	if privateKey, certificate, err = pkcs12.Decode(p12Bytes, p12Password); err == nil {
+	if privateKey, certificate, CAs, err = pkcs12.DecodeChain(p12Bytes, p12Password); err == nil {
 		return
 	}
 
@@ -143,6 +152,12 @@ func (bag Bag) Parse() (privateKey any, certificate *x509.Certificate, CAs []*x5
 	return
 }
 
+// EncodeP12 encodes the private key, leaf certificate and CA chain as a modern PKCS#12 file:
+// AES-256-CBC with PBKDF2-HMAC-SHA-256 for encryption, HMAC-SHA-256 for the MAC.
+func EncodeP12(privateKey any, leaf *x509.Certificate, CAs []*x509.Certificate, p12Password string) ([]byte, error) {
+	return pkcs12.Modern2023.Encode(privateKey, leaf, CAs, p12Password)
+}
+
 // ReadP12Bytes splits the give .p12 bytes to privateKey and certificate bytes (as returned by openssl).
 func ReadP12Bytes(ctx context.Context, p12Bytes []byte, p12Password string) (Bag, error) {
 	p12Password, err := ReadPassword(p12Password)
@@ -263,8 +278,32 @@ func ConvertP12(ctx context.Context, p12FileName, p12Password string) (certPEM,
 	return certPEM, caPEM, keyPEM, nil
 }
 
-// ReadJKSBytes splits the give .p12 bytes to privateKey and certificate bytes (as returned by openssl).
+// ReadJKSBytes splits the give .jks bytes to privateKey and certificate bytes (as returned by openssl).
+//
+// Uses DecodeKeyStore first, falls back to keytool.
 func ReadJKSBytes(ctx context.Context, jksBytes []byte, jksPassword string) (Bag, error) {
+	if entries, err := DecodeKeyStore(jksBytes, jksPassword); err != nil {
+		slog.Debug("DecodeKeyStore", "error", err)
+	} else {
+		for _, e := range entries {
+			if e.PrivateKey == nil {
+				continue
+			}
+			var bag Bag
+			if len(e.Chain) == 0 {
+				return bag, fmt.Errorf("%q: private key entry without certificate", e.Alias)
+			}
+			if bag.PrivateKey, err = x509.MarshalPKCS8PrivateKey(e.PrivateKey); err != nil {
+				return bag, err
+			}
+			bag.Cert = e.Chain[0].Raw
+			for _, c := range e.Chain[1:] {
+				bag.CAs = append(bag.CAs, c.Raw...)
+			}
+			return bag, nil
+		}
+	}
+
 	fh, err := os.CreateTemp("", "jks-*.jks")
 	if err != nil {
 		return Bag{}, err
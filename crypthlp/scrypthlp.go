@@ -35,18 +35,12 @@ func GenKey(password []byte, saltLen, keyLen int, timeout time.Duration,
 	if err != nil {
 		return Key{}, err
 	}
-	key := Key{Salt: salt, R: 8, P: 1, L2N: minL2N}
+	key := Key{Salt: salt, R: 8, P: 1}
 	dursMu.Lock()
 	defer dursMu.Unlock()
-	for i, d := range durs {
-		if d < timeout {
-			key.L2N = minL2N + uint(i)
-			continue
-		}
-		if d > timeout {
-			key.L2N--
-		}
-		break
+	var over bool
+	if key.L2N, over = workFactor(timeout); over {
+		key.L2N--
 	}
 	deadline := time.Now().Add(timeout)
 	for now := time.Now(); now.Before(deadline); {
@@ -74,6 +68,42 @@ func GenKey(password []byte, saltLen, keyLen int, timeout time.Duration,
 	return key, nil
 }
 
+// workFactor returns the biggest scrypt work factor (log2 N) measured to take less than timeout,
+// and whether the next one has been measured to take more.
+//
+// dursMu must be held.
+func workFactor(timeout time.Duration) (l2n uint, over bool) {
+	l2n = minL2N
+	for i, d := range durs {
+		if d < timeout {
+			l2n = minL2N + uint(i)
+			continue
+		}
+		return l2n, d > timeout
+	}
+	return l2n, false
+}
+
+// calibrateWorkFactor returns the biggest scrypt work factor (log2 N), at most maxL2N,
+// which takes less than timeout - measuring only the work factors not measured yet (by GenKey or a previous call).
+func calibrateWorkFactor(timeout time.Duration, maxL2N uint) (uint, error) {
+	dursMu.Lock()
+	defer dursMu.Unlock()
+	var zero [16]byte
+	for {
+		l2n, over := workFactor(timeout)
+		next := minL2N + uint(len(durs))
+		if over || l2n >= maxL2N || next > maxL2N {
+			return min(l2n, maxL2N), nil
+		}
+		start := time.Now()
+		if _, err := scrypt.Key(zero[:], zero[:], 1<<next, 8, 1, 32); err != nil {
+			return l2n, err
+		}
+		durs = append(durs, time.Since(start))
+	}
+}
+
 type Key struct {
 	Bytes []byte `json:"-"`
 	Salt  []byte
@@ -5,9 +5,11 @@
 package crypthlp
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"time"
@@ -23,15 +25,54 @@ func Open(filename string, passphrase []byte) (Key, io.Reader, error) {
 	return OpenReader(fh, passphrase)
 }
 
+// OpenReader reads the header, derives the key from the passphrase, and returns
+// the reader of the decrypted data.
+//
+// Both the streaming (see Key.CreateStreamWriter) and the old, one-box format is supported:
+// the streaming format is decrypted chunk-by-chunk while reading,
+// the old format is read and opened at once.
+//
+// Passphrase encrypted age files (see CreateAgePassphraseWriter) are decrypted, too -
+// for those the returned Key is empty.
 func OpenReader(r io.Reader, passphrase []byte) (Key, io.Reader, error) {
+	br := bufio.NewReader(r)
+	if isAge(br) {
+		id, err := AgeScryptIdentity(passphrase)
+		if err != nil {
+			return Key{}, nil, err
+		}
+		ar, err := OpenAgeReader(br, id)
+		return Key{}, ar, err
+	}
+	r = br
 	dec := json.NewDecoder(r)
-	var key Key
-	if err := dec.Decode(&key); err != nil {
-		return key, nil, err
+	var hdr streamHeader
+	if err := dec.Decode(&hdr); err != nil {
+		return hdr.Key, nil, err
+	}
+	key := hdr.Key
+	if hdr.Version != 0 {
+		if hdr.Version != streamVersion {
+			return key, nil, fmt.Errorf("unknown version %d", hdr.Version)
+		}
+		// check before the costly key derivation
+		if err := checkChunkSize(hdr.ChunkSize); err != nil {
+			return key, nil, err
+		}
 	}
 	if err := key.Populate(passphrase, 32); err != nil {
 		return key, nil, err
 	}
+	if hdr.Version != 0 {
+		br := bufio.NewReader(io.MultiReader(dec.Buffered(), r))
+		if c, err := br.ReadByte(); err != nil {
+			return key, nil, err
+		} else if c != '\n' {
+			return key, nil, fmt.Errorf("header must end with a newline, got %q", c)
+		}
+		sr, err := newStreamReader(br, key, hdr.ChunkSize)
+		return key, sr, err
+	}
 	box, err := io.ReadAll(io.MultiReader(dec.Buffered(), r))
 	if err != nil {
 		return key, nil, err
@@ -74,7 +115,15 @@ func CreateWriter(w io.Writer, passphrase []byte, timeout time.Duration) (io.Wri
 	return key.CreateWriter(w)
 }
 
+// CreateWriter writes the header, and returns a WriteCloser which encrypts the data
+// in the streaming format, with DefaultChunkSize chunks (see CreateStreamWriter).
 func (key Key) CreateWriter(w io.Writer) (io.WriteCloser, error) {
+	return key.CreateStreamWriter(w, DefaultChunkSize)
+}
+
+// CreateBoxWriter writes the header, and returns a WriteCloser which buffers all the data,
+// and seals it in one secretbox on Close - this is the old format.
+func (key Key) CreateBoxWriter(w io.Writer) (io.WriteCloser, error) {
 	if err := json.NewEncoder(w).Encode(key); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,236 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+// Use of this source code is governed by an Apache 2.0
+// license that can be found in the LICENSE file.
+
+package crypthlp
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// streamVersion is the version of the chunked streaming format.
+const streamVersion = 2
+
+// DefaultChunkSize is the plaintext size of a chunk of the streaming format.
+var DefaultChunkSize = 64 << 10
+
+// MaxChunkSize is the largest accepted chunk size, as the chunk size comes
+// from the untrusted header, and a chunk is read into memory before authentication.
+const MaxChunkSize = 16 << 20
+
+var (
+	ErrTruncated = errors.New("truncated stream")
+	ErrOpenChunk = errors.New("failed open chunk")
+)
+
+// streamHeader is the JSON header of the streaming format.
+//
+// The old (version 0) format is the Key header followed by one secretbox,
+// with the salt as nonce.
+//
+// The streaming format (version 2) is the header followed by chunks,
+// each sealed with XChaCha20-Poly1305, holding ChunkSize bytes of plaintext
+// (the last one may be shorter).
+// The nonce of the i-th chunk is the salt with i XOR-ed into its last 8 bytes,
+// and the additional data is the final flag (1 for the last chunk, 0 for the others),
+// so a truncated stream fails to open.
+type streamHeader struct {
+	Key
+	Version   int `json:",omitempty"`
+	ChunkSize int `json:",omitempty"`
+}
+
+// CreateStreamWriter writes the header, and returns a WriteCloser which
+// encrypts the data in chunks of chunkSize (DefaultChunkSize if <= 0).
+//
+// Close must be called to write the final chunk.
+func (key Key) CreateStreamWriter(w io.Writer, chunkSize int) (io.WriteCloser, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	if err := checkChunkSize(chunkSize); err != nil {
+		return nil, err
+	}
+	aead, err := key.streamAEAD()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.NewEncoder(w).Encode(streamHeader{Key: key, Version: streamVersion, ChunkSize: chunkSize}); err != nil {
+		return nil, err
+	}
+	return &streamWriter{
+		w: writeCloser{w}, aead: aead, salt: key.Salt,
+		buf:       make([]byte, 0, chunkSize+aead.Overhead()),
+		chunkSize: chunkSize,
+	}, nil
+}
+
+func (key Key) streamAEAD() (cipher.AEAD, error) {
+	if len(key.Salt) != chacha20poly1305.NonceSizeX {
+		return nil, fmt.Errorf("salt must be %d bytes, got %d", chacha20poly1305.NonceSizeX, len(key.Salt))
+	}
+	return chacha20poly1305.NewX(key.Bytes)
+}
+
+// chunkNonce returns the nonce of the i-th chunk.
+func chunkNonce(nonce, salt []byte, i uint64) []byte {
+	nonce = append(nonce[:0], salt...)
+	n := len(nonce) - 8
+	binary.BigEndian.PutUint64(nonce[n:], binary.BigEndian.Uint64(nonce[n:])^i)
+	return nonce
+}
+
+var (
+	adNotFinal = []byte{0}
+	adFinal    = []byte{1}
+)
+
+type streamWriter struct {
+	w         io.WriteCloser
+	aead      cipher.AEAD
+	err       error
+	salt      []byte
+	nonce     []byte
+	buf       []byte
+	counter   uint64
+	chunkSize int
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	if sw.err != nil {
+		return 0, sw.err
+	}
+	var n int
+	for len(p) != 0 {
+		// A full chunk is sealed only when more data arrives,
+		// as the last chunk must be sealed as final.
+		if len(sw.buf) == sw.chunkSize {
+			if sw.err = sw.seal(adNotFinal); sw.err != nil {
+				return n, sw.err
+			}
+		}
+		m := min(len(p), sw.chunkSize-len(sw.buf))
+		sw.buf = append(sw.buf, p[:m]...)
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+func (sw *streamWriter) seal(ad []byte) error {
+	sw.nonce = chunkNonce(sw.nonce, sw.salt, sw.counter)
+	sw.counter++
+	sw.buf = sw.aead.Seal(sw.buf[:0], sw.nonce, sw.buf, ad)
+	_, err := sw.w.Write(sw.buf)
+	sw.buf = sw.buf[:0]
+	return err
+}
+
+// Close seals the final chunk and closes the underlying writer.
+func (sw *streamWriter) Close() error {
+	if sw.err != nil {
+		return sw.err
+	}
+	if sw.err = sw.seal(adFinal); sw.err != nil {
+		return sw.err
+	}
+	sw.err = errors.New("closed")
+	return sw.w.Close()
+}
+
+type streamReader struct {
+	r         *bufio.Reader
+	aead      cipher.AEAD
+	err       error
+	salt      []byte
+	nonce     []byte
+	buf       []byte
+	plainBuf  []byte
+	plain     []byte
+	counter   uint64
+	chunkSize int
+}
+
+func checkChunkSize(chunkSize int) error {
+	if chunkSize <= 0 || chunkSize > MaxChunkSize {
+		return fmt.Errorf("bad chunk size %d (must be between 1 and %d)", chunkSize, MaxChunkSize)
+	}
+	return nil
+}
+
+func newStreamReader(r io.Reader, key Key, chunkSize int) (*streamReader, error) {
+	if err := checkChunkSize(chunkSize); err != nil {
+		return nil, err
+	}
+	aead, err := key.streamAEAD()
+	if err != nil {
+		return nil, err
+	}
+	return &streamReader{
+		r: bufio.NewReader(r), aead: aead, salt: key.Salt,
+		buf:       make([]byte, chunkSize+aead.Overhead()),
+		plainBuf:  make([]byte, 0, chunkSize),
+		chunkSize: chunkSize,
+	}, nil
+}
+
+func (sr *streamReader) Read(p []byte) (int, error) {
+	for len(sr.plain) == 0 {
+		if sr.err != nil {
+			return 0, sr.err
+		}
+		sr.err = sr.next()
+	}
+	n := copy(p, sr.plain)
+	sr.plain = sr.plain[n:]
+	return n, nil
+}
+
+// next reads and opens the next chunk, returning io.EOF after the final chunk.
+func (sr *streamReader) next() error {
+	n, err := io.ReadFull(sr.r, sr.buf)
+	final := false
+	switch err {
+	case nil:
+		if _, err = sr.r.Peek(1); err == io.EOF {
+			final = true
+		} else if err != nil {
+			return err
+		}
+	case io.EOF, io.ErrUnexpectedEOF:
+		final = true
+	default:
+		return err
+	}
+	if n < sr.aead.Overhead() {
+		return fmt.Errorf("%w: after %d chunks", ErrTruncated, sr.counter)
+	}
+	ad := adNotFinal
+	if final {
+		ad = adFinal
+	}
+	sr.nonce = chunkNonce(sr.nonce, sr.salt, sr.counter)
+	// not in place, as a failed Open clears the destination
+	if sr.plain, err = sr.aead.Open(sr.plainBuf[:0], sr.nonce, sr.buf[:n], ad); err != nil {
+		if final {
+			// a non-final chunk sealed, so the stream has been truncated
+			if _, err2 := sr.aead.Open(nil, sr.nonce, sr.buf[:n], adNotFinal); err2 == nil {
+				return fmt.Errorf("%w: after %d chunks", ErrTruncated, sr.counter+1)
+			}
+		}
+		return fmt.Errorf("%w: %d: %w", ErrOpenChunk, sr.counter, err)
+	}
+	sr.counter++
+	if final {
+		return io.EOF
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+// Use of this source code is governed by an Apache 2.0
+// license that can be found in the LICENSE file.
+
+package crypthlp_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/tgulacsi/go/crypthlp"
+)
+
+func TestStream(t *testing.T) {
+	passphrase := []byte("passphrase")
+	salt, err := crypthlp.Salt(24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := crypthlp.Key{Salt: salt, L2N: 10, R: 8, P: 1}
+	if err = key.Populate(passphrase, 32); err != nil {
+		t.Fatal(err)
+	}
+	const chunkSize = 16
+	data, err := crypthlp.Salt(5*chunkSize + 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seal := func(data []byte) []byte {
+		var buf bytes.Buffer
+		w, err := key.CreateStreamWriter(&buf, chunkSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// write in odd pieces
+		for p := data; len(p) != 0; {
+			n := min(len(p), 7)
+			if _, err = w.Write(p[:n]); err != nil {
+				t.Fatal(err)
+			}
+			p = p[n:]
+		}
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	open := func(b []byte) ([]byte, error) {
+		_, r, err := crypthlp.OpenReader(bytes.NewReader(b), passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	}
+
+	for _, n := range []int{0, 1, chunkSize, chunkSize + 1, 2 * chunkSize, len(data)} {
+		got, err := open(seal(data[:n]))
+		if err != nil {
+			t.Fatalf("%d: %+v", n, err)
+		}
+		if !bytes.Equal(got, data[:n]) {
+			t.Errorf("%d: got %x, wanted %x", n, got, data[:n])
+		}
+	}
+
+	sealed := seal(data)
+	hdrLen := bytes.IndexByte(sealed, '\n') + 1
+	chunkLen := chunkSize + 16
+	for _, n := range []int{hdrLen, hdrLen + chunkLen, hdrLen + 3*chunkLen, len(sealed) - 4} {
+		if _, err = open(sealed[:n]); !errors.Is(err, crypthlp.ErrTruncated) && !errors.Is(err, crypthlp.ErrOpenChunk) {
+			t.Errorf("truncated at %d: got %v", n, err)
+		}
+	}
+	if _, err = open(sealed[:hdrLen+2*chunkLen]); !errors.Is(err, crypthlp.ErrTruncated) {
+		t.Errorf("truncated at chunk boundary: got %v, wanted ErrTruncated", err)
+	}
+	tampered := bytes.Clone(sealed)
+	tampered[hdrLen+chunkLen+1] ^= 1
+	if _, err = open(tampered); !errors.Is(err, crypthlp.ErrOpenChunk) {
+		t.Errorf("tampered: got %v, wanted ErrOpenChunk", err)
+	}
+	if _, r, err := crypthlp.OpenReader(bytes.NewReader(sealed), []byte("wrong")); err != nil {
+		t.Fatal(err)
+	} else if _, err = io.ReadAll(r); !errors.Is(err, crypthlp.ErrOpenChunk) {
+		t.Errorf("wrong passphrase: got %v, wanted ErrOpenChunk", err)
+	}
+
+	// the chunk size of the header is untrusted
+	for _, size := range []string{"1099511627776", "-1", "16777217"} {
+		huge := bytes.Replace(sealed, []byte(`"ChunkSize":16`), []byte(`"ChunkSize":`+size), 1)
+		if bytes.Equal(huge, sealed) {
+			t.Fatalf("no ChunkSize in %q", sealed[:hdrLen])
+		}
+		if _, _, err = crypthlp.OpenReader(bytes.NewReader(huge), passphrase); err == nil {
+			t.Errorf("ChunkSize %s: no error", size)
+		}
+	}
+	if _, err = key.CreateStreamWriter(io.Discard, crypthlp.MaxChunkSize+1); err == nil {
+		t.Error("too large chunk size for writing: no error")
+	}
+
+	// the old format is still readable
+	var buf bytes.Buffer
+	w, err := key.CreateBoxWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(data)
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := open(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(got, data) {
+		t.Errorf("old format: got %x, wanted %x", got, data)
+	}
+}
@@ -4,11 +4,11 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"os/signal"
 	"strings"
 	"time"
@@ -18,6 +18,8 @@ import (
 
 	"github.com/tmc/langchaingo/embeddings"
 	"github.com/tmc/langchaingo/embeddings/voyageai"
+
+	"github.com/tgulacsi/go/secret"
 )
 
 func main() {
@@ -34,29 +36,26 @@ func Main() error {
 	flagBatchSize := flag.Int("batch-size", 512, "batch size")
 	flagModel := flag.String("model", "voyage-multilingual-2", "model to use")
 	flagProvider := flag.String("provider", "voyageai", "provider")
-	flagAPIKey := flag.String("api-key", os.Getenv("API_KEY"), "API key")
-	if *flagAPIKey == "" {
-		*flagAPIKey = `$(gopass show websites/voyageai.com/tamas+voyageai@gulacsi.eu | awk '/first:/ {print $2})'`
-	}
+	flagAPIKey := secret.Password("env:API_KEY")
+	flag.Var(&flagAPIKey, "api-key", "API key (or reference, such as env:NAME, file:/path, cmd:command)")
 	flag.Parse()
-	if strings.HasPrefix(*flagAPIKey, "$(") && strings.HasSuffix(*flagAPIKey, ")") {
-		shortCtx, shortCancel := context.WithTimeout(ctx, 3*time.Second)
-		b, err := exec.CommandContext(shortCtx, "bash", "-c", (*flagAPIKey)[2:len(*flagAPIKey)-1]).Output()
-		shortCancel()
-		if err != nil {
-			return err
-		}
-		*flagAPIKey = string(b)
+	shortCtx, shortCancel := context.WithTimeout(ctx, 3*time.Second)
+	apiKey, err := flagAPIKey.Get(shortCtx)
+	shortCancel()
+	if err != nil {
+		return err
+	}
+	if apiKey == "" {
+		return errors.New("no API key: set API_KEY, or give a reference with -api-key")
 	}
 
-	var err error
 	var embedder embeddings.Embedder
 	switch strings.ToLower(*flagProvider) {
 	case "voyageai", "voyage":
 		embedder, err = voyageai.NewVoyageAI(
 			voyageai.WithBatchSize(*flagBatchSize),
 			voyageai.WithModel(*flagModel),
-			voyageai.WithToken(*flagAPIKey),
+			voyageai.WithToken(apiKey),
 		)
 	default:
 		err = fmt.Errorf("unknown provider: %q", *flagProvider)
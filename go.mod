@@ -2,8 +2,10 @@ module github.com/tgulacsi/go
 
 require (
 	codeberg.org/go-pdf/fpdf v0.11.0
+	filippo.io/age v1.2.1
 	github.com/BurntSushi/toml v1.2.1
 	github.com/UNO-SOFT/zlog v0.8.6
+	github.com/cespare/xxhash/v2 v2.3.0
 	github.com/clipperhouse/uax29 v1.14.0
 	github.com/dgraph-io/badger/v2 v2.2007.4
 	github.com/dgryski/go-linebreak v0.0.0-20180812204043-d8f37254e7d3
@@ -29,6 +31,7 @@ require (
 	github.com/pdfcpu/pdfcpu v0.12.1
 	github.com/peterbourgon/ff/v3 v3.4.0
 	github.com/peterbourgon/ff/v4 v4.0.0-beta.1
+	github.com/rivo/uniseg v0.4.7
 	github.com/rogpeppe/retry v0.1.0
 	github.com/rs/zerolog v1.31.0
 	github.com/sloonz/go-qprintable v0.0.0-20210417175225-715103f9e6eb
@@ -53,7 +56,6 @@ require (
 	cloud.google.com/go/compute/metadata v0.9.0 // indirect
 	github.com/KimMachineGun/automemlimit v0.7.5 // indirect
 	github.com/cespare/xxhash v1.1.0 // indirect
-	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/clipperhouse/uax29/v2 v2.7.0 // indirect
 	github.com/dgraph-io/ristretto v0.1.1 // indirect
 	github.com/dgryski/go-farm v0.0.0-20200201041132-a6ae2369ad13 // indirect
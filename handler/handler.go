@@ -49,25 +49,17 @@ type ErrHandler struct {
 }
 
 // ServeHTTP allows our Handler type to satisfy http.Handler.
+//
+// The error is written as Problem Details (see WriteProblem).
 func (h ErrHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	err := h.Handler(w, r)
 	if err == nil {
 		return
 	}
-	logger := zlog.SFromContext(r.Context())
-	if err != nil {
-		logger = h.Logger
+	logger := h.Logger
+	if logger == nil {
+		logger = zlog.SFromContext(r.Context())
 	}
-	if se, ok := err.(statuser); ok {
-		// We can retrieve the status here and write out a specific
-		// HTTP status code.
-		logger.Error("HTTP error", "status", se.Status(), "error", err)
-		http.Error(w, err.Error(), se.Status())
-		return
-	}
-	// Any error types we don't specifically look out for default
-	// to serving a HTTP 500
-	logger.Error("HTTP", "error", err)
-	http.Error(w, http.StatusText(http.StatusInternalServerError),
-		http.StatusInternalServerError)
+	logger.Error("HTTP error", "status", errStatusCode(err), "error", err)
+	WriteProblem(w, r, err)
 }
@@ -0,0 +1,520 @@
+// Copyright 2026 Tamás Gulácsi.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UNO-SOFT/zlog/v2"
+)
+
+var (
+	ErrTokenInvalid = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+	ErrKeyNotFound  = errors.New("key not found")
+)
+
+// DefaultJWTLeeway is the default allowed clock skew.
+const DefaultJWTLeeway = time.Minute
+
+// KeySet returns the public key for the key ID.
+type KeySet interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// JWTConfig is the configuration of the JWT verification.
+type JWTConfig struct {
+	// Keys to verify the signatures with.
+	Keys KeySet
+	// now is for testing.
+	now func() time.Time
+	// Issuer, if not empty, must equal to the "iss" claim.
+	Issuer string
+	// Audience, if not empty, must contain one of the "aud" claim.
+	Audience []string
+	// Algorithms allowed, defaults to RS256, ES256 and EdDSA.
+	Algorithms []string
+	// Leeway is the allowed clock skew for exp/nbf/iat (DefaultJWTLeeway if zero);
+	// iat must not be in the future.
+	Leeway time.Duration
+}
+
+// Claims of a JWT.
+type Claims map[string]any
+
+// String returns the named claim as string.
+func (c Claims) String(name string) string { s, _ := c[name].(string); return s }
+
+// Subject returns the "sub" claim.
+func (c Claims) Subject() string { return c.String("sub") }
+
+// Issuer returns the "iss" claim.
+func (c Claims) Issuer() string { return c.String("iss") }
+
+// Audience returns the "aud" claim (which may be a string or an array).
+func (c Claims) Audience() []string {
+	switch x := c["aud"].(type) {
+	case string:
+		return []string{x}
+	case []any:
+		aud := make([]string, 0, len(x))
+		for _, v := range x {
+			if s, ok := v.(string); ok {
+				aud = append(aud, s)
+			}
+		}
+		return aud
+	}
+	return nil
+}
+
+// Time returns the named NumericDate claim.
+func (c Claims) Time(name string) (time.Time, bool) {
+	switch x := c[name].(type) {
+	case float64:
+		sec := int64(x)
+		return time.Unix(sec, int64((x-float64(sec))*1e9)), true
+	case json.Number:
+		if f, err := x.Float64(); err == nil {
+			sec := int64(f)
+			return time.Unix(sec, int64((f-float64(sec))*1e9)), true
+		}
+	}
+	return time.Time{}, false
+}
+
+type ctxKeyClaims struct{}
+
+// ClaimsFromContext returns the Claims stored by BearerAuth.
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	c, ok := ctx.Value(ctxKeyClaims{}).(Claims)
+	return c, ok
+}
+
+// BearerAuth verifies the JWT in the Authorization: Bearer header,
+// and serves hndl with the Claims in the request's context (see ClaimsFromContext).
+func BearerAuth(conf JWTConfig, hndl http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, ErrAuth.Error(), http.StatusUnauthorized)
+			return
+		}
+		claims, err := conf.Verify(r.Context(), strings.TrimSpace(token))
+		if err != nil {
+			// the details (maybe a JWKS fetch error) are for the server's log only
+			zlog.SFromContext(r.Context()).Info("BearerAuth", "error", err)
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, ErrTokenInvalid.Error(), http.StatusUnauthorized)
+			return
+		}
+		hndl.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKeyClaims{}, claims)))
+	})
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Verify the signature and the iss/aud/exp/nbf claims of the compact serialized JWT.
+func (conf JWTConfig) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: not a compact JWS", ErrTokenInvalid)
+	}
+	var hdr jwtHeader
+	if err := decodeB64JSON(parts[0], &hdr); err != nil {
+		return nil, fmt.Errorf("%w: header: %w", ErrTokenInvalid, err)
+	}
+	algs := conf.Algorithms
+	if len(algs) == 0 {
+		algs = []string{"RS256", "ES256", "EdDSA"}
+	}
+	if !slices.Contains(algs, hdr.Alg) {
+		return nil, fmt.Errorf("%w: algorithm %q is not allowed", ErrTokenInvalid, hdr.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %w", ErrTokenInvalid, err)
+	}
+	if conf.Keys == nil {
+		return nil, fmt.Errorf("%w: no keys", ErrKeyNotFound)
+	}
+	key, err := conf.Keys.Key(ctx, hdr.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err = verifyJWS(hdr.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err = decodeB64JSON(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %w", ErrTokenInvalid, err)
+	}
+	now := time.Now()
+	if conf.now != nil {
+		now = conf.now()
+	}
+	leeway := conf.Leeway
+	if leeway == 0 {
+		leeway = DefaultJWTLeeway
+	}
+	if exp, ok := claims.Time("exp"); ok && !now.Before(exp.Add(leeway)) {
+		return claims, fmt.Errorf("%w: expired at %s", ErrTokenExpired, exp)
+	}
+	if nbf, ok := claims.Time("nbf"); ok && now.Add(leeway).Before(nbf) {
+		return claims, fmt.Errorf("%w: not valid before %s", ErrTokenInvalid, nbf)
+	}
+	if iat, ok := claims.Time("iat"); ok && now.Add(leeway).Before(iat) {
+		return claims, fmt.Errorf("%w: issued in the future (%s)", ErrTokenInvalid, iat)
+	}
+	if conf.Issuer != "" && claims.Issuer() != conf.Issuer {
+		return claims, fmt.Errorf("%w: issuer %q", ErrTokenInvalid, claims.Issuer())
+	}
+	if len(conf.Audience) != 0 && !slices.ContainsFunc(claims.Audience(), func(s string) bool {
+		return slices.Contains(conf.Audience, s)
+	}) {
+		return claims, fmt.Errorf("%w: audience %q", ErrTokenInvalid, claims.Audience())
+	}
+	return claims, nil
+}
+
+func decodeB64JSON(s string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func verifyJWS(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	var ok bool
+	switch alg {
+	case "RS256":
+		if k, isRSA := key.(*rsa.PublicKey); isRSA {
+			hsh := sha256.Sum256(signed)
+			ok = rsa.VerifyPKCS1v15(k, crypto.SHA256, hsh[:], sig) == nil
+		}
+	case "ES256":
+		if k, isEC := key.(*ecdsa.PublicKey); isEC && k.Curve == elliptic.P256() && len(sig) == 64 {
+			hsh := sha256.Sum256(signed)
+			ok = ecdsa.Verify(k, hsh[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:]))
+		}
+	case "EdDSA":
+		if k, isEd := key.(ed25519.PublicKey); isEd {
+			ok = ed25519.Verify(k, signed, sig)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrTokenInvalid, alg)
+	}
+	if !ok {
+		return fmt.Errorf("%w: bad signature", ErrTokenInvalid)
+	}
+	return nil
+}
+
+// JWKS is a JSON Web Key Set (RFC 7517).
+type JWKS struct {
+	keys map[string]crypto.PublicKey
+}
+
+var _ = KeySet(JWKS{})
+
+// Key returns the key with the given ID.
+// If kid is empty and the set contains only one key, then that is returned.
+func (ks JWKS) Key(_ context.Context, kid string) (crypto.PublicKey, error) {
+	if k, ok := ks.keys[kid]; ok {
+		return k, nil
+	}
+	if kid == "" && len(ks.keys) == 1 {
+		for _, k := range ks.keys {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+}
+
+// Len returns the number of keys.
+func (ks JWKS) Len() int { return len(ks.keys) }
+
+// ReadJWKSFile reads the JWKS from the file.
+func ReadJWKSFile(fn string) (JWKS, error) {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return JWKS{}, err
+	}
+	return ParseJWKS(b)
+}
+
+// ParseJWKS parses the JSON serialized JWKS.
+// Keys which are not for signature, or of unsupported type are skipped.
+func ParseJWKS(b []byte) (JWKS, error) {
+	var raw struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return JWKS{}, err
+	}
+	ks := JWKS{keys: make(map[string]crypto.PublicKey, len(raw.Keys))}
+	for _, k := range raw.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.PublicKey()
+		if err != nil {
+			return ks, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		if pub != nil {
+			ks.keys[k.Kid] = pub
+		}
+	}
+	return ks, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) PublicKey() (crypto.PublicKey, error) {
+	b64 := base64.RawURLEncoding
+	switch k.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+		e, err := b64.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("e: %w", err)
+		}
+		E := new(big.Int).SetBytes(e)
+		if !E.IsInt64() || E.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("e: too big")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(E.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, nil
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := b64.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("bad P-256 point size")
+		}
+		return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), append(append([]byte{4}, x...), y...))
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, nil
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("bad Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
+
+// DefaultJWKSMaxAge is the default refresh period of RemoteJWKS.
+const DefaultJWKSMaxAge = time.Hour
+
+// RemoteJWKS fetches and caches the JWKS from the URL.
+//
+// The keys are refreshed after MaxAge, or when an unknown key ID is asked
+// (but at most once a minute).
+// A failed refresh is retried after a minute, the cached keys are served meanwhile.
+type RemoteJWKS struct {
+	// fetched is the time of the last successful fetch, tried is of the last attempt.
+	fetched, tried time.Time
+	// err is the error of the last attempt.
+	err error
+	// fetching is closed when the running fetch finishes.
+	fetching chan struct{}
+	// Client is the HTTP client, http.DefaultClient if nil.
+	Client *http.Client
+	URL    string
+	keys   JWKS
+	// MaxAge is the refresh period (DefaultJWKSMaxAge if zero).
+	MaxAge time.Duration
+	mu     sync.Mutex
+}
+
+var _ = KeySet((*RemoteJWKS)(nil))
+
+// jwksRetryInterval is the minimal time before retrying a failed fetch,
+// or fetching again for an unknown key ID.
+const jwksRetryInterval = time.Minute
+
+// Key returns the key for the ID, fetching the JWKS if needed.
+func (rk *RemoteJWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	maxAge := rk.MaxAge
+	if maxAge == 0 {
+		maxAge = DefaultJWKSMaxAge
+	}
+	rk.mu.Lock()
+	keys, fetched, tried, lastErr := rk.keys, rk.fetched, rk.tried, rk.err
+	rk.mu.Unlock()
+	sinceTried := time.Since(tried)
+	// after a failed attempt, wait before retrying
+	if time.Since(fetched) > maxAge && (lastErr == nil || sinceTried > jwksRetryInterval) {
+		keys, lastErr = rk.fetch(ctx)
+		sinceTried = 0
+	}
+	if keys.Len() == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	k, err := keys.Key(ctx, kid)
+	if errors.Is(err, ErrKeyNotFound) && sinceTried > jwksRetryInterval {
+		if keys, err = rk.fetch(ctx); err != nil {
+			return nil, err
+		}
+		k, err = keys.Key(ctx, kid)
+	}
+	return k, err
+}
+
+// fetch refreshes the keys, without holding the lock during the request;
+// concurrent calls wait for the running one.
+// It returns the cached keys with the error if the refresh fails.
+//
+// The refresh is not bound to ctx (but to jwksFetchTimeout), so a caller giving up
+// does not fail it for the others.
+func (rk *RemoteJWKS) fetch(ctx context.Context) (JWKS, error) {
+	rk.mu.Lock()
+	ch := rk.fetching
+	if ch == nil {
+		ch = make(chan struct{})
+		rk.fetching = ch
+		go rk.refresh(context.WithoutCancel(ctx), ch)
+	}
+	rk.mu.Unlock()
+	select {
+	case <-ch:
+	case <-ctx.Done():
+		return JWKS{}, ctx.Err()
+	}
+	rk.mu.Lock()
+	defer rk.mu.Unlock()
+	return rk.keys, rk.err
+}
+
+// jwksFetchTimeout limits the JWKS download.
+const jwksFetchTimeout = 30 * time.Second
+
+// refresh downloads the keys, records the result and closes ch.
+func (rk *RemoteJWKS) refresh(ctx context.Context, ch chan struct{}) {
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+	keys, err := rk.get(ctx)
+
+	rk.mu.Lock()
+	defer rk.mu.Unlock()
+	rk.tried, rk.err = time.Now(), err
+	if err == nil {
+		rk.keys, rk.fetched = keys, rk.tried
+	}
+	rk.fetching = nil
+	close(ch)
+}
+
+// get downloads and parses the JWKS.
+func (rk *RemoteJWKS) get(ctx context.Context) (JWKS, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rk.URL, nil)
+	if err != nil {
+		return JWKS{}, fmt.Errorf("GET %s: %w", rk.URL, err)
+	}
+	req.Header.Set("Accept", "application/jwk-set+json, application/json")
+	cl := rk.Client
+	if cl == nil {
+		cl = http.DefaultClient
+	}
+	resp, err := cl.Do(req)
+	if err != nil {
+		return JWKS{}, fmt.Errorf("GET %s: %w", rk.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return JWKS{}, fmt.Errorf("GET %s: %s", rk.URL, resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return JWKS{}, fmt.Errorf("read %s: %w", rk.URL, err)
+	}
+	keys, err := ParseJWKS(b)
+	if err != nil {
+		return JWKS{}, fmt.Errorf("parse %s: %w", rk.URL, err)
+	}
+	return keys, nil
+}
+
+// DiscoverJWKS returns a RemoteJWKS for the OpenID Connect issuer,
+// reading the jwks_uri from its /.well-known/openid-configuration.
+func DiscoverJWKS(ctx context.Context, cl *http.Client, issuer string) (*RemoteJWKS, error) {
+	if cl == nil {
+		cl = http.DefaultClient
+	}
+	URL := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, "GET", URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", URL, err)
+	}
+	resp, err := cl.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", URL, resp.Status)
+	}
+	var conf struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&conf); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", URL, err)
+	}
+	if conf.JWKSURI == "" {
+		return nil, fmt.Errorf("%s: no jwks_uri", URL)
+	}
+	return &RemoteJWKS{URL: conf.JWKSURI, Client: cl}, nil
+}
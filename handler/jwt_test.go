@@ -0,0 +1,218 @@
+// Copyright 2026 Tamás Gulácsi.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBearerAuth(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+	ecBytes, err := ecKey.PublicKey.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecBytes[1:33]), "y": b64(ecBytes[33:])},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": b64(edPub)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fetches int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Header().Set("Content-Type", "application/jwk-set+json")
+		w.Write(jwks)
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	sign := func(alg, kid string, claims map[string]any) string {
+		hdr, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+		pl, _ := json.Marshal(claims)
+		signed := b64(hdr) + "." + b64(pl)
+		hsh := sha256.Sum256([]byte(signed))
+		var sig []byte
+		switch alg {
+		case "RS256":
+			if sig, err = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, hsh[:]); err != nil {
+				t.Fatal(err)
+			}
+		case "ES256":
+			r, s, err := ecdsa.Sign(rand.Reader, ecKey, hsh[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		case "EdDSA":
+			sig = ed25519.Sign(edKey, []byte(signed))
+		}
+		return signed + "." + b64(sig)
+	}
+	claims := func(mod map[string]any) map[string]any {
+		m := map[string]any{
+			"iss": "https://issuer", "aud": []string{"api"}, "sub": "user",
+			"exp": now.Add(time.Minute).Unix(), "nbf": now.Add(-time.Minute).Unix(),
+		}
+		for k, v := range mod {
+			m[k] = v
+		}
+		return m
+	}
+
+	conf := JWTConfig{
+		Keys:   &RemoteJWKS{URL: srv.URL},
+		Issuer: "https://issuer", Audience: []string{"api"},
+		now: func() time.Time { return now },
+	}
+	var gotSub string
+	h := BearerAuth(conf, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, _ := ClaimsFromContext(r.Context())
+		gotSub = c.Subject()
+	}))
+	for _, tC := range []struct {
+		Name, Token string
+		Code        int
+	}{
+		{"rsa", sign("RS256", "rsa", claims(nil)), 200},
+		{"ec", sign("ES256", "ec", claims(nil)), 200},
+		{"ed", sign("EdDSA", "ed", claims(nil)), 200},
+		{"skew", sign("EdDSA", "ed", claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()})), 200},
+		{"expired", sign("EdDSA", "ed", claims(map[string]any{"exp": now.Add(-2 * time.Minute).Unix()})), 401},
+		{"nbf", sign("EdDSA", "ed", claims(map[string]any{"nbf": now.Add(2 * time.Minute).Unix()})), 401},
+		{"iat", sign("EdDSA", "ed", claims(map[string]any{"iat": now.Add(2 * time.Minute).Unix()})), 401},
+		{"iatskew", sign("EdDSA", "ed", claims(map[string]any{"iat": now.Add(30 * time.Second).Unix()})), 200},
+		{"iss", sign("RS256", "rsa", claims(map[string]any{"iss": "other"})), 401},
+		{"aud", sign("RS256", "rsa", claims(map[string]any{"aud": "other"})), 401},
+		{"wrongkey", sign("ES256", "rsa", claims(nil)), 401},
+		{"unknownkid", sign("RS256", "xxx", claims(nil)), 401},
+		{"none", b64([]byte(`{"alg":"none"}`)) + "." + b64([]byte(`{}`)) + ".", 401},
+		{"empty", "", 401},
+	} {
+		gotSub = ""
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		if tC.Token != "" {
+			r.Header.Set("Authorization", "Bearer "+tC.Token)
+		}
+		h.ServeHTTP(w, r)
+		if w.Code != tC.Code {
+			t.Errorf("%s: got %d, wanted %d: %s", tC.Name, w.Code, tC.Code, w.Body.String())
+		} else if w.Code == 200 && gotSub != "user" {
+			t.Errorf("%s: got sub %q", tC.Name, gotSub)
+		} else if w.Code == 401 && tC.Token != "" && strings.TrimSpace(w.Body.String()) != ErrTokenInvalid.Error() {
+			t.Errorf("%s: got body %q", tC.Name, w.Body.String())
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("JWKS fetched %d times", fetches)
+	}
+
+	ks, err := ParseJWKS(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Keys = ks
+	if _, err = conf.Verify(t.Context(), sign("EdDSA", "ed", claims(map[string]any{"exp": now.Add(-time.Hour).Unix()}))); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("got %v, wanted ErrTokenExpired", err)
+	}
+}
+
+func TestRemoteJWKSFailedRefresh(t *testing.T) {
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "OKP", "crv": "Ed25519", "kid": "ed", "x": base64.RawURLEncoding.EncodeToString(edPub),
+	}}})
+	var fetches atomic.Int32
+	var failing atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if failing.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(jwks)
+	}))
+	defer srv.Close()
+
+	rk := &RemoteJWKS{URL: srv.URL, MaxAge: time.Millisecond}
+	if _, err := rk.Key(t.Context(), "ed"); err != nil {
+		t.Fatal(err)
+	}
+	failing.Store(true)
+	time.Sleep(2 * time.Millisecond)
+	for range 3 {
+		// the failed refresh is not retried, the cached key is served
+		if _, err := rk.Key(t.Context(), "ed"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("JWKS fetched %d times, wanted 2", got)
+	}
+}
+
+func TestRemoteJWKSCanceled(t *testing.T) {
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "OKP", "crv": "Ed25519", "kid": "ed", "x": base64.RawURLEncoding.EncodeToString(edPub),
+	}}})
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write(jwks)
+	}))
+	defer srv.Close()
+
+	rk := &RemoteJWKS{URL: srv.URL}
+	// the first caller gives up during the fetch
+	ctx, cancel := context.WithCancel(t.Context())
+	go func() { time.Sleep(10 * time.Millisecond); cancel() }()
+	if _, err := rk.Key(ctx, "ed"); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %+v, wanted context.Canceled", err)
+	}
+	close(release)
+	// the fetch goes on, and its result is served for the others
+	if _, err := rk.Key(t.Context(), "ed"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,387 @@
+// Copyright 2026 Tamás Gulácsi.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UNO-SOFT/zlog/v2"
+	"github.com/oklog/ulid/v2"
+	"github.com/tgulacsi/go/httpreq"
+	"golang.org/x/time/rate"
+)
+
+// Middleware wraps a http.Handler.
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h with the middlewares, the first being the outermost.
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// RequestIDHeader is the header used for request ID propagation.
+const RequestIDHeader = "X-Request-Id"
+
+type ctxKeyRequestID struct{}
+
+// ContextWithRequestID returns a context with the request ID set.
+func ContextWithRequestID(ctx context.Context, reqID string) context.Context {
+	return context.WithValue(ctx, ctxKeyRequestID{}, reqID)
+}
+
+// RequestIDFromContext returns the request ID from the context, or the empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	s, _ := ctx.Value(ctxKeyRequestID{}).(string)
+	return s
+}
+
+// RequestID takes the request ID from the X-Request-Id (or Request-Id) header,
+// or generates a new one (an ULID).
+//
+// The ID is put into the context (see RequestIDFromContext),
+// into the context's logger (as "reqID"), and is set in the response header.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqID := r.Header.Get(RequestIDHeader)
+		if reqID == "" {
+			reqID = r.Header.Get("Request-Id")
+		}
+		if reqID == "" || len(reqID) > 128 {
+			reqID = ulid.Make().String()
+		}
+		w.Header().Set(RequestIDHeader, reqID)
+		ctx := ContextWithRequestID(r.Context(), reqID)
+		ctx = zlog.NewSContext(ctx, zlog.SFromContext(ctx).With("reqID", reqID))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// AccessLog logs each request in Combined Log Format (see httpreq.GetCombinedLogLine),
+// with the status, size, duration and request ID as attributes.
+//
+// If logger is nil, the context's logger is used.
+func AccessLog(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w}
+			defer func() {
+				lgr := logger
+				if lgr == nil {
+					lgr = zlog.SFromContext(r.Context())
+				}
+				status := sw.Status()
+				lvl := slog.LevelInfo
+				if status >= 500 {
+					lvl = slog.LevelError
+				} else if status >= 400 {
+					lvl = slog.LevelWarn
+				}
+				lgr.LogAttrs(r.Context(), lvl, httpreq.GetCombinedLogLine(r, start, status, sw.size),
+					slog.Int("status", status),
+					slog.Int("size", sw.size),
+					slog.Duration("dur", time.Since(start)),
+					slog.String("reqID", RequestIDFromContext(r.Context())),
+				)
+			}()
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
+
+// Recover recovers from panics in the handler, logs the panic with the stack,
+// and responds with the status of the panic value, if it is an error with a status code
+// (such as StatusError), or with 500 Internal Server Error.
+//
+// http.ErrAbortHandler is re-panicked.
+func Recover(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := &statusWriter{ResponseWriter: w}
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				err, ok := rec.(error)
+				if !ok {
+					err = fmt.Errorf("panic: %v", rec)
+				}
+				lgr := logger
+				if lgr == nil {
+					lgr = zlog.SFromContext(r.Context())
+				}
+				lgr.Error("panic", "error", err, "stack", string(debug.Stack()))
+				if sw.status != 0 { // headers are already sent
+					return
+				}
+				code := errStatusCode(err)
+				if code == http.StatusInternalServerError {
+					http.Error(sw, http.StatusText(code), code)
+				} else {
+					http.Error(sw, err.Error(), code)
+				}
+			}()
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
+
+// errStatusCode returns the status code of the error (by its Status or StatusCode method),
+// or http.StatusInternalServerError.
+func errStatusCode(err error) int {
+	var st statuser
+	if errors.As(err, &st) {
+		return st.Status()
+	}
+	var sc interface{ StatusCode() int }
+	if errors.As(err, &sc) {
+		return sc.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
+// MaxBodySize limits the size of the request body to n bytes.
+//
+// Requests declaring a bigger Content-Length are rejected with 413 Request Entity Too Large
+// right away, others get an error from the body's Read after reading n bytes.
+func MaxBodySize(n int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, n)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP returns the IP address of the client, from RemoteAddr.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// APIKey returns a key function for RateLimit which returns the value of the given header,
+// or the ClientIP if the header is empty.
+func APIKey(header string) func(*http.Request) string {
+	return func(r *http.Request) string {
+		if k := r.Header.Get(header); k != "" {
+			return "key:" + k
+		}
+		return ClientIP(r)
+	}
+}
+
+// RateLimit limits the requests with a token bucket for each key
+// (by default the ClientIP), allowing limit requests per second with the given burst.
+//
+// Rejected requests get 429 Too Many Requests with a Retry-After header.
+func RateLimit(limit rate.Limit, burst int, key func(*http.Request) string) Middleware {
+	if key == nil {
+		key = ClientIP
+	}
+	rl := &rateLimiters{limit: limit, burst: burst, m: make(map[string]*rate.Limiter)}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			now := time.Now()
+			res := rl.get(key(r), now).ReserveN(now, 1)
+			if !res.OK() {
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			if d := res.DelayFrom(now); d > 0 {
+				res.CancelAt(now)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type rateLimiters struct {
+	m         map[string]*rate.Limiter
+	lastClean time.Time
+	limit     rate.Limit
+	burst     int
+	mu        sync.Mutex
+}
+
+func (rl *rateLimiters) get(key string, now time.Time) *rate.Limiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	// forget the limiters which are full again
+	if now.Sub(rl.lastClean) > time.Minute {
+		rl.lastClean = now
+		for k, l := range rl.m {
+			if l.TokensAt(now) >= float64(rl.burst) {
+				delete(rl.m, k)
+			}
+		}
+	}
+	l := rl.m[key]
+	if l == nil {
+		l = rate.NewLimiter(rl.limit, rl.burst)
+		rl.m[key] = l
+	}
+	return l
+}
+
+// CORSConfig is the configuration for CORS.
+type CORSConfig struct {
+	// AllowedOrigins is the list of allowed origins, "*" allows all.
+	AllowedOrigins []string
+	// AllowedMethods defaults to GET, HEAD and POST.
+	AllowedMethods []string
+	// AllowedHeaders are the allowed request headers, "*" allows all.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers exposed to the client.
+	ExposedHeaders []string
+	// MaxAge of the preflight response.
+	MaxAge           time.Duration
+	AllowCredentials bool
+}
+
+// CORS handles Cross-Origin Resource Sharing: answers preflight requests,
+// and sets the Access-Control-* headers for allowed origins.
+func CORS(conf CORSConfig) Middleware {
+	if len(conf.AllowedMethods) == 0 {
+		conf.AllowedMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	allowAll := slices.Contains(conf.AllowedOrigins, "*")
+	allowAllHeaders := slices.Contains(conf.AllowedHeaders, "*")
+	originAllowed := func(origin string) bool {
+		return allowAll || slices.ContainsFunc(conf.AllowedOrigins, func(s string) bool {
+			return strings.EqualFold(s, origin)
+		})
+	}
+	methods := strings.Join(conf.AllowedMethods, ", ")
+	exposed := strings.Join(conf.ExposedHeaders, ", ")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if origin == "" || !originAllowed(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if allowAll && !conf.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if conf.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if !preflight {
+				if exposed != "" {
+					h.Set("Access-Control-Expose-Headers", exposed)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			if !slices.Contains(conf.AllowedMethods, r.Header.Get("Access-Control-Request-Method")) {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			h.Set("Access-Control-Allow-Methods", methods)
+			if reqHeaders := r.Header.Get("Access-Control-Request-Headers"); reqHeaders != "" {
+				if allowAllHeaders {
+					h.Set("Access-Control-Allow-Headers", reqHeaders)
+				} else {
+					for _, k := range strings.Split(reqHeaders, ",") {
+						if k = strings.TrimSpace(k); !slices.ContainsFunc(conf.AllowedHeaders, func(s string) bool {
+							return strings.EqualFold(s, k)
+						}) {
+							w.WriteHeader(http.StatusNoContent)
+							return
+						}
+					}
+					h.Set("Access-Control-Allow-Headers", reqHeaders)
+				}
+			}
+			if conf.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(conf.MaxAge/time.Second)))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// statusWriter records the status code and the number of bytes written.
+type statusWriter struct {
+	http.ResponseWriter
+	status, size int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 && code >= 200 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.size += n
+	return n, err
+}
+
+func (w *statusWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Status returns the written status code (200 if nothing has been written).
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Unwrap is for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
@@ -0,0 +1,114 @@
+// Copyright 2026 Tamás Gulácsi.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	var gotReqID string
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotReqID = RequestIDFromContext(r.Context())
+		if r.URL.Path == "/panic" {
+			panic(StatusError{Err: errors.New("teapot"), Code: http.StatusTeapot})
+		}
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		io.WriteString(w, "ok")
+	}),
+		RequestID, AccessLog(logger), Recover(logger), MaxBodySize(4),
+	)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Request-Id", "abc")
+	h.ServeHTTP(w, r)
+	if w.Code != 200 || gotReqID != "abc" || w.Header().Get(RequestIDHeader) != "abc" {
+		t.Errorf("got %d reqID=%q header=%q", w.Code, gotReqID, w.Header())
+	}
+	if s := buf.String(); !strings.Contains(s, `GET / HTTP/1.1\" 200 2`) || !strings.Contains(s, "reqID=abc") {
+		t.Errorf("access log: %s", s)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+	if w.Code != http.StatusTeapot || gotReqID == "" {
+		t.Errorf("panic: got %d reqID=%q", w.Code, gotReqID)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader("too long")))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("body limit: got %d", w.Code)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	h := RateLimit(1, 2, APIKey("X-Api-Key"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	do := func(key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-Api-Key", key)
+		h.ServeHTTP(w, r)
+		return w
+	}
+	for i := range 2 {
+		if w := do("a"); w.Code != 200 {
+			t.Errorf("%d. got %d", i, w.Code)
+		}
+	}
+	if w := do("a"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("got %d %v, wanted 429 with Retry-After", w.Code, w.Header())
+	}
+	if w := do("b"); w.Code != 200 {
+		t.Errorf("other key: got %d", w.Code)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	h := CORS(CORSConfig{
+		AllowedOrigins: []string{"https://example.com"},
+		AllowedMethods: []string{"GET", "PUT"},
+		AllowedHeaders: []string{"Content-Type"},
+		MaxAge:         time.Hour,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("OPTIONS", "/", nil)
+	r.Header.Set("Origin", "https://example.com")
+	r.Header.Set("Access-Control-Request-Method", "PUT")
+	r.Header.Set("Access-Control-Request-Headers", "content-type")
+	h.ServeHTTP(w, r)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://example.com" {
+		t.Errorf("preflight: got origin %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, PUT" {
+		t.Errorf("preflight: got methods %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "3600" {
+		t.Errorf("preflight: got max-age %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Origin", "https://evil.example.com")
+	h.ServeHTTP(w, r)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("disallowed origin got %q", got)
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2026 Tamás Gulácsi.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+
+	"github.com/tgulacsi/go/httpreq"
+	"github.com/tgulacsi/go/soaphlp"
+)
+
+const (
+	// ProblemJSON is the media type of RFC 9457 Problem Details in JSON.
+	ProblemJSON = "application/problem+json"
+	// ProblemXML is the media type of RFC 9457 Problem Details in XML.
+	ProblemXML = "application/problem+xml"
+
+	problemXMLNS = "urn:ietf:rfc:7807"
+)
+
+// ProblemTypes are the supported media types of WriteProblem, in order of preference.
+var ProblemTypes = ProblemJSON + ", " + ProblemXML + ", application/json, application/xml, text/xml, text/plain"
+
+// Problem is an RFC 9457 Problem Details object.
+type Problem struct {
+	// Extensions are the extension members, marshaled beside the standard members.
+	Extensions map[string]any
+	// Type is an URI reference that identifies the problem type ("about:blank" if empty).
+	Type string
+	// Title is a short, human-readable summary of the problem type.
+	Title string
+	// Detail is a human-readable explanation specific to this occurrence of the problem.
+	Detail string
+	// Instance is an URI reference that identifies the specific occurrence of the problem.
+	Instance string
+	// Status is the HTTP status code.
+	Status int
+}
+
+// ProblemDetailer is implemented by errors which contribute to the Problem Details.
+type ProblemDetailer interface {
+	ProblemDetails(*Problem)
+}
+
+var _ = error((*Problem)(nil))
+
+// Error returns the title and the detail.
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Title + ": " + p.Detail
+}
+
+// StatusCode returns the Status.
+func (p *Problem) StatusCode() int { return p.Status }
+
+// NewProblem returns the Problem Details for the error.
+//
+// The status is from the error's Status or StatusCode method (500 by default),
+// the title is the status text, and the detail is the error's message for 4xx statuses.
+// Then every ProblemDetailer in the error's tree can amend the Problem,
+// the outermost one being the last.
+func NewProblem(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		q := *p
+		return &q
+	}
+	code := errStatusCode(err)
+	p = &Problem{Status: code, Title: http.StatusText(code)}
+	if code < 500 {
+		p.Detail = err.Error()
+	}
+	var dd []ProblemDetailer
+	walkErrors(err, func(err error) {
+		if d, ok := err.(ProblemDetailer); ok {
+			dd = append(dd, d)
+		}
+	})
+	for i := len(dd) - 1; i >= 0; i-- {
+		dd[i].ProblemDetails(p)
+	}
+	return p
+}
+
+func walkErrors(err error, f func(error)) {
+	if err == nil {
+		return
+	}
+	f(err)
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		walkErrors(x.Unwrap(), f)
+	case interface{ Unwrap() []error }:
+		for _, err := range x.Unwrap() {
+			walkErrors(err, f)
+		}
+	}
+}
+
+// WriteProblem writes the error as Problem Details, in the format negotiated
+// by the request's Accept header (see ProblemTypes).
+//
+// Requests of SOAP endpoints (having a SOAPAction header, or an application/soap+xml body)
+// and errors containing a soaphlp.Fault get a SOAP Fault (see soaphlp.FaultFromError);
+// server errors without a Fault get a generic soap:Server Fault.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	p := NewProblem(err)
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+	var fault soaphlp.Fault
+	var faultP *soaphlp.Fault
+	hasFault := errors.As(err, &fault) || errors.As(err, &faultP) && faultP != nil
+	if hasFault || isSOAPRequest(r) {
+		f := soaphlp.FaultFromError(err)
+		if !hasFault && p.Status >= 500 {
+			// the error is logged by the caller (ErrHandler), not sent to the client
+			f = soaphlp.Fault{Code: "soap:Server", Reason: http.StatusText(p.Status)}
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(p.Status)
+		f.WriteResponse(w)
+		return
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		accept = "*/*"
+	}
+	mt, _ := httpreq.BestAcceptMatch(ProblemTypes, accept)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	switch mt {
+	case ProblemXML, "application/xml", "text/xml":
+		if mt != "text/xml" {
+			mt = ProblemXML
+		}
+		w.Header().Set("Content-Type", mt+"; charset=utf-8")
+		w.WriteHeader(p.Status)
+		b, _ := xml.Marshal(p)
+		w.Write(append([]byte(xml.Header), b...))
+	case "text/plain":
+		http.Error(w, p.Error(), p.Status)
+	default:
+		w.Header().Set("Content-Type", ProblemJSON)
+		w.WriteHeader(p.Status)
+		json.NewEncoder(w).Encode(p)
+	}
+}
+
+func isSOAPRequest(r *http.Request) bool {
+	if _, ok := r.Header["Soapaction"]; ok {
+		return true
+	}
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mt == "application/soap+xml"
+}
+
+// MarshalJSON marshals the standard members and the extensions into one object.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	for k, v := range map[string]string{
+		"type": p.Type, "title": p.Title, "detail": p.Detail, "instance": p.Instance,
+	} {
+		if v != "" {
+			m[k] = v
+		} else {
+			delete(m, k)
+		}
+	}
+	if p.Status != 0 {
+		m["status"] = p.Status
+	} else {
+		delete(m, "status")
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON unmarshals the standard members, and the rest into Extensions.
+func (p *Problem) UnmarshalJSON(b []byte) error {
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	*p = Problem{}
+	for k, v := range m {
+		s, _ := v.(string)
+		switch k {
+		case "type":
+			p.Type = s
+		case "title":
+			p.Title = s
+		case "detail":
+			p.Detail = s
+		case "instance":
+			p.Instance = s
+		case "status":
+			if f, ok := v.(float64); ok {
+				p.Status = int(f)
+			}
+		default:
+			if p.Extensions == nil {
+				p.Extensions = make(map[string]any)
+			}
+			p.Extensions[k] = v
+		}
+	}
+	return nil
+}
+
+// MarshalXML marshals as RFC 9457 Appendix B describes.
+func (p *Problem) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Local: "problem"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: problemXMLNS}}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, kv := range [][2]string{{"type", p.Type}, {"title", p.Title}, {"detail", p.Detail}, {"instance", p.Instance}} {
+		if kv[1] != "" {
+			if err := enc.EncodeElement(kv[1], xml.StartElement{Name: xml.Name{Local: kv[0]}}); err != nil {
+				return err
+			}
+		}
+	}
+	if p.Status != 0 {
+		if err := enc.EncodeElement(p.Status, xml.StartElement{Name: xml.Name{Local: "status"}}); err != nil {
+			return err
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(p.Extensions)) {
+		if err := encodeXMLValue(enc, k, p.Extensions[k]); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+func encodeXMLValue(enc *xml.Encoder, name string, v any) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch x := v.(type) {
+	case nil:
+		return enc.EncodeElement("", start)
+	case string, bool, int, int64, float64:
+		return enc.EncodeElement(x, start)
+	case map[string]any:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, k := range slices.Sorted(maps.Keys(x)) {
+			if err := encodeXMLValue(enc, k, x[k]); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for i := range rv.Len() {
+			if err := encodeXMLValue(enc, "i", rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	}
+	if s, ok := v.(fmt.Stringer); ok {
+		return enc.EncodeElement(s.String(), start)
+	}
+	if b, err := json.Marshal(v); err == nil {
+		var m any
+		if err = json.Unmarshal(b, &m); err == nil {
+			return encodeXMLValue(enc, name, m)
+		}
+	}
+	return enc.EncodeElement(fmt.Sprint(v), start)
+}
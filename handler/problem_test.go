@@ -0,0 +1,105 @@
+// Copyright 2026 Tamás Gulácsi.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tgulacsi/go/httperr"
+	"github.com/tgulacsi/go/soaphlp"
+)
+
+type balanceError struct{ balance int }
+
+func (e balanceError) Error() string { return "insufficient balance" }
+func (e balanceError) ProblemDetails(p *Problem) {
+	p.Type = "https://example.com/probs/out-of-credit"
+	p.Title = "You do not have enough credit."
+	p.Extensions = map[string]any{"balance": e.balance, "accounts": []string{"/account/12345"}}
+}
+
+func TestWriteProblem(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", httperr.New(balanceError{balance: 30}, http.StatusForbidden))
+	for _, tC := range []struct {
+		Accept, ContentType string
+		Want                []string
+	}{
+		{Accept: "", ContentType: ProblemJSON, Want: []string{`"balance":30`, `"status":403`, `"type":"https://example.com/probs/out-of-credit"`}},
+		{Accept: "application/xml", ContentType: ProblemXML + "; charset=utf-8", Want: []string{
+			`<problem xmlns="urn:ietf:rfc:7807">`, `<status>403</status>`,
+			`<accounts><i>/account/12345</i></accounts><balance>30</balance>`,
+		}},
+		{Accept: "text/plain", ContentType: "text/plain; charset=utf-8", Want: []string{"You do not have enough credit.: wrapped: insufficient balance"}},
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", tC.Accept)
+		WriteProblem(w, r, err)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%q: got %d", tC.Accept, w.Code)
+		}
+		if got := w.Header().Get("Content-Type"); got != tC.ContentType {
+			t.Errorf("%q: got Content-Type %q, wanted %q", tC.Accept, got, tC.ContentType)
+		}
+		body := w.Body.String()
+		for _, want := range tC.Want {
+			if !strings.Contains(body, want) {
+				t.Errorf("%q: %q not found in %s", tC.Accept, want, body)
+			}
+		}
+	}
+}
+
+func TestProblemJSON(t *testing.T) {
+	p := NewProblem(StatusError{Err: errors.New("nope"), Code: http.StatusNotFound})
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var q Problem
+	if err = json.Unmarshal(b, &q); err != nil {
+		t.Fatal(err)
+	}
+	if q.Status != http.StatusNotFound || q.Title != "Not Found" || q.Detail != "nope" {
+		t.Errorf("got %+v from %s", q, b)
+	}
+
+	if p = NewProblem(errors.New("secret")); p.Status != 500 || p.Detail != "" {
+		t.Errorf("internal error leaked: %+v", p)
+	}
+}
+
+func TestWriteProblemSOAP(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set("SOAPAction", "x")
+	WriteProblem(w, r, soaphlp.Fault{Code: "soap:Server", Reason: "boom"})
+	if w.Code != 500 || !strings.Contains(w.Body.String(), "<faultstring>boom</faultstring>") {
+		t.Errorf("got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestWriteProblemSOAPInternal(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set("SOAPAction", "x")
+	WriteProblem(w, r, errors.New("secret"))
+	if w.Code != 500 || strings.Contains(w.Body.String(), "secret") ||
+		!strings.Contains(w.Body.String(), "<faultstring>Internal Server Error</faultstring>") {
+		t.Errorf("got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	WriteProblem(w, r, StatusError{Code: http.StatusBadRequest, Err: errors.New("bad input")})
+	if w.Code != 400 || !strings.Contains(w.Body.String(), "bad input") {
+		t.Errorf("got %d %s", w.Code, w.Body.String())
+	}
+}
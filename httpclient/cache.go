@@ -0,0 +1,590 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httpclient
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMaxCacheEntrySize is the maximum body size stored by CacheTransport
+// if MaxEntrySize is zero.
+const DefaultMaxCacheEntrySize = 16 << 20
+
+// CacheStorage is the storage backend for CacheTransport.
+type CacheStorage interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+// WithCache returns an Option that caches responses in the given storage.
+func WithCache(storage CacheStorage) Option {
+	return WithCacheTransport(&CacheTransport{Storage: storage})
+}
+
+// WithCacheTransport returns an Option that uses the given CacheTransport
+// (for example to be able to read its Stats).
+//
+// If the wrapped RoundTripper is a TransportWithBreaker and ct.Breaker is nil,
+// then its Breaker is used.
+func WithCacheTransport(ct *CacheTransport) Option {
+	return func(tr http.RoundTripper) http.RoundTripper {
+		ct.Tripper = tr
+		if twb, ok := tr.(TransportWithBreaker); ok && ct.Breaker == nil {
+			ct.Breaker = twb.Breaker
+		}
+		return ct
+	}
+}
+
+// CacheTransport is an RFC 9111 HTTP cache.
+//
+// Only GET responses are stored; unsafe methods invalidate the stored response for the URL.
+// Responses with Vary are stored per variant, at most 8 for a URL.
+// Stored responses are revalidated with If-None-Match / If-Modified-Since,
+// and stale responses are served when the origin fails (or the Breaker is open)
+// and stale-if-error (RFC 5861) permits it.
+type CacheTransport struct {
+	Tripper http.RoundTripper
+	Storage CacheStorage
+	// Breaker is consulted before going to the network:
+	// if it is open, a stale response is served if stale-if-error allows it.
+	Breaker Breaker
+	// now is used for testing.
+	now func() time.Time
+	// MaxEntrySize is the maximum size of a body to be stored.
+	MaxEntrySize int
+	// Shared makes the cache behave as a shared cache:
+	// s-maxage is honoured, private responses are not stored.
+	Shared bool
+
+	hits, misses, revalidated, stale atomic.Uint64
+}
+
+// CacheStats are the counters of a CacheTransport.
+type CacheStats struct {
+	// Hits is the number of responses served from the cache without contacting the origin.
+	Hits uint64
+	// Misses is the number of requests forwarded to the origin without a usable stored response.
+	Misses uint64
+	// Revalidated is the number of stored responses validated with a 304 Not Modified.
+	Revalidated uint64
+	// Stale is the number of stale responses served due to an error.
+	Stale uint64
+}
+
+// Stats returns the current counters.
+func (t *CacheTransport) Stats() CacheStats {
+	return CacheStats{
+		Hits: t.hits.Load(), Misses: t.misses.Load(),
+		Revalidated: t.revalidated.Load(), Stale: t.stale.Load(),
+	}
+}
+
+func (t *CacheTransport) tripper() http.RoundTripper {
+	if t.Tripper == nil {
+		return http.DefaultTransport
+	}
+	return t.Tripper
+}
+
+func (t *CacheTransport) timeNow() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+// RoundTrip serves the request from the cache, or forwards it to the Tripper.
+func (t *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := cacheKey(req)
+	if req.Method != http.MethodGet {
+		resp, err := t.tripper().RoundTrip(req)
+		if err == nil && !isSafeMethod(req.Method) && resp.StatusCode < 400 {
+			t.invalidate(key)
+			for _, h := range []string{"Location", "Content-Location"} {
+				if u, err := req.URL.Parse(resp.Header.Get(h)); err == nil && u.Host == req.URL.Host {
+					t.invalidate(u.String())
+				}
+			}
+		}
+		return resp, err
+	}
+
+	reqCC := parseCacheControl(req.Header)
+	if reqCC.has("no-store") || req.Header.Get("Range") != "" {
+		t.misses.Add(1)
+		return t.tripper().RoundTrip(req)
+	}
+	if len(req.Header["Cache-Control"]) == 0 && req.Header.Get("Pragma") == "no-cache" {
+		reqCC["no-cache"] = ""
+	}
+	conditional := req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+
+	var ent *cacheEntry
+	entKey := key
+	if !conditional {
+		ent, entKey = t.lookup(key, req)
+	}
+	now := t.timeNow()
+	if ent != nil && ent.usable(reqCC, now, t.Shared) {
+		t.hits.Add(1)
+		return ent.response(req, now, "hit"), nil
+	}
+	if reqCC.has("only-if-cached") {
+		t.misses.Add(1)
+		return &http.Response{
+			Status: "504 Gateway Timeout", StatusCode: http.StatusGatewayTimeout,
+			Proto: "HTTP/1.1", ProtoMajor: 1, ProtoMinor: 1,
+			Header:  http.Header{"Cache-Status": {cacheStatusName + "; fwd=miss"}},
+			Body:    http.NoBody,
+			Request: req,
+		}, nil
+	}
+
+	if ent != nil && t.Breaker != nil && t.Breaker.Opened() && ent.staleIfError(reqCC, now, t.Shared) {
+		t.stale.Add(1)
+		return ent.response(req, now, "hit; detail=stale-if-error"), nil
+	}
+
+	outReq := req
+	if ent != nil {
+		outReq = req.Clone(req.Context())
+		if etag := ent.Header.Get("ETag"); etag != "" {
+			outReq.Header.Set("If-None-Match", etag)
+		}
+		if lm := ent.Header.Get("Last-Modified"); lm != "" {
+			outReq.Header.Set("If-Modified-Since", lm)
+		}
+	}
+	reqTime := now
+	resp, err := t.tripper().RoundTrip(outReq)
+	now = t.timeNow()
+	if ent != nil && (err != nil || isServerError(resp.StatusCode)) && ent.staleIfError(reqCC, now, t.Shared) {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		t.stale.Add(1)
+		return ent.response(req, now, "hit; detail=stale-if-error"), nil
+	}
+	if err != nil {
+		t.misses.Add(1)
+		return resp, err
+	}
+
+	if ent != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		t.revalidated.Add(1)
+		ent.update(resp.Header, reqTime, now)
+		t.store(entKey, ent)
+		return ent.response(req, now, "fwd=stale; fwd-status=304"), nil
+	}
+
+	t.misses.Add(1)
+	fwd := "fwd=uri-miss"
+	if ent != nil {
+		fwd = "fwd=stale"
+	}
+	if !t.storable(req, resp, reqCC) {
+		if ent != nil {
+			t.Storage.Delete(entKey)
+		}
+		resp.Header.Set("Cache-Status", cacheStatusName+"; "+fwd+"; fwd-status="+strconv.Itoa(resp.StatusCode))
+		return resp, nil
+	}
+	resp.Header.Set("Cache-Status", cacheStatusName+"; "+fwd+"; fwd-status="+strconv.Itoa(resp.StatusCode)+"; stored")
+	maxSize := t.MaxEntrySize
+	if maxSize == 0 {
+		maxSize = DefaultMaxCacheEntrySize
+	}
+	newEnt := &cacheEntry{
+		Status: resp.Status, StatusCode: resp.StatusCode,
+		Header:      resp.Header.Clone(),
+		Vary:        varyHeader(req, resp.Header),
+		RequestTime: reqTime, ResponseTime: now,
+	}
+	newEnt.Header.Del("Cache-Status")
+	resp.Body = &cachingBody{
+		ReadCloser: resp.Body, limit: maxSize,
+		store: func(body []byte) {
+			newEnt.Body = body
+			t.storeVariant(key, req, newEnt)
+		},
+	}
+	return resp, nil
+}
+
+const cacheStatusName = "httpclient"
+
+func (t *CacheTransport) load(key string) *cacheEntry {
+	b, ok := t.Storage.Get(key)
+	if !ok {
+		return nil
+	}
+	var ent cacheEntry
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&ent); err != nil {
+		t.Storage.Delete(key)
+		return nil
+	}
+	return &ent
+}
+
+// lookup the stored response for the request, returning also its key.
+//
+// A response with Vary is stored under a key including the values of the
+// selecting request header fields, and the URL's key holds the list of these variants.
+func (t *CacheTransport) lookup(key string, req *http.Request) (*cacheEntry, string) {
+	ent := t.load(key)
+	if ent == nil || len(ent.Variants) == 0 {
+		if ent != nil && !ent.matchesVary(req) {
+			ent = nil
+		}
+		return ent, key
+	}
+	vk := variantKey(key, ent.Vary, req)
+	if !slices.Contains(ent.Variants, vk) {
+		return nil, vk
+	}
+	if ent = t.load(vk); ent != nil && !ent.matchesVary(req) {
+		ent = nil
+	}
+	return ent, vk
+}
+
+// storeVariant stores the response under the URL's key, or as a variant if it has Vary.
+func (t *CacheTransport) storeVariant(key string, req *http.Request, ent *cacheEntry) {
+	idx := t.load(key)
+	if len(ent.Vary) == 0 {
+		if idx != nil {
+			for _, vk := range idx.Variants {
+				t.Storage.Delete(vk)
+			}
+		}
+		t.store(key, ent)
+		return
+	}
+	if idx == nil || len(idx.Variants) == 0 {
+		idx = &cacheEntry{}
+	}
+	idx.Vary = make(http.Header, len(ent.Vary))
+	for k := range ent.Vary {
+		idx.Vary[k] = nil
+	}
+	vk := variantKey(key, ent.Vary, req)
+	idx.Variants = append(slices.DeleteFunc(idx.Variants, func(s string) bool { return s == vk }), vk)
+	if n := len(idx.Variants) - maxCacheVariants; n > 0 {
+		for _, old := range idx.Variants[:n] {
+			t.Storage.Delete(old)
+		}
+		idx.Variants = slices.Delete(idx.Variants, 0, n)
+	}
+	t.store(vk, ent)
+	t.store(key, idx)
+}
+
+// invalidate deletes the stored response for the key, with all its variants.
+func (t *CacheTransport) invalidate(key string) {
+	if idx := t.load(key); idx != nil {
+		for _, vk := range idx.Variants {
+			t.Storage.Delete(vk)
+		}
+	}
+	t.Storage.Delete(key)
+}
+
+func (t *CacheTransport) store(key string, ent *cacheEntry) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(ent); err == nil {
+		t.Storage.Set(key, buf.Bytes())
+	}
+}
+
+// storable implements RFC 9111 Section 3.
+func (t *CacheTransport) storable(req *http.Request, resp *http.Response, reqCC cacheControl) bool {
+	if reqCC.has("no-store") {
+		return false
+	}
+	cc := parseCacheControl(resp.Header)
+	if cc.has("no-store") || (t.Shared && cc.has("private")) {
+		return false
+	}
+	if resp.Header.Get("Vary") == "*" {
+		return false
+	}
+	if t.Shared && req.Header.Get("Authorization") != "" &&
+		!(cc.has("public") || cc.has("s-maxage") || cc.has("must-revalidate")) {
+		return false
+	}
+	if resp.StatusCode == http.StatusPartialContent {
+		return false
+	}
+	if heuristicallyCacheable(resp.StatusCode) {
+		return true
+	}
+	return cc.has("max-age") || (t.Shared && cc.has("s-maxage")) ||
+		resp.Header.Get("Expires") != "" || cc.has("public")
+}
+
+func cacheKey(req *http.Request) string { return req.URL.String() }
+
+// maxCacheVariants is the maximum number of variants stored for a URL.
+const maxCacheVariants = 8
+
+// variantKey returns the key of the variant selected by the vary fields' values in the request.
+func variantKey(key string, vary http.Header, req *http.Request) string {
+	var buf strings.Builder
+	buf.WriteString(key)
+	for _, k := range slices.Sorted(maps.Keys(vary)) {
+		buf.WriteByte(0)
+		buf.WriteString(k)
+		buf.WriteByte(':')
+		buf.WriteString(strings.Join(req.Header.Values(k), ", "))
+	}
+	return buf.String()
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func isServerError(code int) bool {
+	switch code {
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// heuristicallyCacheable reports whether the status code is cacheable by default (RFC 9110 Section 15.1).
+func heuristicallyCacheable(code int) bool {
+	switch code {
+	case 200, 203, 204, 300, 301, 308, 404, 405, 410, 414, 501:
+		return true
+	}
+	return false
+}
+
+// varyHeader returns the request header fields nominated by the Vary response header.
+func varyHeader(req *http.Request, respHeader http.Header) http.Header {
+	var vary http.Header
+	for _, v := range respHeader.Values("Vary") {
+		for f := range strings.SplitSeq(v, ",") {
+			if f = http.CanonicalHeaderKey(strings.TrimSpace(f)); f == "" {
+				continue
+			}
+			if vary == nil {
+				vary = make(http.Header)
+			}
+			vary[f] = req.Header.Values(f)
+		}
+	}
+	return vary
+}
+
+type cacheEntry struct {
+	RequestTime, ResponseTime time.Time
+	Header, Vary              http.Header
+	Status                    string
+	Body                      []byte
+	// Variants lists the keys of the stored variants of a response with Vary:
+	// such an entry, stored under the URL's key, only serves as an index.
+	Variants   []string
+	StatusCode int
+}
+
+func (e *cacheEntry) matchesVary(req *http.Request) bool {
+	for k, vv := range e.Vary {
+		if strings.Join(req.Header.Values(k), ", ") != strings.Join(vv, ", ") {
+			return false
+		}
+	}
+	return true
+}
+
+// update the stored header with the fields of a 304 response (RFC 9111 Section 3.2).
+func (e *cacheEntry) update(header http.Header, reqTime, respTime time.Time) {
+	for k, vv := range header {
+		switch k {
+		case "Content-Length", "Content-Encoding", "Transfer-Encoding", "Content-Range":
+			continue
+		}
+		e.Header[k] = vv
+	}
+	e.RequestTime, e.ResponseTime = reqTime, respTime
+}
+
+// currentAge implements RFC 9111 Section 4.2.3.
+func (e *cacheEntry) currentAge(now time.Time) time.Duration {
+	date, err := http.ParseTime(e.Header.Get("Date"))
+	if err != nil {
+		date = e.ResponseTime
+	}
+	apparentAge := max(0, e.ResponseTime.Sub(date))
+	var ageValue time.Duration
+	if n, err := strconv.ParseInt(e.Header.Get("Age"), 10, 64); err == nil && n > 0 {
+		ageValue = time.Duration(n) * time.Second
+	}
+	correctedAgeValue := ageValue + e.ResponseTime.Sub(e.RequestTime)
+	return max(apparentAge, correctedAgeValue) + now.Sub(e.ResponseTime)
+}
+
+// freshnessLifetime implements RFC 9111 Section 4.2.1.
+func (e *cacheEntry) freshnessLifetime(shared bool) time.Duration {
+	cc := parseCacheControl(e.Header)
+	if shared {
+		if d, ok := cc.duration("s-maxage"); ok {
+			return d
+		}
+	}
+	if d, ok := cc.duration("max-age"); ok {
+		return d
+	}
+	date, err := http.ParseTime(e.Header.Get("Date"))
+	if err != nil {
+		date = e.ResponseTime
+	}
+	if s := e.Header.Get("Expires"); s != "" {
+		expires, err := http.ParseTime(s)
+		if err != nil {
+			return 0
+		}
+		return max(0, expires.Sub(date))
+	}
+	if heuristicallyCacheable(e.StatusCode) {
+		if lm, err := http.ParseTime(e.Header.Get("Last-Modified")); err == nil && lm.Before(date) {
+			return min(date.Sub(lm)/10, 24*time.Hour)
+		}
+	}
+	return 0
+}
+
+// usable reports whether the stored response can be served without validation.
+func (e *cacheEntry) usable(reqCC cacheControl, now time.Time, shared bool) bool {
+	cc := parseCacheControl(e.Header)
+	if reqCC.has("no-cache") || cc.has("no-cache") {
+		return false
+	}
+	lifetime, age := e.freshnessLifetime(shared), e.currentAge(now)
+	if d, ok := reqCC.duration("max-age"); ok && age > d {
+		return false
+	}
+	if d, ok := reqCC.duration("min-fresh"); ok && lifetime-age < d {
+		return false
+	}
+	if age < lifetime {
+		return true
+	}
+	if cc.has("must-revalidate") || (shared && cc.has("proxy-revalidate")) {
+		return false
+	}
+	if v, ok := reqCC["max-stale"]; ok {
+		if v == "" {
+			return true
+		}
+		d, ok := reqCC.duration("max-stale")
+		return ok && age-lifetime <= d
+	}
+	return false
+}
+
+// staleIfError reports whether the stale response can be served in case of an error (RFC 5861 Section 4).
+func (e *cacheEntry) staleIfError(reqCC cacheControl, now time.Time, shared bool) bool {
+	cc := parseCacheControl(e.Header)
+	if cc.has("must-revalidate") || (shared && (cc.has("proxy-revalidate") || cc.has("s-maxage"))) {
+		return false
+	}
+	staleness := e.currentAge(now) - e.freshnessLifetime(shared)
+	for _, c := range []cacheControl{reqCC, cc} {
+		if d, ok := c.duration("stale-if-error"); ok && staleness <= d {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *cacheEntry) response(req *http.Request, now time.Time, status string) *http.Response {
+	resp := &http.Response{
+		Status: e.Status, StatusCode: e.StatusCode,
+		Proto: "HTTP/1.1", ProtoMajor: 1, ProtoMinor: 1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+	resp.Header.Set("Age", strconv.FormatInt(int64(e.currentAge(now)/time.Second), 10))
+	resp.Header.Set("Cache-Status", cacheStatusName+"; "+status)
+	return resp
+}
+
+// cachingBody stores the body when read till EOF.
+type cachingBody struct {
+	io.ReadCloser
+	store func([]byte)
+	buf   bytes.Buffer
+	limit int
+	done  bool
+}
+
+func (cb *cachingBody) Read(p []byte) (int, error) {
+	n, err := cb.ReadCloser.Read(p)
+	if !cb.done {
+		if cb.buf.Len()+n > cb.limit {
+			cb.done = true
+			cb.buf = bytes.Buffer{}
+		} else {
+			cb.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF && !cb.done {
+		cb.done = true
+		cb.store(bytes.Clone(cb.buf.Bytes()))
+		cb.buf = bytes.Buffer{}
+	}
+	return n, err
+}
+
+type cacheControl map[string]string
+
+func parseCacheControl(h http.Header) cacheControl {
+	cc := make(cacheControl)
+	for _, v := range h.Values("Cache-Control") {
+		for d := range strings.SplitSeq(v, ",") {
+			k, v, _ := strings.Cut(strings.TrimSpace(d), "=")
+			if k = strings.ToLower(strings.TrimSpace(k)); k == "" {
+				continue
+			}
+			cc[k] = strings.Trim(strings.TrimSpace(v), `"`)
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(k string) bool { _, ok := cc[k]; return ok }
+
+func (cc cacheControl) duration(k string) (time.Duration, bool) {
+	v, ok := cc[k]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
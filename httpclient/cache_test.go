@@ -0,0 +1,201 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+)
+
+func TestCacheTransport(t *testing.T) {
+	var hits atomic.Int32
+	var fail atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if fail.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache, stale-if-error=3600")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=600")
+			w.Header().Set("Vary", "Accept-Language")
+			io.WriteString(w, r.Header.Get("Accept-Language"))
+			return
+		case "/nostore":
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		io.WriteString(w, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	ct := &CacheTransport{Storage: NewMemoryCache(1 << 20), now: func() time.Time { return now }}
+	cl := &http.Client{Transport: ct}
+	get := func(path string, header ...string) (string, string) {
+		t.Helper()
+		req, err := http.NewRequest("GET", srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := cl.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: %s", path, resp.Status)
+		}
+		return string(b), resp.Header.Get("Cache-Status")
+	}
+	wantHits := func(want int32) {
+		t.Helper()
+		if got := hits.Swap(0); got != want {
+			t.Errorf("got %d requests to the origin, wanted %d", got, want)
+		}
+	}
+
+	for range 3 {
+		if got, _ := get("/fresh"); got != "/fresh" {
+			t.Errorf("got %q", got)
+		}
+	}
+	wantHits(1)
+	now = now.Add(61 * time.Second)
+	get("/fresh")
+	wantHits(1)
+
+	get("/etag")
+	_, status := get("/etag")
+	wantHits(2)
+	if st := ct.Stats(); st.Revalidated != 1 {
+		t.Errorf("got %+v, wanted 1 revalidation (%q)", st, status)
+	}
+
+	if got, _ := get("/vary", "Accept-Language", "hu"); got != "hu" {
+		t.Errorf("got %q", got)
+	}
+	if got, _ := get("/vary", "Accept-Language", "en"); got != "en" {
+		t.Errorf("got %q", got)
+	}
+	wantHits(2)
+	for _, lang := range []string{"hu", "en"} {
+		if got, status := get("/vary", "Accept-Language", lang); got != lang {
+			t.Errorf("got %q (%q), wanted %q", got, status, lang)
+		}
+	}
+	wantHits(0)
+	if resp, err := cl.Post(srv.URL+"/vary", "text/plain", nil); err != nil {
+		t.Fatal(err)
+	} else {
+		resp.Body.Close()
+	}
+	get("/vary", "Accept-Language", "hu")
+	get("/vary", "Accept-Language", "en")
+	wantHits(3)
+
+	get("/nostore")
+	get("/nostore")
+	wantHits(2)
+
+	fail.Store(true)
+	if got, status := get("/etag"); got != "/etag" {
+		t.Errorf("stale-if-error: got %q (%q)", got, status)
+	}
+	wantHits(1)
+	if st := ct.Stats(); st.Stale != 1 {
+		t.Errorf("got %+v, wanted 1 stale", st)
+	}
+
+	ct.Breaker = openBreaker{}
+	get("/etag")
+	wantHits(0)
+}
+
+func TestCacheControlAge(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	ent := cacheEntry{
+		StatusCode: 200,
+		Header: http.Header{
+			"Date":          {now.Add(-10 * time.Second).Format(http.TimeFormat)},
+			"Age":           {"5"},
+			"Cache-Control": {"max-age=30"},
+		},
+		RequestTime: now.Add(-2 * time.Second), ResponseTime: now,
+	}
+	if got := ent.currentAge(now.Add(3 * time.Second)); got != 13*time.Second {
+		t.Errorf("age: got %s", got)
+	}
+	if got := ent.freshnessLifetime(false); got != 30*time.Second {
+		t.Errorf("lifetime: got %s", got)
+	}
+	if !ent.usable(cacheControl{}, now, false) {
+		t.Error("should be fresh")
+	}
+	if ent.usable(cacheControl{"max-age": "5"}, now, false) {
+		t.Error("request max-age=5 should prevent usage")
+	}
+	if !ent.usable(cacheControl{"max-stale": strconv.Itoa(10)}, now.Add(25*time.Second), false) {
+		t.Error("max-stale should allow stale")
+	}
+}
+
+func TestDirCache(t *testing.T) {
+	d := DirCache(t.TempDir())
+	if _, ok := d.Get("a"); ok {
+		t.Fatal("found non-existing")
+	}
+	d.Set("a", []byte("b"))
+	if b, ok := d.Get("a"); !ok || string(b) != "b" {
+		t.Errorf("got %q, %t", b, ok)
+	}
+	d.Delete("a")
+	if _, ok := d.Get("a"); ok {
+		t.Error("found deleted")
+	}
+}
+
+func TestMemoryCacheLRU(t *testing.T) {
+	c := NewMemoryCache(3)
+	c.Set("a", []byte("a"))
+	c.Set("b", []byte("b"))
+	c.Set("c", []byte("c"))
+	c.Get("a")
+	c.Set("d", []byte("d"))
+	if _, ok := c.Get("b"); ok {
+		t.Error("b should be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("a should be kept")
+	}
+}
+
+type openBreaker struct{}
+
+func (openBreaker) Execute(func() (any, error)) (any, error) { return nil, gobreaker.ErrOpenState }
+func (openBreaker) Opened() bool                             { return true }
@@ -0,0 +1,123 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httpclient
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/tgulacsi/go/temp"
+)
+
+var (
+	_ CacheStorage = (*MemoryCache)(nil)
+	_ CacheStorage = DirCache("")
+)
+
+// MemoryCache is an in-memory LRU CacheStorage, limited by the total size of the stored values.
+type MemoryCache struct {
+	ll      *list.List
+	items   map[string]*list.Element
+	mu      sync.Mutex
+	size    int
+	maxSize int
+}
+
+type memoryCacheItem struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryCache returns a new MemoryCache which holds at most maxSize bytes.
+func NewMemoryCache(maxSize int) *MemoryCache {
+	return &MemoryCache{ll: list.New(), items: make(map[string]*list.Element), maxSize: maxSize}
+}
+
+// Get the value for the key.
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+	return e.Value.(*memoryCacheItem).value, true
+}
+
+// Set the value for the key, evicting the least recently used items if needed.
+func (c *MemoryCache) Set(key string, value []byte) {
+	if len(value) > c.maxSize {
+		c.Delete(key)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		it := e.Value.(*memoryCacheItem)
+		c.size += len(value) - len(it.value)
+		it.value = value
+		c.ll.MoveToFront(e)
+	} else {
+		c.items[key] = c.ll.PushFront(&memoryCacheItem{key: key, value: value})
+		c.size += len(value)
+	}
+	for c.size > c.maxSize {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// Delete the key.
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.removeElement(e)
+	}
+}
+
+// Len returns the number of stored items.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *MemoryCache) removeElement(e *list.Element) {
+	it := c.ll.Remove(e).(*memoryCacheItem)
+	delete(c.items, it.key)
+	c.size -= len(it.value)
+}
+
+// DirCache is an on-disk CacheStorage, storing each value in a file named after the hash of the key.
+type DirCache string
+
+func (d DirCache) path(key string) string {
+	hsh := sha256.Sum256([]byte(key))
+	s := hex.EncodeToString(hsh[:])
+	return filepath.Join(string(d), s[:2], s[2:])
+}
+
+// Get the value for the key.
+func (d DirCache) Get(key string) ([]byte, bool) {
+	b, err := os.ReadFile(d.path(key))
+	return b, err == nil
+}
+
+// Set the value for the key, atomically.
+func (d DirCache) Set(key string, value []byte) {
+	fn := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(fn), 0750); err != nil {
+		return
+	}
+	_ = temp.WriteFileAtomic(fn, value, 0640)
+}
+
+// Delete the key.
+func (d DirCache) Delete(key string) { _ = os.Remove(d.path(key)) }
@@ -0,0 +1,20 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package httpclient
+
+import (
+	"net/http"
+
+	"github.com/tgulacsi/go/httphdr"
+)
+
+// WithSigner returns an Option that signs the requests with the Signer
+// (RFC 9421 HTTP Message Signatures), sending them through the wrapped RoundTripper.
+func WithSigner(s *httphdr.Signer) Option {
+	return func(tr http.RoundTripper) http.RoundTripper {
+		s.Transport = tr
+		return s
+	}
+}
@@ -25,11 +25,15 @@ import (
 	"strings"
 	"sync"
 	"unicode"
+	"unicode/utf8"
 )
 
 var bPool = sync.Pool{New: func() any { return bytes.NewBuffer(make([]byte, 0, 128)) }}
 
 // ContentDisposition returns a formatted http://tools.ietf.org/html/rfc6266 header.
+//
+// Non-ASCII file names are replaced with '_' in the filename parameter,
+// and UTF-8 percent-encoded in the filename* parameter (RFC 8187).
 func ContentDisposition(dispType string, filename string) string {
 	b := bPool.Get().(*bytes.Buffer)
 	defer func() {
@@ -38,26 +42,40 @@ func ContentDisposition(dispType string, filename string) string {
 	}()
 	b.WriteString(dispType)
 	b.WriteString(`; filename="`)
-	justLatin := true
-	start := b.Len()
+	justASCII := true
 	for _, r := range filename {
-		if r < unicode.MaxLatin1 && r != '"' && !unicode.IsControl(r) && !unicode.IsSpace(r) {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
 			b.WriteByte(byte(r))
-		} else {
-			fmt.Fprintf(b, "%%%x", r)
-			justLatin = false
+		case r < utf8.RuneSelf && !unicode.IsControl(r):
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('_')
+			justASCII = false
 		}
 	}
-	if justLatin {
-		b.WriteByte('"')
+	b.WriteByte('"')
+	if justASCII {
 		return b.String()
 	}
-	encoded := b.Bytes()[start:]
-	b.WriteString(`" filename*=utf-8''`)
-	b.Write(encoded)
+	b.WriteString("; filename*=UTF-8''")
+	for i := 0; i < len(filename); i++ {
+		if c := filename[i]; isAttrChar(c) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(b, "%%%02X", c)
+		}
+	}
 	return b.String()
 }
 
+// isAttrChar reports whether the byte is an attr-char of RFC 8187.
+func isAttrChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
+
 type Accept []KeyVal
 type KeyVal struct {
 	Val any
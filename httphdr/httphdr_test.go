@@ -33,3 +33,15 @@ func TestAccept(t *testing.T) {
 		}
 	}
 }
+
+func TestContentDisposition(t *testing.T) {
+	for k, want := range map[string]string{
+		"a.pdf":         `attachment; filename="a.pdf"`,
+		`a "b".pdf`:     `attachment; filename="a \"b\".pdf"`,
+		"árvíztűrő.txt": `attachment; filename="_rv_zt_r_.txt"; filename*=UTF-8''%C3%A1rv%C3%ADzt%C5%B1r%C5%91.txt`,
+	} {
+		if got := ContentDisposition("attachment", k); got != want {
+			t.Errorf("%q: got %q, wanted %q", k, got, want)
+		}
+	}
+}
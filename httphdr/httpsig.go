@@ -0,0 +1,588 @@
+// Copyright 2026 Tamás Gulácsi.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httphdr
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RFC 9421 HTTP Message Signature algorithms.
+const (
+	AlgHMACSHA256      = "hmac-sha256"
+	AlgEd25519         = "ed25519"
+	AlgECDSAP256SHA256 = "ecdsa-p256-sha256"
+	AlgRSAPSSSHA512    = "rsa-pss-sha512"
+)
+
+// DefaultSignatureLabel is the label of the signature made by Signer.
+const DefaultSignatureLabel = "sig1"
+
+var (
+	ErrSignatureMissing = errors.New("signature missing")
+	ErrSignatureInvalid = errors.New("signature invalid")
+	ErrDigestMismatch   = errors.New("content digest mismatch")
+)
+
+// SigKey is a key for signing or verifying HTTP Message Signatures.
+//
+// Key is
+//   - []byte for hmac-sha256,
+//   - ed25519.PrivateKey or ed25519.PublicKey for ed25519,
+//   - *ecdsa.PrivateKey or *ecdsa.PublicKey (P-256) for ecdsa-p256-sha256,
+//   - *rsa.PrivateKey or *rsa.PublicKey for rsa-pss-sha512.
+type SigKey struct {
+	Key any
+	// ID is the keyid signature parameter.
+	ID string
+	// Alg is the algorithm, inferred from the Key if empty.
+	Alg string
+}
+
+// KeyResolver resolves the key by its ID.
+type KeyResolver interface {
+	ResolveKey(ctx context.Context, keyID string) (SigKey, error)
+}
+
+// KeyResolverFunc is a function implementing KeyResolver.
+type KeyResolverFunc func(ctx context.Context, keyID string) (SigKey, error)
+
+// ResolveKey calls f.
+func (f KeyResolverFunc) ResolveKey(ctx context.Context, keyID string) (SigKey, error) {
+	return f(ctx, keyID)
+}
+
+// StaticKeys is a KeyResolver of a fixed set of keys.
+type StaticKeys map[string]SigKey
+
+// ResolveKey returns the key with the ID.
+func (m StaticKeys) ResolveKey(ctx context.Context, keyID string) (SigKey, error) {
+	if k, ok := m[keyID]; ok {
+		if k.ID == "" {
+			k.ID = keyID
+		}
+		return k, nil
+	}
+	return SigKey{}, fmt.Errorf("%w: unknown key %q", ErrSignatureInvalid, keyID)
+}
+
+func (k SigKey) alg() (string, error) {
+	if k.Alg != "" {
+		return k.Alg, nil
+	}
+	switch key := k.Key.(type) {
+	case []byte:
+		return AlgHMACSHA256, nil
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return AlgEd25519, nil
+	case *ecdsa.PrivateKey, *ecdsa.PublicKey:
+		return AlgECDSAP256SHA256, nil
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return AlgRSAPSSSHA512, nil
+	default:
+		return "", fmt.Errorf("unknown key type %T", key)
+	}
+}
+
+func (k SigKey) sign(base []byte) ([]byte, error) {
+	alg, err := k.alg()
+	if err != nil {
+		return nil, err
+	}
+	switch key := k.Key.(type) {
+	case []byte:
+		if alg == AlgHMACSHA256 {
+			mac := hmac.New(sha256.New, key)
+			mac.Write(base)
+			return mac.Sum(nil), nil
+		}
+	case ed25519.PrivateKey:
+		if alg == AlgEd25519 {
+			return ed25519.Sign(key, base), nil
+		}
+	case *ecdsa.PrivateKey:
+		if alg == AlgECDSAP256SHA256 {
+			hsh := sha256.Sum256(base)
+			r, s, err := ecdsa.Sign(rand.Reader, key, hsh[:])
+			if err != nil {
+				return nil, err
+			}
+			return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...), nil
+		}
+	case *rsa.PrivateKey:
+		if alg == AlgRSAPSSSHA512 {
+			hsh := sha512.Sum512(base)
+			return rsa.SignPSS(rand.Reader, key, crypto.SHA512, hsh[:], &rsa.PSSOptions{SaltLength: 64})
+		}
+	}
+	return nil, fmt.Errorf("cannot sign %s with %T", alg, k.Key)
+}
+
+func (k SigKey) verify(base, sig []byte) error {
+	alg, err := k.alg()
+	if err != nil {
+		return err
+	}
+	key := k.Key
+	switch x := key.(type) {
+	case ed25519.PrivateKey:
+		key = x.Public()
+	case *ecdsa.PrivateKey:
+		key = &x.PublicKey
+	case *rsa.PrivateKey:
+		key = &x.PublicKey
+	}
+	ok := false
+	switch key := key.(type) {
+	case []byte:
+		if alg == AlgHMACSHA256 {
+			mac := hmac.New(sha256.New, key)
+			mac.Write(base)
+			ok = subtle.ConstantTimeCompare(mac.Sum(nil), sig) == 1
+		}
+	case ed25519.PublicKey:
+		if alg == AlgEd25519 {
+			ok = ed25519.Verify(key, base, sig)
+		}
+	case *ecdsa.PublicKey:
+		if alg == AlgECDSAP256SHA256 && len(sig) == 64 {
+			hsh := sha256.Sum256(base)
+			ok = ecdsa.Verify(key, hsh[:],
+				new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:]))
+		}
+	case *rsa.PublicKey:
+		if alg == AlgRSAPSSSHA512 {
+			hsh := sha512.Sum512(base)
+			ok = rsa.VerifyPSS(key, crypto.SHA512, hsh[:], sig, &rsa.PSSOptions{SaltLength: 64}) == nil
+		}
+	default:
+		return fmt.Errorf("cannot verify %s with %T", alg, k.Key)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s verification failed", ErrSignatureInvalid, alg)
+	}
+	return nil
+}
+
+// Signer signs the requests (RFC 9421) and sends them with Transport (http.DefaultTransport if nil).
+type Signer struct {
+	Transport http.RoundTripper
+	now       func() time.Time
+	Key       SigKey
+	// Label of the signature, DefaultSignatureLabel if empty.
+	Label string
+	// Tag is the optional tag signature parameter.
+	Tag string
+	// Components to be signed: derived components (such as @method, @target-uri, @authority)
+	// and lowercase header names.
+	// Defaults to @method, @target-uri, @authority, and content-digest if the request has a body.
+	Components []string
+	// Expiry sets the expires signature parameter, if not zero.
+	Expiry time.Duration
+}
+
+// RoundTrip signs a clone of the request and sends it.
+func (s *Signer) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if err := s.Sign(req); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	tr := s.Transport
+	if tr == nil {
+		tr = http.DefaultTransport
+	}
+	return tr.RoundTrip(req)
+}
+
+// Sign the request: set the Content-Digest (if it has a body), Signature-Input and Signature headers.
+func (s *Signer) Sign(req *http.Request) error {
+	alg, err := s.Key.alg()
+	if err != nil {
+		return err
+	}
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if hasBody && req.Header.Get("Content-Digest") == "" {
+		var body io.ReadCloser
+		if req.GetBody != nil {
+			if body, err = req.GetBody(); err != nil {
+				return err
+			}
+		} else {
+			b, err := io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(b))
+			req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(b)), nil }
+			body = io.NopCloser(bytes.NewReader(b))
+		}
+		digest, err := ContentDigest(body)
+		body.Close()
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Digest", digest)
+	}
+
+	components := s.Components
+	if len(components) == 0 {
+		components = []string{"@method", "@target-uri", "@authority"}
+		if hasBody {
+			components = append(components, "content-digest")
+		}
+	}
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	created := now().Unix()
+	params := Params{{Key: "created", Value: created}}
+	if s.Expiry > 0 {
+		params = append(params, Param{Key: "expires", Value: created + int64(s.Expiry/time.Second)})
+	}
+	params = append(params, Param{Key: "keyid", Value: s.Key.ID}, Param{Key: "alg", Value: alg})
+	if s.Tag != "" {
+		params = append(params, Param{Key: "tag", Value: s.Tag})
+	}
+	il := InnerList{Items: make([]Item, len(components)), Params: params}
+	for i, c := range components {
+		il.Items[i] = Item{Value: c}
+	}
+	base, sigParams, err := signatureBase(messageFromRequest(req, false), il)
+	if err != nil {
+		return err
+	}
+	sig, err := s.Key.sign(base)
+	if err != nil {
+		return err
+	}
+	label := s.Label
+	if label == "" {
+		label = DefaultSignatureLabel
+	}
+	req.Header.Add("Signature-Input", label+"="+sigParams)
+	b, err := Dictionary{{Key: label, Value: Item{Value: sig}}}.MarshalText()
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Signature", string(b))
+	return nil
+}
+
+// Verifier verifies the signatures (RFC 9421) and the Content-Digest (RFC 9530) of the requests.
+type Verifier struct {
+	Keys KeyResolver
+	now  func() time.Time
+	// Label of the signature to be verified - the first one if empty.
+	Label string
+	// Required components which must be covered by the signature.
+	// Defaults to @method, @target-uri and content-digest if the request has a body.
+	Required []string
+	// MaxAge is the maximum age of the signature (by its created parameter), if not zero.
+	MaxAge time.Duration
+}
+
+type sigKeyIDCtxKey struct{}
+
+// SignatureKeyIDFromContext returns the key ID of the verified signature.
+func SignatureKeyIDFromContext(ctx context.Context) (string, bool) {
+	s, ok := ctx.Value(sigKeyIDCtxKey{}).(string)
+	return s, ok
+}
+
+// Middleware verifies the requests before calling next,
+// responding with 401 for invalid signatures and 400 for Content-Digest mismatches.
+//
+// The key ID is put into the request's context (see SignatureKeyIDFromContext).
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keyID, err := v.Verify(r)
+		if err != nil {
+			code := http.StatusUnauthorized
+			if errors.Is(err, ErrDigestMismatch) {
+				code = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sigKeyIDCtxKey{}, keyID)))
+	})
+}
+
+// Verify the signature of the request, returning the key ID.
+//
+// If the request has a Content-Digest, the body is read into memory and checked, too.
+func (v *Verifier) Verify(r *http.Request) (string, error) {
+	inputs, err := ParseDictionary(r.Header.Values("Signature-Input")...)
+	if err != nil {
+		return "", fmt.Errorf("%w: Signature-Input: %w", ErrSignatureInvalid, err)
+	}
+	sigs, err := ParseDictionary(r.Header.Values("Signature")...)
+	if err != nil {
+		return "", fmt.Errorf("%w: Signature: %w", ErrSignatureInvalid, err)
+	}
+	if len(inputs) == 0 || len(sigs) == 0 {
+		return "", ErrSignatureMissing
+	}
+	label := v.Label
+	if label == "" {
+		label = inputs[0].Key
+	}
+	il, ok := inputs.Get(label).(InnerList)
+	if !ok {
+		return "", fmt.Errorf("%w: no Signature-Input for %q", ErrSignatureMissing, label)
+	}
+	sigItem, ok := sigs.Get(label).(Item)
+	if !ok {
+		return "", fmt.Errorf("%w: no Signature for %q", ErrSignatureMissing, label)
+	}
+	sig, ok := sigItem.Value.([]byte)
+	if !ok {
+		return "", fmt.Errorf("%w: Signature %q is not a byte sequence", ErrSignatureInvalid, label)
+	}
+
+	covered := make([]string, 0, len(il.Items))
+	for _, it := range il.Items {
+		c, _ := it.Value.(string)
+		covered = append(covered, c)
+	}
+	required := v.Required
+	if len(required) == 0 {
+		required = []string{"@method", "@target-uri"}
+		if r.ContentLength != 0 && r.Body != nil && r.Body != http.NoBody {
+			required = append(required, "content-digest")
+		}
+	}
+	for _, c := range required {
+		if !slices.Contains(covered, c) {
+			return "", fmt.Errorf("%w: %q is not covered", ErrSignatureInvalid, c)
+		}
+	}
+
+	now := time.Now
+	if v.now != nil {
+		now = v.now
+	}
+	t := now().Unix()
+	if exp, ok := il.Params.Get("expires").(int64); ok && exp < t {
+		return "", fmt.Errorf("%w: expired", ErrSignatureInvalid)
+	}
+	if created, ok := il.Params.Get("created").(int64); ok {
+		if created > t+60 {
+			return "", fmt.Errorf("%w: created in the future", ErrSignatureInvalid)
+		}
+		if v.MaxAge > 0 && created < t-int64(v.MaxAge/time.Second) {
+			return "", fmt.Errorf("%w: too old", ErrSignatureInvalid)
+		}
+	} else if v.MaxAge > 0 {
+		return "", fmt.Errorf("%w: no created parameter", ErrSignatureInvalid)
+	}
+	keyID, _ := il.Params.Get("keyid").(string)
+	key, err := v.Keys.ResolveKey(r.Context(), keyID)
+	if err != nil {
+		return keyID, err
+	}
+	if alg, ok := il.Params.Get("alg").(string); ok {
+		if keyAlg, err := key.alg(); err != nil {
+			return keyID, err
+		} else if alg != keyAlg {
+			return keyID, fmt.Errorf("%w: alg %q does not match the key's %q", ErrSignatureInvalid, alg, keyAlg)
+		}
+	}
+
+	base, _, err := signatureBase(messageFromRequest(r, true), il)
+	if err != nil {
+		return keyID, err
+	}
+	if err = key.verify(base, sig); err != nil {
+		return keyID, err
+	}
+
+	if digest := r.Header.Get("Content-Digest"); digest != "" && r.Body != nil {
+		b, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return keyID, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(b))
+		if err = VerifyContentDigest(digest, b); err != nil {
+			return keyID, err
+		}
+	}
+	return keyID, nil
+}
+
+// ContentDigest returns the RFC 9530 Content-Digest header value (sha-256) of the content.
+func ContentDigest(r io.Reader) (string, error) {
+	hsh := sha256.New()
+	if _, err := io.Copy(hsh, r); err != nil {
+		return "", err
+	}
+	b, err := Dictionary{{Key: "sha-256", Value: Item{Value: hsh.Sum(nil)}}}.MarshalText()
+	return string(b), err
+}
+
+// VerifyContentDigest checks the RFC 9530 Content-Digest header value (sha-256 or sha-512) against the content.
+func VerifyContentDigest(digest string, content []byte) error {
+	d, err := ParseDictionary(digest)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDigestMismatch, err)
+	}
+	var checked bool
+	for _, dm := range d {
+		var hsh hash.Hash
+		switch dm.Key {
+		case "sha-256":
+			hsh = sha256.New()
+		case "sha-512":
+			hsh = sha512.New()
+		default:
+			continue
+		}
+		it, _ := dm.Value.(Item)
+		want, _ := it.Value.([]byte)
+		hsh.Write(content)
+		if !bytes.Equal(hsh.Sum(nil), want) {
+			return fmt.Errorf("%w: %s", ErrDigestMismatch, dm.Key)
+		}
+		checked = true
+	}
+	if !checked {
+		return fmt.Errorf("%w: no supported algorithm in %q", ErrDigestMismatch, digest)
+	}
+	return nil
+}
+
+// sigMessage is the data of the request needed for the signature base.
+type sigMessage struct {
+	Header                 http.Header
+	Method, Scheme, Host   string
+	Path, RawQuery, ReqURI string
+}
+
+func messageFromRequest(r *http.Request, server bool) sigMessage {
+	m := sigMessage{Header: r.Header, Method: r.Method, Host: r.Host, Scheme: r.URL.Scheme}
+	if m.Host == "" {
+		m.Host = r.URL.Host
+	}
+	if server && m.Scheme == "" {
+		if m.Scheme = "http"; r.TLS != nil {
+			m.Scheme = "https"
+		}
+	}
+	if m.Path = r.URL.EscapedPath(); m.Path == "" {
+		m.Path = "/"
+	}
+	m.RawQuery = r.URL.RawQuery
+	m.ReqURI = m.Path
+	if m.RawQuery != "" {
+		m.ReqURI += "?" + m.RawQuery
+	}
+	return m
+}
+
+// authority returns the lowercase host, without the default port.
+func (m sigMessage) authority() string {
+	host := strings.ToLower(m.Host)
+	if h, port, err := net.SplitHostPort(host); err == nil &&
+		(port == "80" && m.Scheme == "http" || port == "443" && m.Scheme == "https") {
+		if strings.Contains(h, ":") {
+			return "[" + h + "]"
+		}
+		return h
+	}
+	return host
+}
+
+func (m sigMessage) component(name string) (string, error) {
+	switch name {
+	case "@method":
+		return strings.ToUpper(m.Method), nil
+	case "@target-uri":
+		return strings.ToLower(m.Scheme) + "://" + m.Host + m.ReqURI, nil
+	case "@authority":
+		return m.authority(), nil
+	case "@scheme":
+		return strings.ToLower(m.Scheme), nil
+	case "@request-target":
+		return m.ReqURI, nil
+	case "@path":
+		return m.Path, nil
+	case "@query":
+		return "?" + m.RawQuery, nil
+	}
+	if strings.HasPrefix(name, "@") {
+		return "", fmt.Errorf("unsupported derived component %q", name)
+	}
+	if name != strings.ToLower(name) {
+		return "", fmt.Errorf("component %q must be lowercase", name)
+	}
+	vv := slices.Clone(m.Header.Values(name))
+	if len(vv) == 0 {
+		return "", fmt.Errorf("%w: header %q is missing", ErrSignatureInvalid, name)
+	}
+	for i, v := range vv {
+		vv[i] = strings.TrimSpace(v)
+	}
+	return strings.Join(vv, ", "), nil
+}
+
+// signatureBase returns the signature base and the serialized signature parameters.
+func signatureBase(m sigMessage, il InnerList) ([]byte, string, error) {
+	var buf bytes.Buffer
+	seen := make(map[string]struct{}, len(il.Items))
+	for _, it := range il.Items {
+		name, ok := it.Value.(string)
+		if !ok {
+			return nil, "", fmt.Errorf("%w: component %v is not a string", ErrSignatureInvalid, it.Value)
+		}
+		if len(it.Params) != 0 {
+			return nil, "", fmt.Errorf("%w: component parameters are not supported (%q)", ErrSignatureInvalid, name)
+		}
+		if _, ok := seen[name]; ok {
+			return nil, "", fmt.Errorf("%w: duplicate component %q", ErrSignatureInvalid, name)
+		}
+		seen[name] = struct{}{}
+		v, err := m.component(name)
+		if err != nil {
+			return nil, "", err
+		}
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, "", fmt.Errorf("%w: %q contains a newline", ErrSignatureInvalid, name)
+		}
+		buf.WriteString(`"` + name + `": ` + v + "\n")
+	}
+	var sb strings.Builder
+	if err := il.marshal(&sb); err != nil {
+		return nil, "", err
+	}
+	sigParams := sb.String()
+	buf.WriteString(`"@signature-params": `)
+	buf.WriteString(sigParams)
+	return buf.Bytes(), sigParams, nil
+}
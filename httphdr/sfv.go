@@ -0,0 +1,709 @@
+// Copyright 2026 Tamás Gulácsi.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httphdr
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrSyntax is returned for unparseable RFC 9651 (formerly RFC 8941) Structured Field Values.
+var ErrSyntax = errors.New("structured field syntax error")
+
+// Token is a structured field Token.
+type Token string
+
+// DisplayString is a structured field Display String (Unicode text).
+type DisplayString string
+
+// Param is a key-value pair of the parameters.
+type Param struct {
+	Value any
+	Key   string
+}
+
+// Params is an ordered map of parameters.
+type Params []Param
+
+// Get the value of the key - nil if not found.
+func (ps Params) Get(key string) any {
+	for _, p := range ps {
+		if p.Key == key {
+			return p.Value
+		}
+	}
+	return nil
+}
+
+// set the key, overwriting the existing value in place.
+func (ps Params) set(key string, value any) Params {
+	for i, p := range ps {
+		if p.Key == key {
+			ps[i].Value = value
+			return ps
+		}
+	}
+	return append(ps, Param{Key: key, Value: value})
+}
+
+// Member is a member of a List or Dictionary: Item or InnerList.
+type Member interface {
+	member()
+}
+
+// Item is a bare item with parameters.
+//
+// The bare item values are
+//   - int64 for Integer,
+//   - float64 for Decimal,
+//   - string for String,
+//   - Token for Token,
+//   - []byte for Byte Sequence,
+//   - bool for Boolean,
+//   - time.Time for Date (whole seconds),
+//   - DisplayString for Display String.
+type Item struct {
+	Value  any
+	Params Params
+}
+
+// InnerList is a list of Items with parameters.
+type InnerList struct {
+	Items  []Item
+	Params Params
+}
+
+func (Item) member()      {}
+func (InnerList) member() {}
+
+// List is a structured field List.
+type List []Member
+
+// DictMember is a key-value pair of the Dictionary.
+type DictMember struct {
+	Value Member
+	Key   string
+}
+
+// Dictionary is a structured field Dictionary, an ordered map.
+type Dictionary []DictMember
+
+// Get the member of the key - nil if not found.
+func (d Dictionary) Get(key string) Member {
+	for _, m := range d {
+		if m.Key == key {
+			return m.Value
+		}
+	}
+	return nil
+}
+
+// ParseItem parses the field lines as an Item.
+func ParseItem(lines ...string) (Item, error) {
+	var it Item
+	err := it.UnmarshalText([]byte(strings.Join(lines, ", ")))
+	return it, err
+}
+
+// ParseList parses the field lines as a List.
+func ParseList(lines ...string) (List, error) {
+	var l List
+	err := l.UnmarshalText([]byte(strings.Join(lines, ", ")))
+	return l, err
+}
+
+// ParseDictionary parses the field lines as a Dictionary.
+func ParseDictionary(lines ...string) (Dictionary, error) {
+	var d Dictionary
+	err := d.UnmarshalText([]byte(strings.Join(lines, ", ")))
+	return d, err
+}
+
+// UnmarshalText parses the Item.
+func (it *Item) UnmarshalText(b []byte) error {
+	p := sfParser{s: string(b)}
+	p.skipSP()
+	var err error
+	if *it, err = p.parseItem(); err != nil {
+		return err
+	}
+	return p.end()
+}
+
+// UnmarshalText parses the List.
+func (l *List) UnmarshalText(b []byte) error {
+	p := sfParser{s: string(b)}
+	p.skipSP()
+	*l = nil
+	for !p.eof() {
+		m, err := p.parseMember()
+		if err != nil {
+			return err
+		}
+		*l = append(*l, m)
+		if done, err := p.nextMember(); done || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalText parses the Dictionary.
+func (d *Dictionary) UnmarshalText(b []byte) error {
+	p := sfParser{s: string(b)}
+	p.skipSP()
+	*d = nil
+	for !p.eof() {
+		key, err := p.parseKey()
+		if err != nil {
+			return err
+		}
+		var m Member
+		if p.peek() == '=' {
+			p.i++
+			if m, err = p.parseMember(); err != nil {
+				return err
+			}
+		} else {
+			it := Item{Value: true}
+			if it.Params, err = p.parseParams(); err != nil {
+				return err
+			}
+			m = it
+		}
+		*d = d.set(key, m)
+		if done, err := p.nextMember(); done || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d Dictionary) set(key string, m Member) Dictionary {
+	for i, dm := range d {
+		if dm.Key == key {
+			d[i].Value = m
+			return d
+		}
+	}
+	return append(d, DictMember{Key: key, Value: m})
+}
+
+type sfParser struct {
+	s string
+	i int
+}
+
+func (p *sfParser) eof() bool { return p.i >= len(p.s) }
+func (p *sfParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.i]
+}
+func (p *sfParser) skipSP() {
+	for !p.eof() && p.s[p.i] == ' ' {
+		p.i++
+	}
+}
+func (p *sfParser) skipOWS() {
+	for !p.eof() && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+func (p *sfParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w at %d of %q: %s", ErrSyntax, p.i, p.s, fmt.Sprintf(format, args...))
+}
+
+// end checks that only spaces remain.
+func (p *sfParser) end() error {
+	p.skipSP()
+	if !p.eof() {
+		return p.errorf("trailing characters")
+	}
+	return nil
+}
+
+// nextMember consumes the separating comma, returns true at the end.
+func (p *sfParser) nextMember() (bool, error) {
+	p.skipOWS()
+	if p.eof() {
+		return true, nil
+	}
+	if p.s[p.i] != ',' {
+		return false, p.errorf("expected comma")
+	}
+	p.i++
+	p.skipOWS()
+	if p.eof() {
+		return false, p.errorf("trailing comma")
+	}
+	return false, nil
+}
+
+func (p *sfParser) parseMember() (Member, error) {
+	if p.peek() == '(' {
+		return p.parseInnerList()
+	}
+	return p.parseItem()
+}
+
+func (p *sfParser) parseInnerList() (InnerList, error) {
+	var il InnerList
+	p.i++ // (
+	for !p.eof() {
+		p.skipSP()
+		if p.peek() == ')' {
+			p.i++
+			var err error
+			il.Params, err = p.parseParams()
+			return il, err
+		}
+		it, err := p.parseItem()
+		if err != nil {
+			return il, err
+		}
+		il.Items = append(il.Items, it)
+		if c := p.peek(); c != ' ' && c != ')' {
+			return il, p.errorf("expected space or ) in inner list")
+		}
+	}
+	return il, p.errorf("unterminated inner list")
+}
+
+func (p *sfParser) parseItem() (Item, error) {
+	var it Item
+	var err error
+	if it.Value, err = p.parseBareItem(); err != nil {
+		return it, err
+	}
+	it.Params, err = p.parseParams()
+	return it, err
+}
+
+func (p *sfParser) parseParams() (Params, error) {
+	var ps Params
+	for p.peek() == ';' {
+		p.i++
+		p.skipSP()
+		key, err := p.parseKey()
+		if err != nil {
+			return ps, err
+		}
+		var value any = true
+		if p.peek() == '=' {
+			p.i++
+			if value, err = p.parseBareItem(); err != nil {
+				return ps, err
+			}
+		}
+		ps = ps.set(key, value)
+	}
+	return ps, nil
+}
+
+func (p *sfParser) parseKey() (string, error) {
+	if c := p.peek(); !isLCAlpha(c) && c != '*' {
+		return "", p.errorf("bad key start %q", c)
+	}
+	start := p.i
+	for !p.eof() && isKeyChar(p.s[p.i]) {
+		p.i++
+	}
+	return p.s[start:p.i], nil
+}
+
+func (p *sfParser) parseBareItem() (any, error) {
+	switch c := p.peek(); {
+	case c == '-' || isDigit(c):
+		return p.parseNumber()
+	case c == '"':
+		return p.parseString()
+	case c == '*' || isAlpha(c):
+		return p.parseToken(), nil
+	case c == ':':
+		return p.parseByteSequence()
+	case c == '?':
+		return p.parseBoolean()
+	case c == '@':
+		return p.parseDate()
+	case c == '%':
+		return p.parseDisplayString()
+	default:
+		return nil, p.errorf("unexpected %q", c)
+	}
+}
+
+func (p *sfParser) parseNumber() (any, error) {
+	start := p.i
+	if p.peek() == '-' {
+		p.i++
+	}
+	if !isDigit(p.peek()) {
+		return nil, p.errorf("expected digit")
+	}
+	digitsStart, dot := p.i, -1
+Loop:
+	for ; !p.eof(); p.i++ {
+		switch c := p.s[p.i]; {
+		case isDigit(c):
+		case dot < 0 && c == '.':
+			if p.i-digitsStart > 12 {
+				return nil, p.errorf("too many integer digits")
+			}
+			dot = p.i
+		default:
+			break Loop
+		}
+		if dot < 0 && p.i-digitsStart >= 15 || dot >= 0 && p.i-digitsStart >= 16 {
+			return nil, p.errorf("number too long")
+		}
+	}
+	s := p.s[start:p.i]
+	if dot < 0 {
+		return strconv.ParseInt(s, 10, 64)
+	}
+	if n := p.i - dot - 1; n == 0 || n > 3 {
+		return nil, p.errorf("decimal must have 1-3 fractional digits")
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func (p *sfParser) parseString() (string, error) {
+	var buf strings.Builder
+	p.i++ // "
+	for !p.eof() {
+		c := p.s[p.i]
+		p.i++
+		switch {
+		case c == '\\':
+			if p.eof() {
+				return "", p.errorf("unterminated escape")
+			}
+			if c = p.s[p.i]; c != '"' && c != '\\' {
+				return "", p.errorf("bad escape %q", c)
+			}
+			p.i++
+			buf.WriteByte(c)
+		case c == '"':
+			return buf.String(), nil
+		case c < 0x20 || c > 0x7e:
+			return "", p.errorf("bad character %q in string", c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *sfParser) parseToken() Token {
+	start := p.i
+	for p.i++; !p.eof() && (isTChar(p.s[p.i]) || p.s[p.i] == ':' || p.s[p.i] == '/'); p.i++ {
+	}
+	return Token(p.s[start:p.i])
+}
+
+func (p *sfParser) parseByteSequence() ([]byte, error) {
+	p.i++ // :
+	j := strings.IndexByte(p.s[p.i:], ':')
+	if j < 0 {
+		return nil, p.errorf("unterminated byte sequence")
+	}
+	s := p.s[p.i : p.i+j]
+	for i := range len(s) {
+		if c := s[i]; !isAlpha(c) && !isDigit(c) && c != '+' && c != '/' && c != '=' {
+			return nil, p.errorf("bad character %q in byte sequence", c)
+		}
+	}
+	p.i += j + 1
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		// be lenient with the padding
+		if b, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "=")); err != nil {
+			return nil, p.errorf("byte sequence: %v", err)
+		}
+	}
+	return b, nil
+}
+
+func (p *sfParser) parseBoolean() (bool, error) {
+	p.i++ // ?
+	switch p.peek() {
+	case '1':
+		p.i++
+		return true, nil
+	case '0':
+		p.i++
+		return false, nil
+	default:
+		return false, p.errorf("bad boolean")
+	}
+}
+
+func (p *sfParser) parseDate() (time.Time, error) {
+	p.i++ // @
+	v, err := p.parseNumber()
+	if err != nil {
+		return time.Time{}, err
+	}
+	n, ok := v.(int64)
+	if !ok {
+		return time.Time{}, p.errorf("date must be an integer")
+	}
+	return time.Unix(n, 0).UTC(), nil
+}
+
+func (p *sfParser) parseDisplayString() (DisplayString, error) {
+	p.i++ // %
+	if p.peek() != '"' {
+		return "", p.errorf("expected '\"' after '%%'")
+	}
+	p.i++
+	var buf []byte
+	for !p.eof() {
+		c := p.s[p.i]
+		p.i++
+		switch {
+		case c == '%':
+			if p.i+2 > len(p.s) || !isLCHex(p.s[p.i]) || !isLCHex(p.s[p.i+1]) {
+				return "", p.errorf("bad percent encoding in display string")
+			}
+			b, _ := strconv.ParseUint(p.s[p.i:p.i+2], 16, 8)
+			buf = append(buf, byte(b))
+			p.i += 2
+		case c == '"':
+			if !utf8.Valid(buf) {
+				return "", p.errorf("display string is not valid UTF-8")
+			}
+			return DisplayString(buf), nil
+		case c < 0x20 || c > 0x7e:
+			return "", p.errorf("bad character %q in display string", c)
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return "", p.errorf("unterminated display string")
+}
+
+// MarshalText serializes the Item.
+func (it Item) MarshalText() ([]byte, error) {
+	var buf strings.Builder
+	err := it.marshal(&buf)
+	return []byte(buf.String()), err
+}
+
+// MarshalText serializes the List.
+func (l List) MarshalText() ([]byte, error) {
+	var buf strings.Builder
+	for i, m := range l {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		if err := marshalMember(&buf, m); err != nil {
+			return nil, err
+		}
+	}
+	return []byte(buf.String()), nil
+}
+
+// MarshalText serializes the Dictionary.
+func (d Dictionary) MarshalText() ([]byte, error) {
+	var buf strings.Builder
+	for i, dm := range d {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		if err := marshalKey(&buf, dm.Key); err != nil {
+			return nil, err
+		}
+		if it, ok := dm.Value.(Item); ok && it.Value == true {
+			if err := it.Params.marshal(&buf); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		buf.WriteByte('=')
+		if err := marshalMember(&buf, dm.Value); err != nil {
+			return nil, err
+		}
+	}
+	return []byte(buf.String()), nil
+}
+
+// String returns the serialized form, or the error.
+func (it Item) String() string { return sfString(it.MarshalText()) }
+
+// String returns the serialized form, or the error.
+func (l List) String() string { return sfString(l.MarshalText()) }
+
+// String returns the serialized form, or the error.
+func (d Dictionary) String() string { return sfString(d.MarshalText()) }
+
+func sfString(b []byte, err error) string {
+	if err != nil {
+		return "!" + err.Error()
+	}
+	return string(b)
+}
+
+func marshalMember(buf *strings.Builder, m Member) error {
+	switch x := m.(type) {
+	case Item:
+		return x.marshal(buf)
+	case InnerList:
+		return x.marshal(buf)
+	default:
+		return fmt.Errorf("unknown member type %T", m)
+	}
+}
+
+func (il InnerList) marshal(buf *strings.Builder) error {
+	buf.WriteByte('(')
+	for i, it := range il.Items {
+		if i != 0 {
+			buf.WriteByte(' ')
+		}
+		if err := it.marshal(buf); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(')')
+	return il.Params.marshal(buf)
+}
+
+func (it Item) marshal(buf *strings.Builder) error {
+	if err := marshalBareItem(buf, it.Value); err != nil {
+		return err
+	}
+	return it.Params.marshal(buf)
+}
+
+func (ps Params) marshal(buf *strings.Builder) error {
+	for _, p := range ps {
+		buf.WriteByte(';')
+		if err := marshalKey(buf, p.Key); err != nil {
+			return err
+		}
+		if p.Value == true {
+			continue
+		}
+		buf.WriteByte('=')
+		if err := marshalBareItem(buf, p.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func marshalKey(buf *strings.Builder, key string) error {
+	if key == "" || !isLCAlpha(key[0]) && key[0] != '*' {
+		return fmt.Errorf("bad key %q", key)
+	}
+	for i := range len(key) {
+		if !isKeyChar(key[i]) {
+			return fmt.Errorf("bad key %q", key)
+		}
+	}
+	buf.WriteString(key)
+	return nil
+}
+
+const maxSFInteger = 999_999_999_999_999
+
+func marshalBareItem(buf *strings.Builder, v any) error {
+	switch x := v.(type) {
+	case int:
+		return marshalBareItem(buf, int64(x))
+	case int64:
+		if x < -maxSFInteger || x > maxSFInteger {
+			return fmt.Errorf("integer %d out of range", x)
+		}
+		buf.WriteString(strconv.FormatInt(x, 10))
+	case float64:
+		f := math.RoundToEven(x*1000) / 1000
+		if math.IsNaN(f) || math.Abs(f) >= 1e12 {
+			return fmt.Errorf("decimal %v out of range", x)
+		}
+		s := strconv.FormatFloat(f, 'f', -1, 64)
+		buf.WriteString(s)
+		if !strings.Contains(s, ".") {
+			buf.WriteString(".0")
+		}
+	case string:
+		buf.WriteByte('"')
+		for i := range len(x) {
+			c := x[i]
+			if c < 0x20 || c > 0x7e {
+				return fmt.Errorf("bad character %q in string", c)
+			}
+			if c == '"' || c == '\\' {
+				buf.WriteByte('\\')
+			}
+			buf.WriteByte(c)
+		}
+		buf.WriteByte('"')
+	case Token:
+		if x == "" || !isAlpha(x[0]) && x[0] != '*' {
+			return fmt.Errorf("bad token %q", x)
+		}
+		for i := 1; i < len(x); i++ {
+			if c := x[i]; !isTChar(c) && c != ':' && c != '/' {
+				return fmt.Errorf("bad token %q", x)
+			}
+		}
+		buf.WriteString(string(x))
+	case []byte:
+		buf.WriteByte(':')
+		buf.WriteString(base64.StdEncoding.EncodeToString(x))
+		buf.WriteByte(':')
+	case bool:
+		if x {
+			buf.WriteString("?1")
+		} else {
+			buf.WriteString("?0")
+		}
+	case time.Time:
+		n := x.Unix()
+		if n < -maxSFInteger || n > maxSFInteger {
+			return fmt.Errorf("date %v out of range", x)
+		}
+		buf.WriteByte('@')
+		buf.WriteString(strconv.FormatInt(n, 10))
+	case DisplayString:
+		if !utf8.ValidString(string(x)) {
+			return fmt.Errorf("display string %q is not valid UTF-8", x)
+		}
+		const hex = "0123456789abcdef"
+		buf.WriteString(`%"`)
+		for i := range len(x) {
+			if c := x[i]; c == '%' || c == '"' || c < 0x20 || c > 0x7e {
+				buf.Write([]byte{'%', hex[c>>4], hex[c&0xf]})
+			} else {
+				buf.WriteByte(c)
+			}
+		}
+		buf.WriteByte('"')
+	default:
+		return fmt.Errorf("unknown bare item type %T", v)
+	}
+	return nil
+}
+
+func isDigit(c byte) bool   { return '0' <= c && c <= '9' }
+func isLCAlpha(c byte) bool { return 'a' <= c && c <= 'z' }
+func isLCHex(c byte) bool   { return isDigit(c) || 'a' <= c && c <= 'f' }
+func isAlpha(c byte) bool   { return isLCAlpha(c) || 'A' <= c && c <= 'Z' }
+func isKeyChar(c byte) bool {
+	return isLCAlpha(c) || isDigit(c) || c == '_' || c == '-' || c == '.' || c == '*'
+}
+func isTChar(c byte) bool {
+	return isAlpha(c) || isDigit(c) || strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
@@ -0,0 +1,324 @@
+// Copyright 2026 Tamás Gulácsi.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httphdr
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sfTest struct {
+	Expected   any      `json:"expected"`
+	Name       string   `json:"name"`
+	HeaderType string   `json:"header_type"`
+	Raw        []string `json:"raw"`
+	Canonical  []string `json:"canonical"`
+	MustFail   bool     `json:"must_fail"`
+	CanFail    bool     `json:"can_fail"`
+}
+
+func TestStructuredFields(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "structured-field-tests", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no test files")
+	}
+	for _, fn := range files {
+		b, err := os.ReadFile(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tests []sfTest
+		if err = json.Unmarshal(b, &tests); err != nil {
+			t.Fatalf("%s: %+v", fn, err)
+		}
+		t.Run(strings.TrimSuffix(filepath.Base(fn), ".json"), func(t *testing.T) {
+			for _, tC := range tests {
+				t.Run(tC.Name, func(t *testing.T) {
+					var v interface {
+						MarshalText() ([]byte, error)
+					}
+					var got any
+					switch tC.HeaderType {
+					case "item":
+						it, e := ParseItem(tC.Raw...)
+						v, got, err = it, sfItemJSON(it), e
+					case "list":
+						l, e := ParseList(tC.Raw...)
+						v, got, err = l, sfListJSON(l), e
+					case "dictionary":
+						d, e := ParseDictionary(tC.Raw...)
+						v, got, err = d, sfDictJSON(d), e
+					default:
+						t.Skip(tC.HeaderType)
+					}
+					if tC.MustFail {
+						if err == nil {
+							t.Fatalf("%q: parsed as %#v, wanted failure", tC.Raw, v)
+						}
+						return
+					}
+					if err != nil {
+						if tC.CanFail {
+							return
+						}
+						t.Fatalf("%q: %+v", tC.Raw, err)
+					}
+					if !reflect.DeepEqual(got, tC.Expected) {
+						t.Errorf("%q:\ngot  %#v,\nwanted %#v", tC.Raw, got, tC.Expected)
+					}
+					want := strings.Join(tC.Raw, ", ")
+					if tC.Canonical != nil {
+						want = strings.Join(tC.Canonical, ", ")
+					}
+					if b, err := v.MarshalText(); err != nil {
+						t.Errorf("serialize %#v: %+v", v, err)
+					} else if string(b) != want {
+						t.Errorf("serialized to %q, wanted %q", string(b), want)
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestStructuredFieldsSerialisation runs the serialisation-tests of the structured-field-tests.
+func TestStructuredFieldsSerialisation(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "structured-field-tests", "serialisation-tests", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no test files")
+	}
+	for _, fn := range files {
+		b, err := os.ReadFile(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber() // to distinguish Integers from Decimals
+		var tests []sfTest
+		if err = dec.Decode(&tests); err != nil {
+			t.Fatalf("%s: %+v", fn, err)
+		}
+		t.Run(strings.TrimSuffix(filepath.Base(fn), ".json"), func(t *testing.T) {
+			for _, tC := range tests {
+				t.Run(tC.Name, func(t *testing.T) {
+					var b []byte
+					var err error
+					switch tC.HeaderType {
+					case "item":
+						b, err = sfItemFromJSON(tC.Expected).MarshalText()
+					case "list":
+						b, err = sfListFromJSON(tC.Expected).MarshalText()
+					case "dictionary":
+						b, err = sfDictFromJSON(tC.Expected).MarshalText()
+					default:
+						t.Fatalf("unknown header type %q", tC.HeaderType)
+					}
+					if tC.MustFail {
+						if err == nil {
+							t.Fatalf("%#v: serialized to %q, wanted failure", tC.Expected, b)
+						}
+						return
+					}
+					if err != nil {
+						t.Fatalf("%#v: %+v", tC.Expected, err)
+					}
+					if want := strings.Join(tC.Canonical, ", "); string(b) != want {
+						t.Errorf("serialized to %q, wanted %q", string(b), want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestStructuredFieldsSerialize(t *testing.T) {
+	for _, tC := range []struct {
+		Item Item
+		Want string
+		Fail bool
+	}{
+		{Item: Item{Value: 1.0005}, Want: "1.0"},
+		{Item: Item{Value: 1.0015}, Want: "1.002"},
+		{Item: Item{Value: -0.5, Params: Params{{Key: "a", Value: true}, {Key: "b", Value: false}}}, Want: "-0.5;a;b=?0"},
+		{Item: Item{Value: 3}, Want: "3"},
+		{Item: Item{Value: int64(1e15)}, Fail: true},
+		{Item: Item{Value: 1e12}, Fail: true},
+		{Item: Item{Value: "füü"}, Fail: true},
+		{Item: Item{Value: Token("1a")}, Fail: true},
+		{Item: Item{Value: Token("a"), Params: Params{{Key: "A", Value: 1}}}, Fail: true},
+		{Item: Item{Value: 1i}, Fail: true},
+	} {
+		b, err := tC.Item.MarshalText()
+		if tC.Fail {
+			if err == nil {
+				t.Errorf("%#v: got %q, wanted failure", tC.Item, b)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%#v: %+v", tC.Item, err)
+		} else if string(b) != tC.Want {
+			t.Errorf("%#v: got %q, wanted %q", tC.Item, b, tC.Want)
+		}
+	}
+
+	d, err := ParseDictionary(`u=2, i`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if it, ok := d.Get("u").(Item); !ok || it.Value != int64(2) {
+		t.Errorf("got %#v for u", d.Get("u"))
+	}
+	if d.Get("x") != nil {
+		t.Errorf("got %#v for x", d.Get("x"))
+	}
+}
+
+// sf*JSON convert to the JSON representation of the structured-field-tests.
+
+func sfBareJSON(v any) any {
+	switch x := v.(type) {
+	case int64:
+		return float64(x)
+	case Token:
+		return map[string]any{"__type": "token", "value": string(x)}
+	case []byte:
+		return map[string]any{"__type": "binary", "value": base32.StdEncoding.EncodeToString(x)}
+	case time.Time:
+		return map[string]any{"__type": "date", "value": float64(x.Unix())}
+	case DisplayString:
+		return map[string]any{"__type": "displaystring", "value": string(x)}
+	}
+	return v
+}
+
+func sfParamsJSON(ps Params) []any {
+	a := make([]any, 0, len(ps))
+	for _, p := range ps {
+		a = append(a, []any{p.Key, sfBareJSON(p.Value)})
+	}
+	return a
+}
+
+func sfItemJSON(it Item) any { return []any{sfBareJSON(it.Value), sfParamsJSON(it.Params)} }
+
+func sfMemberJSON(m Member) any {
+	switch x := m.(type) {
+	case Item:
+		return sfItemJSON(x)
+	case InnerList:
+		items := make([]any, 0, len(x.Items))
+		for _, it := range x.Items {
+			items = append(items, sfItemJSON(it))
+		}
+		return []any{items, sfParamsJSON(x.Params)}
+	}
+	return nil
+}
+
+func sfListJSON(l List) any {
+	a := make([]any, 0, len(l))
+	for _, m := range l {
+		a = append(a, sfMemberJSON(m))
+	}
+	return a
+}
+
+func sfDictJSON(d Dictionary) any {
+	a := make([]any, 0, len(d))
+	for _, dm := range d {
+		a = append(a, []any{dm.Key, sfMemberJSON(dm.Value)})
+	}
+	return a
+}
+
+// sf*FromJSON convert from the JSON representation of the structured-field-tests,
+// decoded with UseNumber.
+
+func sfBareFromJSON(v any) any {
+	switch x := v.(type) {
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return i
+		}
+		f, _ := x.Float64()
+		return f
+	case map[string]any:
+		s, _ := x["value"].(string)
+		switch x["__type"] {
+		case "token":
+			return Token(s)
+		case "binary":
+			b, _ := base32.StdEncoding.DecodeString(s)
+			return b
+		case "displaystring":
+			return DisplayString(s)
+		case "date":
+			n, _ := x["value"].(json.Number).Int64()
+			return time.Unix(n, 0)
+		}
+	}
+	return v
+}
+
+func sfParamsFromJSON(v any) Params {
+	a, _ := v.([]any)
+	ps := make(Params, 0, len(a))
+	for _, kv := range a {
+		kv := kv.([]any)
+		ps = append(ps, Param{Key: kv[0].(string), Value: sfBareFromJSON(kv[1])})
+	}
+	return ps
+}
+
+func sfItemFromJSON(v any) Item {
+	a := v.([]any)
+	return Item{Value: sfBareFromJSON(a[0]), Params: sfParamsFromJSON(a[1])}
+}
+
+func sfMemberFromJSON(v any) Member {
+	a := v.([]any)
+	items, ok := a[0].([]any)
+	if !ok {
+		return sfItemFromJSON(v)
+	}
+	il := InnerList{Params: sfParamsFromJSON(a[1])}
+	for _, it := range items {
+		il.Items = append(il.Items, sfItemFromJSON(it))
+	}
+	return il
+}
+
+func sfListFromJSON(v any) List {
+	a, _ := v.([]any)
+	l := make(List, 0, len(a))
+	for _, m := range a {
+		l = append(l, sfMemberFromJSON(m))
+	}
+	return l
+}
+
+func sfDictFromJSON(v any) Dictionary {
+	a, _ := v.([]any)
+	d := make(Dictionary, 0, len(a))
+	for _, kv := range a {
+		kv := kv.([]any)
+		d = append(d, DictMember{Key: kv[0].(string), Value: sfMemberFromJSON(kv[1])})
+	}
+	return d
+}
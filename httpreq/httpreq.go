@@ -117,7 +117,9 @@ func ReadRequestFiles(r *http.Request) (filenames []string, status int, err erro
 	return
 }
 
-// SendFile sends the given file as response
+// SendFile sends the given file as response.
+//
+// See SendContent for Range and conditional request support.
 func SendFile(w http.ResponseWriter, filename, contentType string) error {
 	fh, err := os.Open(filename)
 	if err != nil {
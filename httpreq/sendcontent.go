@@ -0,0 +1,92 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httpreq
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tgulacsi/go/httphdr"
+)
+
+// Content to be sent by SendContent.
+type Content struct {
+	// ReaderAt is the source of the content.
+	ReaderAt io.ReaderAt
+	// ModTime is the modification time, used for Last-Modified and If-(Un)Modified-Since.
+	ModTime time.Time
+	// Name is the file name, used for Content-Disposition,
+	// and for guessing the Content-Type if it is empty.
+	Name string
+	// ContentType is the Content-Type - sniffed from the name's extension or the content if empty.
+	ContentType string
+	// ETag is the entity tag - quoted if not already.
+	ETag string
+	// Disposition is the Content-Disposition type ("attachment" or "inline"),
+	// the header is not set if empty.
+	Disposition string
+	// Size of the content. If zero, and ReaderAt has a Size() int64 method, that is used.
+	// Otherwise SendContent returns an error if the content is not empty.
+	Size int64
+}
+
+// NewFileContent returns the Content of the opened file, with a weak ETag
+// calculated from the modification time and the size.
+func NewFileContent(fh *os.File) (Content, error) {
+	fi, err := fh.Stat()
+	if err != nil {
+		return Content{}, err
+	}
+	if !fi.Mode().IsRegular() {
+		return Content{}, fmt.Errorf("%s: not a regular file", fh.Name())
+	}
+	return Content{
+		ReaderAt: fh, Size: fi.Size(), ModTime: fi.ModTime(),
+		Name: filepath.Base(fh.Name()),
+		ETag: fmt.Sprintf(`W/"%x-%x"`, fi.ModTime().UnixNano(), fi.Size()),
+	}, nil
+}
+
+// SendContent sends the content as response, honoring the Range (single and multipart/byteranges),
+// If-Range, If-Match, If-None-Match, If-Modified-Since and If-Unmodified-Since request headers.
+//
+// See http.ServeContent.
+func SendContent(w http.ResponseWriter, r *http.Request, c Content) error {
+	if c.ReaderAt == nil {
+		return errors.New("nil ReaderAt")
+	}
+	if c.Size == 0 {
+		if sr, ok := c.ReaderAt.(interface{ Size() int64 }); ok {
+			c.Size = sr.Size()
+		} else if n, err := c.ReaderAt.ReadAt(make([]byte, 1), 0); n != 0 || err != io.EOF {
+			return errors.New("unknown size: Content.Size must be set")
+		}
+	}
+	if c.Size < 0 {
+		return fmt.Errorf("negative size %d", c.Size)
+	}
+	hdr := w.Header()
+	if c.ETag != "" {
+		if !strings.HasSuffix(c.ETag, `"`) {
+			c.ETag = `"` + c.ETag + `"`
+		}
+		hdr.Set("ETag", c.ETag)
+	}
+	if c.ContentType != "" {
+		hdr.Set("Content-Type", c.ContentType)
+	}
+	if c.Disposition != "" && c.Name != "" {
+		hdr.Set("Content-Disposition", httphdr.ContentDisposition(c.Disposition, c.Name))
+	}
+	logger.Info("SendContent", "name", c.Name, "length", c.Size, "range", r.Header.Get("Range"))
+	http.ServeContent(w, r, c.Name, c.ModTime, io.NewSectionReader(c.ReaderAt, 0, c.Size))
+	return nil
+}
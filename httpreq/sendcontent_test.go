@@ -0,0 +1,92 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httpreq
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendContent(t *testing.T) {
+	data := strings.Repeat("0123456789", 100)
+	modTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := Content{
+		ReaderAt: strings.NewReader(data), ModTime: modTime,
+		Name: "számok.txt", ETag: "abc", Disposition: "attachment",
+	}
+	for _, tC := range []struct {
+		Name   string
+		Header map[string]string
+		Body   string
+		Type   string
+		Code   int
+	}{
+		{Name: "full", Code: 200, Body: data},
+		{Name: "range", Header: map[string]string{"Range": "bytes=10-19"}, Code: 206, Body: "0123456789"},
+		{Name: "suffix", Header: map[string]string{"Range": "bytes=-5"}, Code: 206, Body: "56789"},
+		{Name: "multi", Header: map[string]string{"Range": "bytes=0-1,5-6"}, Code: 206, Type: "multipart/byteranges"},
+		{Name: "unsatisfiable", Header: map[string]string{"Range": "bytes=2000-"}, Code: 416},
+		{Name: "if-none-match", Header: map[string]string{"If-None-Match": `"abc"`}, Code: 304},
+		{Name: "if-modified-since", Header: map[string]string{"If-Modified-Since": modTime.Format(http.TimeFormat)}, Code: 304},
+		{Name: "if-match", Header: map[string]string{"If-Match": `"xyz"`}, Code: 412},
+		{Name: "if-range", Header: map[string]string{"Range": "bytes=0-1", "If-Range": `"abc"`}, Code: 206, Body: "01"},
+		{Name: "if-range-mismatch", Header: map[string]string{"Range": "bytes=0-1", "If-Range": `"xyz"`}, Code: 200, Body: data},
+	} {
+		t.Run(tC.Name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			for k, v := range tC.Header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			if err := SendContent(w, r, c); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tC.Code {
+				t.Fatalf("got %d, wanted %d", w.Code, tC.Code)
+			}
+			if tC.Body != "" && w.Body.String() != tC.Body {
+				t.Errorf("got %q, wanted %q", w.Body.String(), tC.Body)
+			}
+			if tC.Type != "" && !strings.HasPrefix(w.Header().Get("Content-Type"), tC.Type) {
+				t.Errorf("got Content-Type %q, wanted %q", w.Header().Get("Content-Type"), tC.Type)
+			}
+			if tC.Code < 300 {
+				if got := w.Header().Get("ETag"); got != `"abc"` {
+					t.Errorf("got ETag %q", got)
+				}
+				if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, "filename*=UTF-8''sz%C3%A1mok.txt") {
+					t.Errorf("got Content-Disposition %q", got)
+				}
+			}
+		})
+	}
+}
+
+func TestSendContentSize(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	// no Size() method
+	ra := io.NewSectionReader(strings.NewReader("data"), 0, 4)
+	if err := SendContent(httptest.NewRecorder(), r, Content{ReaderAt: onlyReaderAt{ra}}); err == nil {
+		t.Error("unknown size: wanted error")
+	}
+	w := httptest.NewRecorder()
+	if err := SendContent(w, r, Content{ReaderAt: onlyReaderAt{ra}, Size: 4}); err != nil {
+		t.Fatal(err)
+	} else if w.Body.String() != "data" {
+		t.Errorf("got %q", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	if err := SendContent(w, r, Content{ReaderAt: onlyReaderAt{strings.NewReader("")}}); err != nil {
+		t.Errorf("empty: %+v", err)
+	} else if w.Code != 200 || w.Body.Len() != 0 {
+		t.Errorf("empty: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+type onlyReaderAt struct{ io.ReaderAt }
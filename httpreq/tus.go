@@ -0,0 +1,436 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httpreq
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tgulacsi/go/temp"
+)
+
+const (
+	// TusVersion is the supported tus protocol version.
+	TusVersion = "1.0.0"
+	// TusExtensions are the supported tus protocol extensions.
+	TusExtensions = "creation,termination,expiration"
+
+	// DefaultTusExpiry is the default time after an abandoned upload expires.
+	DefaultTusExpiry = 24 * time.Hour
+
+	tusContentType = "application/offset+octet-stream"
+)
+
+// ErrUploadNotFound is returned by the TusStore for unknown uploads.
+var ErrUploadNotFound = errors.New("upload not found")
+
+// TusUpload is the state of a resumable upload.
+type TusUpload struct {
+	Created time.Time
+	// Expires is the time the upload expires if not finished - each PATCH extends it.
+	Expires time.Time
+	// Metadata is the decoded Upload-Metadata.
+	Metadata map[string]string
+	ID       string
+	// Length is the total size of the upload.
+	Length int64
+	// Offset is the number of bytes received.
+	Offset int64
+	// Completed is set when OnComplete has finished successfully.
+	Completed bool
+}
+
+// Done reports whether all the data has been received.
+func (u TusUpload) Done() bool { return u.Offset >= u.Length }
+
+// TusStore stores the state and data of the uploads.
+type TusStore interface {
+	// Create a new upload, with room for Length bytes.
+	Create(ctx context.Context, u TusUpload) error
+	// Get the state of the upload - the error must wrap ErrUploadNotFound for unknown uploads.
+	Get(ctx context.Context, id string) (TusUpload, error)
+	// Save the state of the upload.
+	Save(ctx context.Context, u TusUpload) error
+	// WriteAt writes the data read from r at the offset, returning the number of bytes written,
+	// even on error.
+	WriteAt(ctx context.Context, id string, offset int64, r io.Reader) (int64, error)
+	// Open the data of the upload.
+	Open(ctx context.Context, id string) (temp.ReadSeekCloser, error)
+	// Delete the upload.
+	Delete(ctx context.Context, id string) error
+	// List all the uploads.
+	List(ctx context.Context) ([]TusUpload, error)
+}
+
+// TusHandler is an http.Handler for resumable uploads,
+// implementing the tus 1.0 core protocol with the creation, termination and expiration extensions
+// (https://tus.io/protocols/resumable-upload).
+//
+// POST creates a new upload under the request's path, HEAD, PATCH and DELETE
+// act on the upload identified by the last path element.
+type TusHandler struct {
+	// Store for the uploads, defaults to DirTusStore in the temp dir.
+	Store TusStore
+	// OnComplete is called with the finished upload's data.
+	// The data is removed when the upload expires, so it must be copied or linked (see temp.LinkOrCopy)
+	// to be kept.
+	OnComplete func(context.Context, TusUpload, temp.ReadSeekCloser) error
+	now        func() time.Time
+	locks      sync.Map
+	// MaxSize is the maximum size of an upload (0: unlimited).
+	MaxSize int64
+	// Expiry is the time after an abandoned upload expires (DefaultTusExpiry if 0).
+	Expiry time.Duration
+	once   sync.Once
+}
+
+func (h *TusHandler) init() {
+	h.once.Do(func() {
+		if h.Store == nil {
+			h.Store = DirTusStore(filepath.Join(os.TempDir(), "tus"))
+		}
+		if h.Expiry == 0 {
+			h.Expiry = DefaultTusExpiry
+		}
+		if h.now == nil {
+			h.now = time.Now
+		}
+	})
+}
+
+func (h *TusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.init()
+	hdr := w.Header()
+	hdr.Set("Tus-Resumable", TusVersion)
+	method := r.Method
+	if m := r.Header.Get("X-HTTP-Method-Override"); m != "" {
+		method = strings.ToUpper(m)
+	}
+	if method == http.MethodOptions {
+		hdr.Set("Tus-Version", TusVersion)
+		hdr.Set("Tus-Extension", TusExtensions)
+		if h.MaxSize > 0 {
+			hdr.Set("Tus-Max-Size", strconv.FormatInt(h.MaxSize, 10))
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if v := r.Header.Get("Tus-Resumable"); v != TusVersion {
+		hdr.Set("Tus-Version", TusVersion)
+		http.Error(w, fmt.Sprintf("unsupported Tus-Resumable %q", v), http.StatusPreconditionFailed)
+		return
+	}
+	ctx := r.Context()
+	if method == http.MethodPost {
+		h.create(w, r)
+		return
+	}
+
+	id := path.Base(r.URL.Path)
+	if !validTusID(id) {
+		http.Error(w, "bad upload id", http.StatusNotFound)
+		return
+	}
+	var mu any
+	if method == http.MethodPatch || method == http.MethodDelete {
+		mu, _ = h.locks.LoadOrStore(id, new(sync.Mutex))
+		if !mu.(*sync.Mutex).TryLock() {
+			http.Error(w, "upload is locked", http.StatusLocked)
+			return
+		}
+		defer mu.(*sync.Mutex).Unlock()
+	}
+	u, err := h.Store.Get(ctx, id)
+	if err == nil && !u.Done() && h.now().After(u.Expires) {
+		err = fmt.Errorf("%w: expired", ErrUploadNotFound)
+	}
+	if err != nil {
+		if errors.Is(err, ErrUploadNotFound) {
+			// do not keep a lock for every id a client makes up
+			if mu != nil {
+				h.locks.CompareAndDelete(id, mu)
+			}
+			http.Error(w, ErrUploadNotFound.Error(), http.StatusNotFound)
+		} else {
+			logger.Error(err, "tus get", "id", id)
+			tusInternalError(w)
+		}
+		return
+	}
+
+	switch method {
+	case http.MethodHead:
+		hdr.Set("Cache-Control", "no-store")
+		hdr.Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+		hdr.Set("Upload-Length", strconv.FormatInt(u.Length, 10))
+		if len(u.Metadata) != 0 {
+			hdr.Set("Upload-Metadata", encodeTusMetadata(u.Metadata))
+		}
+		if !u.Done() {
+			hdr.Set("Upload-Expires", u.Expires.UTC().Format(http.TimeFormat))
+		}
+		w.WriteHeader(http.StatusOK)
+
+	case http.MethodPatch:
+		h.patch(w, r, u)
+
+	case http.MethodDelete:
+		if err := h.Store.Delete(ctx, id); err != nil {
+			logger.Error(err, "tus delete", "id", id)
+			tusInternalError(w)
+			return
+		}
+		h.locks.Delete(id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		hdr.Set("Allow", "OPTIONS, POST, HEAD, PATCH, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *TusHandler) create(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Upload-Defer-Length") != "" {
+		http.Error(w, "Upload-Defer-Length is not supported", http.StatusBadRequest)
+		return
+	}
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		http.Error(w, fmt.Sprintf("bad Upload-Length %q", r.Header.Get("Upload-Length")), http.StatusBadRequest)
+		return
+	}
+	if h.MaxSize > 0 && length > h.MaxSize {
+		http.Error(w, fmt.Sprintf("Upload-Length %d is bigger than the maximum %d", length, h.MaxSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+	md, err := decodeTusMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := h.now()
+	u := TusUpload{
+		ID: strings.ToLower(rand.Text()), Length: length, Metadata: md,
+		Created: now, Expires: now.Add(h.Expiry),
+	}
+	ctx := r.Context()
+	if err = h.Store.Create(ctx, u); err != nil {
+		logger.Error(err, "tus create", "length", length)
+		tusInternalError(w)
+		return
+	}
+	logger.Info("tus create", "id", u.ID, "length", length, "metadata", md)
+	hdr := w.Header()
+	hdr.Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+u.ID)
+	hdr.Set("Upload-Expires", u.Expires.UTC().Format(http.TimeFormat))
+	if u.Done() {
+		if err = h.complete(ctx, &u); err != nil {
+			tusInternalError(w)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *TusHandler) patch(w http.ResponseWriter, r *http.Request, u TusUpload) {
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != tusContentType {
+		http.Error(w, "Content-Type must be "+tusContentType, http.StatusUnsupportedMediaType)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, fmt.Sprintf("bad Upload-Offset %q", r.Header.Get("Upload-Offset")), http.StatusBadRequest)
+		return
+	}
+	if offset != u.Offset {
+		http.Error(w, fmt.Sprintf("Upload-Offset %d does not match the current offset %d", offset, u.Offset), http.StatusConflict)
+		return
+	}
+	remaining := u.Length - u.Offset
+	if r.ContentLength > remaining {
+		http.Error(w, fmt.Sprintf("Content-Length %d exceeds the remaining %d bytes", r.ContentLength, remaining), http.StatusRequestEntityTooLarge)
+		return
+	}
+	ctx := r.Context()
+	n, writeErr := h.Store.WriteAt(ctx, u.ID, u.Offset, io.LimitReader(r.Body, remaining))
+	u.Offset += n
+	u.Expires = h.now().Add(h.Expiry)
+	if err := h.Store.Save(ctx, u); err != nil {
+		logger.Error(err, "tus save", "id", u.ID)
+		tusInternalError(w)
+		return
+	}
+	if writeErr != nil {
+		logger.Error(writeErr, "tus write", "id", u.ID, "offset", u.Offset)
+		tusInternalError(w)
+		return
+	}
+	hdr := w.Header()
+	hdr.Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	if u.Done() {
+		if err := h.complete(ctx, &u); err != nil {
+			tusInternalError(w)
+			return
+		}
+	} else {
+		hdr.Set("Upload-Expires", u.Expires.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// complete calls OnComplete for the finished upload, and records its success,
+// so a repeated PATCH on a finished upload does not call it again.
+//
+// A failed OnComplete is retried on the next (empty) PATCH.
+func (h *TusHandler) complete(ctx context.Context, u *TusUpload) error {
+	if u.Completed {
+		return nil
+	}
+	logger.Info("tus complete", "id", u.ID, "length", u.Length)
+	if h.OnComplete != nil {
+		f, err := h.Store.Open(ctx, u.ID)
+		if err != nil {
+			logger.Error(err, "tus open", "id", u.ID)
+			return fmt.Errorf("open %q: %w", u.ID, err)
+		}
+		err = h.OnComplete(ctx, *u, f)
+		f.Close()
+		if err != nil {
+			logger.Error(err, "tus OnComplete", "id", u.ID)
+			return err
+		}
+	}
+	u.Completed = true
+	if err := h.Store.Save(ctx, *u); err != nil {
+		logger.Error(err, "tus save", "id", u.ID)
+		return err
+	}
+	return nil
+}
+
+// tusInternalError responds with a generic error - the details are logged, not sent to the client.
+func tusInternalError(w http.ResponseWriter) {
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// PurgeExpired deletes the uploads which are expired, or finished and
+// not touched for Expiry, returning the number of deleted uploads.
+//
+// Uploads locked by a running request are skipped.
+//
+// It should be called periodically.
+func (h *TusHandler) PurgeExpired(ctx context.Context) (int, error) {
+	h.init()
+	uu, err := h.Store.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now := h.now()
+	var n int
+	var errs []error
+	for _, u := range uu {
+		if now.Before(u.Expires) {
+			continue
+		}
+		deleted, err := h.purge(ctx, u.ID, now)
+		if err != nil {
+			errs = append(errs, err)
+		} else if deleted {
+			n++
+		}
+	}
+	return n, errors.Join(errs...)
+}
+
+// purge deletes the upload if it is not locked, and still expired.
+func (h *TusHandler) purge(ctx context.Context, id string, now time.Time) (bool, error) {
+	mu, _ := h.locks.LoadOrStore(id, new(sync.Mutex))
+	if !mu.(*sync.Mutex).TryLock() {
+		return false, nil
+	}
+	defer mu.(*sync.Mutex).Unlock()
+	// the listed state may be stale
+	u, err := h.Store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrUploadNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("get %q: %w", id, err)
+	}
+	if now.Before(u.Expires) {
+		return false, nil
+	}
+	if err := h.Store.Delete(ctx, id); err != nil {
+		return false, fmt.Errorf("delete %q: %w", id, err)
+	}
+	h.locks.Delete(id)
+	return true, nil
+}
+
+func validTusID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeTusMetadata decodes the Upload-Metadata header:
+// comma separated key and base64 encoded value pairs, separated by a space.
+func decodeTusMetadata(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	m := make(map[string]string)
+	for kv := range strings.SplitSeq(s, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(kv), " ")
+		if k == "" {
+			return nil, fmt.Errorf("empty key in Upload-Metadata %q", s)
+		}
+		if _, ok := m[k]; ok {
+			return nil, fmt.Errorf("duplicate key %q in Upload-Metadata", k)
+		}
+		b, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("Upload-Metadata %q: %w", k, err)
+		}
+		m[k] = string(b)
+	}
+	return m, nil
+}
+
+func encodeTusMetadata(m map[string]string) string {
+	var buf strings.Builder
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		if buf.Len() != 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(k)
+		if v := m[k]; v != "" {
+			buf.WriteByte(' ')
+			buf.WriteString(base64.StdEncoding.EncodeToString([]byte(v)))
+		}
+	}
+	return buf.String()
+}
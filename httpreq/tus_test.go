@@ -0,0 +1,146 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httpreq
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tgulacsi/go/temp"
+)
+
+func TestTusHandler(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var completed string
+	var completions int
+	h := &TusHandler{
+		Store: DirTusStore(t.TempDir()), MaxSize: 1 << 20,
+		now: func() time.Time { return now },
+		OnComplete: func(ctx context.Context, u TusUpload, f temp.ReadSeekCloser) error {
+			completions++
+			b, err := io.ReadAll(f)
+			completed = u.Metadata["filename"] + ":" + string(b)
+			return err
+		},
+	}
+	do := func(method, path string, hdr map[string]string, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if method != http.MethodOptions {
+			r.Header.Set("Tus-Resumable", TusVersion)
+		}
+		for k, v := range hdr {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if got := w.Header().Get("Tus-Resumable"); got != TusVersion {
+			t.Errorf("%s %s: got Tus-Resumable %q", method, path, got)
+		}
+		return w
+	}
+	check := func(w *httptest.ResponseRecorder, code int) {
+		t.Helper()
+		if w.Code != code {
+			t.Fatalf("got %d, wanted %d: %s", w.Code, code, w.Body.String())
+		}
+	}
+	patch := func(loc string, offset int, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		return do(http.MethodPatch, loc, map[string]string{
+			"Content-Type":  tusContentType,
+			"Upload-Offset": strconv.Itoa(offset),
+		}, body)
+	}
+
+	w := do(http.MethodOptions, "/files/", nil, "")
+	check(w, http.StatusNoContent)
+	if got := w.Header().Get("Tus-Extension"); got != TusExtensions {
+		t.Errorf("got Tus-Extension %q", got)
+	}
+	r := httptest.NewRequest(http.MethodHead, "/files/x", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	check(w, http.StatusPreconditionFailed)
+
+	check(do(http.MethodPost, "/files/", map[string]string{"Upload-Length": "2000000"}, ""), http.StatusRequestEntityTooLarge)
+	w = do(http.MethodPost, "/files/", map[string]string{
+		"Upload-Length":   "11",
+		"Upload-Metadata": "filename YS50eHQ=,is_confidential",
+	}, "")
+	check(w, http.StatusCreated)
+	loc := w.Header().Get("Location")
+	if !strings.HasPrefix(loc, "/files/") {
+		t.Fatalf("got Location %q", loc)
+	}
+
+	check(patch(loc, 0, "hello"), http.StatusNoContent)
+	check(patch(loc, 0, "hello"), http.StatusConflict)
+	check(patch(loc, 5, " world, and more"), http.StatusRequestEntityTooLarge)
+	check(do(http.MethodPatch, loc, map[string]string{"Upload-Offset": "5"}, " world"), http.StatusUnsupportedMediaType)
+
+	w = do(http.MethodHead, loc, nil, "")
+	check(w, http.StatusOK)
+	if got := w.Header().Get("Upload-Offset"); got != "5" {
+		t.Errorf("got Upload-Offset %q", got)
+	}
+	if got := w.Header().Get("Upload-Metadata"); got != "filename YS50eHQ=,is_confidential" {
+		t.Errorf("got Upload-Metadata %q", got)
+	}
+
+	w = patch(loc, 5, " world")
+	check(w, http.StatusNoContent)
+	if got := w.Header().Get("Upload-Offset"); got != "11" {
+		t.Errorf("got Upload-Offset %q", got)
+	}
+	if completed != "a.txt:hello world" {
+		t.Errorf("got completed %q", completed)
+	}
+	// a repeated PATCH on the finished upload does not complete it again
+	check(patch(loc, 11, ""), http.StatusNoContent)
+	if completions != 1 {
+		t.Errorf("OnComplete called %d times, wanted 1", completions)
+	}
+	// unknown uploads must not leave a lock behind
+	check(patch("/files/unknown", 0, "x"), http.StatusNotFound)
+	if _, ok := h.locks.Load("unknown"); ok {
+		t.Error("lock kept for an unknown upload")
+	}
+	check(do(http.MethodDelete, loc, nil, ""), http.StatusNoContent)
+	check(do(http.MethodHead, loc, nil, ""), http.StatusNotFound)
+
+	// abandoned upload
+	w = do(http.MethodPost, "/files", map[string]string{"Upload-Length": "3"}, "")
+	check(w, http.StatusCreated)
+	loc = w.Header().Get("Location")
+	check(patch(loc, 0, "a"), http.StatusNoContent)
+	now = now.Add(DefaultTusExpiry + time.Second)
+	check(do(http.MethodHead, loc, nil, ""), http.StatusNotFound)
+	// a locked (being PATCHed) upload is skipped
+	mu, _ := h.locks.LoadOrStore(path.Base(loc), new(sync.Mutex))
+	mu.(*sync.Mutex).Lock()
+	if n, err := h.PurgeExpired(t.Context()); err != nil {
+		t.Fatal(err)
+	} else if n != 0 {
+		t.Errorf("purged %d locked, wanted 0", n)
+	}
+	mu.(*sync.Mutex).Unlock()
+	if n, err := h.PurgeExpired(t.Context()); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Errorf("purged %d, wanted 1", n)
+	}
+	if uu, err := h.Store.List(t.Context()); err != nil || len(uu) != 0 {
+		t.Errorf("got %v, %+v after purge", err, uu)
+	}
+}
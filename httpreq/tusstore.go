@@ -0,0 +1,141 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httpreq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tgulacsi/go/temp"
+)
+
+var _ TusStore = DirTusStore("")
+
+// DirTusStore is a TusStore in the directory, storing each upload's data in a file
+// named after the upload's ID, and its state beside it, in a .json file.
+//
+// The data file is preallocated (see punchhole.Preallocate) when created, on Linux.
+type DirTusStore string
+
+func (d DirTusStore) path(id string) string { return filepath.Join(string(d), id) }
+
+// Create the upload's data and state files.
+func (d DirTusStore) Create(ctx context.Context, u TusUpload) error {
+	if err := os.MkdirAll(string(d), 0750); err != nil {
+		return err
+	}
+	fh, err := os.OpenFile(d.path(u.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	if u.Length > 0 {
+		if err = preallocate(fh, u.Length); err != nil {
+			logger.Info("preallocate", "file", fh.Name(), "length", u.Length, "error", err)
+		}
+	}
+	if err = fh.Close(); err != nil {
+		os.Remove(fh.Name())
+		return err
+	}
+	if err = d.Save(ctx, u); err != nil {
+		os.Remove(fh.Name())
+		return err
+	}
+	return nil
+}
+
+// Get the state of the upload.
+func (d DirTusStore) Get(ctx context.Context, id string) (TusUpload, error) {
+	var u TusUpload
+	b, err := os.ReadFile(d.path(id) + ".json")
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = fmt.Errorf("%w: %w", ErrUploadNotFound, err)
+		}
+		return u, err
+	}
+	if err = json.Unmarshal(b, &u); err != nil {
+		return u, fmt.Errorf("unmarshal %q: %w", id, err)
+	}
+	return u, nil
+}
+
+// Save the state of the upload, atomically.
+func (d DirTusStore) Save(ctx context.Context, u TusUpload) error {
+	b, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return temp.WriteFileAtomic(d.path(u.ID)+".json", b, 0640)
+}
+
+// WriteAt writes the data read from r into the data file, at the offset.
+func (d DirTusStore) WriteAt(ctx context.Context, id string, offset int64, r io.Reader) (int64, error) {
+	fh, err := os.OpenFile(d.path(id), os.O_WRONLY, 0)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = fmt.Errorf("%w: %w", ErrUploadNotFound, err)
+		}
+		return 0, err
+	}
+	if _, err = fh.Seek(offset, io.SeekStart); err != nil {
+		fh.Close()
+		return 0, err
+	}
+	n, err := io.Copy(fh, r)
+	if closeErr := fh.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return n, err
+}
+
+// Open the data file.
+func (d DirTusStore) Open(ctx context.Context, id string) (temp.ReadSeekCloser, error) {
+	return os.Open(d.path(id))
+}
+
+// Delete the data and state files.
+func (d DirTusStore) Delete(ctx context.Context, id string) error {
+	var errs []error
+	for _, fn := range []string{d.path(id) + ".json", d.path(id)} {
+		if err := os.Remove(fn); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// List the uploads in the directory.
+func (d DirTusStore) List(ctx context.Context) ([]TusUpload, error) {
+	dis, err := os.ReadDir(string(d))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var uu []TusUpload
+	for _, di := range dis {
+		id, ok := strings.CutSuffix(di.Name(), ".json")
+		if !ok || !validTusID(id) {
+			continue
+		}
+		u, err := d.Get(ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrUploadNotFound) {
+				continue
+			}
+			return uu, err
+		}
+		uu = append(uu, u)
+	}
+	return uu, nil
+}
@@ -0,0 +1,21 @@
+//go:build linux
+
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httpreq
+
+import (
+	"os"
+
+	"github.com/tgulacsi/go/punchhole"
+)
+
+// preallocate reserves the disk space for the file (see punchhole.Preallocate).
+func preallocate(fh *os.File, size int64) error {
+	if punchhole.Preallocate == nil {
+		return nil
+	}
+	return punchhole.Preallocate(fh, size)
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httpreq
+
+import "os"
+
+// preallocate is a no-op where punchhole.Preallocate is not available.
+func preallocate(fh *os.File, size int64) error { return nil }
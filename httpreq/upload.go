@@ -0,0 +1,334 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httpreq
+
+import (
+	"bufio"
+	"crypto"
+	_ "crypto/sha256" // register SHA256
+	_ "crypto/sha512" // register SHA512
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/tgulacsi/go/temp"
+)
+
+var (
+	ErrNotMultipart = errors.New("not a multipart request")
+	ErrPartTooLarge = errors.New("part too large")
+	ErrBodyTooLarge = errors.New("request body too large")
+	ErrTooManyParts = errors.New("too many parts")
+)
+
+// DefaultMaxFieldSize is the default maximum size of a non-file form field.
+const DefaultMaxFieldSize = 1 << 20
+
+// UploadError is the error of the multipart handling, with the appropriate HTTP status code.
+type UploadError struct {
+	Err error
+	// Part is the form name of the part.
+	Part string
+	Code int
+}
+
+func (e *UploadError) Error() string {
+	if e.Part == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("part %q: %v", e.Part, e.Err)
+}
+func (e *UploadError) Unwrap() error { return e.Err }
+
+// StatusCode returns the HTTP status code for the error.
+func (e *UploadError) StatusCode() int { return e.Code }
+
+func newUploadError(err error, part string) *UploadError {
+	var ue *UploadError
+	if errors.As(err, &ue) {
+		return ue
+	}
+	code := http.StatusBadRequest
+	var mbe *http.MaxBytesError
+	switch {
+	case errors.Is(err, ErrPartTooLarge), errors.Is(err, ErrBodyTooLarge), errors.As(err, &mbe):
+		code = http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrNotMultipart):
+		code = http.StatusUnsupportedMediaType
+	}
+	return &UploadError{Err: err, Part: part, Code: code}
+}
+
+// UploadConfig is the configuration of the multipart handling. The zero value is usable.
+type UploadConfig struct {
+	// Hashes to be calculated for each part, defaults to SHA256.
+	Hashes []crypto.Hash
+	// MaxPartSize is the maximum size of one part (0: unlimited).
+	MaxPartSize int64
+	// MaxTotalSize is the maximum size of the request body (0: unlimited).
+	MaxTotalSize int64
+	// MaxFieldSize is the maximum size of a non-file field (DefaultMaxFieldSize if 0).
+	MaxFieldSize int64
+	// MaxParts is the maximum number of parts (0: unlimited).
+	MaxParts int
+}
+
+// MultipartReader iterates over the parts of a multipart request, without buffering them.
+type MultipartReader struct {
+	mr   *multipart.Reader
+	part *Part
+	conf UploadConfig
+	n    int
+}
+
+// NewMultipartReader returns a MultipartReader for the request.
+//
+// All returned errors are *UploadError.
+func NewMultipartReader(r *http.Request, conf UploadConfig) (*MultipartReader, error) {
+	if conf.MaxTotalSize > 0 {
+		if r.ContentLength > conf.MaxTotalSize {
+			return nil, newUploadError(ErrBodyTooLarge, "")
+		}
+		r.Body = &limitedReadCloser{ReadCloser: r.Body, N: conf.MaxTotalSize, Err: ErrBodyTooLarge}
+	}
+	if len(conf.Hashes) == 0 {
+		conf.Hashes = []crypto.Hash{crypto.SHA256}
+	}
+	mr, err := r.MultipartReader()
+	if err != nil {
+		if errors.Is(err, http.ErrNotMultipart) {
+			err = fmt.Errorf("%w: %w", ErrNotMultipart, err)
+		}
+		return nil, newUploadError(err, "")
+	}
+	return &MultipartReader{mr: mr, conf: conf}, nil
+}
+
+// NextPart returns the next part, or io.EOF when there are no more parts.
+//
+// The previous part is drained and closed.
+func (mr *MultipartReader) NextPart() (*Part, error) {
+	if mr.part != nil {
+		mr.part.Close()
+		mr.part = nil
+	}
+	if mr.conf.MaxParts > 0 && mr.n >= mr.conf.MaxParts {
+		return nil, newUploadError(ErrTooManyParts, "")
+	}
+	p, err := mr.mr.NextPart()
+	if err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, newUploadError(err, "")
+	}
+	mr.n++
+	part := &Part{
+		Header:   p.Header,
+		FormName: p.FormName(), FileName: p.FileName(),
+		ContentType: p.Header.Get("Content-Type"),
+		part:        p,
+		hashes:      make(map[crypto.Hash]hash.Hash, len(mr.conf.Hashes)),
+	}
+	limit := mr.conf.MaxPartSize
+	if part.FileName == "" {
+		if limit = mr.conf.MaxFieldSize; limit == 0 {
+			limit = DefaultMaxFieldSize
+		}
+	}
+	var r io.Reader = p
+	if limit > 0 {
+		r = &limitedReadCloser{ReadCloser: io.NopCloser(p), N: limit, Err: ErrPartTooLarge}
+	}
+	ws := make([]io.Writer, 0, len(mr.conf.Hashes))
+	for _, h := range mr.conf.Hashes {
+		hsh := h.New()
+		part.hashes[h] = hsh
+		ws = append(ws, hsh)
+	}
+	br := bufio.NewReaderSize(io.TeeReader(r, io.MultiWriter(ws...)), 512)
+	b, err := br.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, newUploadError(err, part.FormName)
+	}
+	part.DetectedType = http.DetectContentType(b)
+	part.r = br
+	mr.part = part
+	return part, nil
+}
+
+// Part is a part of a multipart request, which hashes its content while being read.
+type Part struct {
+	Header textproto.MIMEHeader
+	part   *multipart.Part
+	r      io.Reader
+	hashes map[crypto.Hash]hash.Hash
+	// FormName is the name of the form field.
+	FormName string
+	// FileName is the file name, empty for non-file fields.
+	FileName string
+	// ContentType is the declared Content-Type.
+	ContentType string
+	// DetectedType is the sniffed content type (see http.DetectContentType).
+	DetectedType string
+	size         int64
+	eof          bool
+}
+
+// Read reads the part, returning *UploadError on errors.
+func (p *Part) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.size += int64(n)
+	if err == io.EOF {
+		p.eof = true
+	} else if err != nil {
+		err = newUploadError(err, p.FormName)
+	}
+	return n, err
+}
+
+// Close drains the part.
+func (p *Part) Close() error {
+	if !p.eof {
+		if _, err := io.Copy(io.Discard, p); err != nil {
+			return err
+		}
+	}
+	return p.part.Close()
+}
+
+// Size returns the number of bytes read so far.
+func (p *Part) Size() int64 { return p.size }
+
+// Sum returns the hash of the content, valid only after the part has been read till EOF.
+// Returns nil if the hash has not been calculated.
+func (p *Part) Sum(h crypto.Hash) []byte {
+	if !p.eof {
+		return nil
+	}
+	if hsh := p.hashes[h]; hsh != nil {
+		return hsh.Sum(nil)
+	}
+	return nil
+}
+
+// StoredFile is a file part stored in a temporary file.
+type StoredFile struct {
+	Header textproto.MIMEHeader
+	// Sums are the calculated hashes.
+	Sums map[crypto.Hash][]byte
+	// Path is the path of the temporary file.
+	Path                                          string
+	FormName, FileName, ContentType, DetectedType string
+	Size                                          int64
+}
+
+// Upload is the result of StoreMultipart.
+type Upload struct {
+	// Values are the non-file fields.
+	Values url.Values
+	Files  []StoredFile
+}
+
+// RemoveAll removes the stored temporary files.
+func (u *Upload) RemoveAll() error {
+	var errs []error
+	for _, f := range u.Files {
+		if err := os.Remove(f.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// StoreMultipart stores the file parts of the multipart request in temporary files
+// (see temp.ReaderToFile), while hashing and sniffing their content.
+//
+// The returned error is an *UploadError, with the appropriate HTTP status code
+// (413 for size limits, 415 for non-multipart requests).
+// The stored files are removed on error.
+func StoreMultipart(r *http.Request, conf UploadConfig) (*Upload, error) {
+	defer r.Body.Close()
+	mr, err := NewMultipartReader(r, conf)
+	if err != nil {
+		return nil, err
+	}
+	u := Upload{Values: make(url.Values)}
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			u.RemoveAll()
+			return nil, err
+		}
+		if p.FileName == "" {
+			var buf strings.Builder
+			if _, err = io.Copy(&buf, p); err != nil {
+				u.RemoveAll()
+				return nil, err
+			}
+			u.Values.Add(p.FormName, buf.String())
+			continue
+		}
+		fn, err := temp.ReaderToFile(p, p.FileName, "")
+		if err != nil {
+			u.RemoveAll()
+			var ue *UploadError
+			if !errors.As(err, &ue) {
+				err = &UploadError{Err: fmt.Errorf("store: %w", err), Part: p.FormName, Code: http.StatusInternalServerError}
+			}
+			return nil, err
+		}
+		sf := StoredFile{
+			Header: p.Header, Path: fn,
+			FormName: p.FormName, FileName: p.FileName,
+			ContentType: p.ContentType, DetectedType: p.DetectedType,
+			Size: p.Size(),
+			Sums: make(map[crypto.Hash][]byte, len(p.hashes)),
+		}
+		for h := range p.hashes {
+			sf.Sums[h] = p.Sum(h)
+		}
+		if sf.ContentType == "" {
+			sf.ContentType = sf.DetectedType
+		} else if mt, _, err := mime.ParseMediaType(sf.ContentType); err == nil && mt == "application/octet-stream" {
+			sf.ContentType = sf.DetectedType
+		}
+		u.Files = append(u.Files, sf)
+	}
+	return &u, nil
+}
+
+// limitedReadCloser returns Err after reading more than N bytes.
+type limitedReadCloser struct {
+	io.ReadCloser
+	Err error
+	N   int64
+}
+
+func (l *limitedReadCloser) Read(p []byte) (int, error) {
+	if l.N < 0 {
+		return 0, l.Err
+	}
+	if int64(len(p)) > l.N+1 {
+		p = p[:l.N+1]
+	}
+	n, err := l.ReadCloser.Read(p)
+	l.N -= int64(n)
+	if l.N < 0 {
+		return n, l.Err
+	}
+	return n, err
+}
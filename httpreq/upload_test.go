@@ -0,0 +1,74 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httpreq
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStoreMultipart(t *testing.T) {
+	pdf := "%PDF-1.4\n" + strings.Repeat("x", 1000)
+	newReq := func() *http.Request {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		mw.WriteField("name", "value")
+		w, err := CreateFormFile(mw, "upfile", "a.pdf", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(pdf))
+		if err = mw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		r := httptest.NewRequest("POST", "/", bytes.NewReader(buf.Bytes()))
+		r.Header.Set("Content-Type", mw.FormDataContentType())
+		return r
+	}
+
+	u, err := StoreMultipart(newReq(), UploadConfig{Hashes: []crypto.Hash{crypto.SHA256, crypto.SHA512}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer u.RemoveAll()
+	if got := u.Values.Get("name"); got != "value" {
+		t.Errorf("got field %q", got)
+	}
+	if len(u.Files) != 1 {
+		t.Fatalf("got %d files", len(u.Files))
+	}
+	f := u.Files[0]
+	want := sha256.Sum256([]byte(pdf))
+	if !bytes.Equal(f.Sums[crypto.SHA256], want[:]) || len(f.Sums[crypto.SHA512]) != 64 {
+		t.Errorf("got sums %x", f.Sums)
+	}
+	if f.ContentType != "application/pdf" || f.DetectedType != "application/pdf" || f.Size != int64(len(pdf)) {
+		t.Errorf("got %+v", f)
+	}
+	if b, err := os.ReadFile(f.Path); err != nil || string(b) != pdf {
+		t.Errorf("stored %d bytes: %+v", len(b), err)
+	}
+
+	for _, conf := range []UploadConfig{{MaxPartSize: 100}, {MaxTotalSize: 100}} {
+		_, err = StoreMultipart(newReq(), conf)
+		var ue *UploadError
+		if !errors.As(err, &ue) || ue.StatusCode() != http.StatusRequestEntityTooLarge {
+			t.Errorf("%+v: got %v, wanted 413", conf, err)
+		}
+	}
+	_, err = StoreMultipart(httptest.NewRequest("POST", "/", strings.NewReader("a")), UploadConfig{})
+	var ue *UploadError
+	if !errors.As(err, &ue) || ue.StatusCode() != http.StatusUnsupportedMediaType || !errors.Is(err, ErrNotMultipart) {
+		t.Errorf("got %v, wanted 415", err)
+	}
+}
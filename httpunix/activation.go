@@ -0,0 +1,155 @@
+// Copyright (c) 2026 Tamás Gulácsi.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package httpunix
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrNoActivation is returned when the process has not been socket activated.
+var ErrNoActivation = errors.New("no socket activation")
+
+// listenFDsStart is the first passed file descriptor (SD_LISTEN_FDS_START).
+var listenFDsStart = 3
+
+// activation holds the socket activated listeners, parsed once,
+// which are not handed out yet.
+var activation struct {
+	err  error
+	lns  []namedListener
+	once sync.Once
+	mu   sync.Mutex
+}
+
+// takeActivated parses the passed file descriptors on the first call,
+// and returns (and forgets) the remaining listeners for which take returns true.
+func takeActivated(take func(namedListener) bool) ([]namedListener, error) {
+	activation.once.Do(func() { activation.lns, activation.err = activationListeners() })
+	activation.mu.Lock()
+	defer activation.mu.Unlock()
+	if activation.err != nil {
+		return nil, activation.err
+	}
+	var taken []namedListener
+	remaining := activation.lns[:0]
+	for _, ln := range activation.lns {
+		if take(ln) {
+			taken = append(taken, ln)
+		} else {
+			remaining = append(remaining, ln)
+		}
+	}
+	activation.lns = remaining
+	return taken, nil
+}
+
+// ActivationListeners returns the listeners passed by systemd socket activation
+// (LISTEN_PID, LISTEN_FDS, LISTEN_FDNAMES), keyed by their names
+// ("unknown" when LISTEN_FDNAMES is not set), which are not returned yet
+// by ActivationListeners or ActivationListener.
+//
+// The environment variables are parsed only once and unset, so child processes won't inherit them.
+// The file descriptors are set close-on-exec.
+func ActivationListeners() (map[string][]net.Listener, error) {
+	lns, err := takeActivated(func(namedListener) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	if len(lns) == 0 {
+		return nil, ErrNoActivation
+	}
+	m := make(map[string][]net.Listener, len(lns))
+	for _, ln := range lns {
+		m[ln.name] = append(m[ln.name], ln.Listener)
+	}
+	return m, nil
+}
+
+// ActivationListener returns the first socket activated listener with the given name,
+// or the first one if name is empty, which is not returned yet.
+// The rest is kept for the later calls, so each socket of a multi-socket unit can be requested by its name.
+func ActivationListener(name string) (net.Listener, error) {
+	var found bool
+	lns, err := takeActivated(func(ln namedListener) bool {
+		if found || name != "" && ln.name != name {
+			return false
+		}
+		found = true
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(lns) == 0 {
+		return nil, fmt.Errorf("%w: no listener named %q", ErrNoActivation, name)
+	}
+	return lns[0].Listener, nil
+}
+
+type namedListener struct {
+	net.Listener
+	name string
+}
+
+func activationListeners() ([]namedListener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, ErrNoActivation
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, ErrNoActivation
+	}
+	var names []string
+	if s := os.Getenv("LISTEN_FDNAMES"); s != "" {
+		names = strings.Split(s, ":")
+	}
+	lns := make([]namedListener, 0, n)
+	for i := range n {
+		fd := listenFDsStart + i
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		setCloseOnExec(fd)
+		f := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, ln := range lns {
+				ln.Close()
+			}
+			return nil, fmt.Errorf("fd %d (%s): %w", fd, name, err)
+		}
+		lns = append(lns, namedListener{Listener: ln, name: name})
+	}
+	return lns, nil
+}
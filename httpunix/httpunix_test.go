@@ -0,0 +1,195 @@
+// Copyright (c) 2026 Tamás Gulácsi.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package httpunix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// resetActivation forgets the parsed activation listeners.
+func resetActivation(t *testing.T) {
+	activation.lns, activation.err = nil, nil
+	activation.once = sync.Once{}
+	t.Cleanup(func() {
+		for _, ln := range activation.lns {
+			ln.Close()
+		}
+		activation.lns, activation.err = nil, nil
+		activation.once = sync.Once{}
+	})
+}
+
+// passListeners passes n new TCP listeners as activated ones, returns their addresses.
+func passListeners(t *testing.T, names ...string) []string {
+	t.Helper()
+	resetActivation(t)
+	// the passed fds must be consecutive
+	var fds []*os.File
+	var addrs []string
+	for range 10 {
+		fds, addrs = fds[:0], addrs[:0]
+		for range names {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := ln.(*net.TCPListener).File()
+			ln.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { f.Close() })
+			fds = append(fds, f)
+			addrs = append(addrs, ln.Addr().String())
+		}
+		consecutive := true
+		for i, f := range fds {
+			consecutive = consecutive && int(f.Fd()) == int(fds[0].Fd())+i
+		}
+		if consecutive {
+			break
+		}
+	}
+
+	oldStart := listenFDsStart
+	t.Cleanup(func() { listenFDsStart = oldStart })
+	listenFDsStart = int(fds[0].Fd())
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", strconv.Itoa(len(names)))
+	t.Setenv("LISTEN_FDNAMES", strings.Join(names, ":"))
+	return addrs
+}
+
+func TestActivationListener(t *testing.T) {
+	addrs := passListeners(t, "web")
+
+	aln, err := ActivationListener("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer aln.Close()
+	if aln.Addr().String() != addrs[0] {
+		t.Errorf("got %s, wanted %s", aln.Addr(), addrs[0])
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Error("LISTEN_FDS is not unset")
+	}
+	if _, err = ActivationListeners(); err != ErrNoActivation {
+		t.Errorf("got %v, wanted ErrNoActivation", err)
+	}
+}
+
+func TestActivationListenerMulti(t *testing.T) {
+	addrs := passListeners(t, "a", "b", "b")
+	for _, tC := range []struct {
+		Name, Want string
+	}{{"b", addrs[1]}, {"a", addrs[0]}, {"", addrs[2]}} {
+		ln, err := ActivationListener(tC.Name)
+		if err != nil {
+			t.Fatalf("%q: %+v", tC.Name, err)
+		}
+		defer ln.Close()
+		if ln.Addr().String() != tC.Want {
+			t.Errorf("%q: got %s, wanted %s", tC.Name, ln.Addr(), tC.Want)
+		}
+	}
+	if _, err := ActivationListener("a"); !errors.Is(err, ErrNoActivation) {
+		t.Errorf("a again: got %v, wanted ErrNoActivation", err)
+	}
+}
+
+func TestListenAndServe(t *testing.T) {
+	dir := t.TempDir()
+	notifyAddr := filepath.Join(dir, "notify")
+	notify, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: notifyAddr, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer notify.Close()
+	t.Setenv("NOTIFY_SOCKET", notifyAddr)
+	readNotify := func() string {
+		t.Helper()
+		notify.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var a [64]byte
+		n, _, err := notify.ReadFromUnix(a[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(a[:n])
+	}
+
+	sockets := []string{filepath.Join(dir, "http.sock")}
+	if runtime.GOOS == "linux" {
+		sockets = append(sockets, fmt.Sprintf("@httpunix-test-%d", os.Getpid()))
+	}
+	for _, sock := range sockets {
+		ctx, cancel := context.WithCancel(t.Context())
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- ListenAndServe(ctx, "unix:"+sock, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				pc, ok := PeerCredFromContext(r.Context())
+				fmt.Fprintf(w, "%t %d", ok, pc.UID)
+			}))
+		}()
+		if got := readNotify(); got != NotifyReady {
+			t.Errorf("got %q, wanted %q", got, NotifyReady)
+		}
+
+		var tr Transport
+		resp, err := (&http.Client{Transport: &tr}).Get(Scheme + "://" + tr.GetLocation(sock) + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "false 0"
+		if runtime.GOOS == "linux" {
+			want = fmt.Sprintf("true %d", os.Getuid())
+		}
+		if string(b) != want {
+			t.Errorf("%s: got %q, wanted %q", sock, b, want)
+		}
+
+		cancel()
+		if got := readNotify(); got != NotifyStopping {
+			t.Errorf("got %q, wanted %q", got, NotifyStopping)
+		}
+		if err := <-errCh; err != http.ErrServerClosed {
+			t.Errorf("got %v", err)
+		}
+	}
+}
@@ -67,37 +67,88 @@ func ListenAndServe(ctx context.Context, addr string, hndl http.Handler) error {
 	})
 }
 
-// ListenAndServeSrv is the same as http.ListenAndServe, except it can listen on unix domain sockets.
+// ShutdownTimeout is the time allowed for the graceful shutdown of the server.
+var ShutdownTimeout = 3 * time.Second
+
+// ListenAndServeSrv is the same as http.ListenAndServe, except it can listen on unix domain sockets
+// ("unix:/path", or "unix:@name" for the abstract namespace),
+// and on systemd activated sockets ("systemd:" for the first, "systemd:NAME" for a named one).
+//
+// The peer credentials of unix domain socket connections are put into the request context
+// (see ConnContext), if srv.ConnContext is nil.
+//
+// If NOTIFY_SOCKET is set, the service manager is notified when the server is ready and when it stops,
+// and the watchdog is pinged if WATCHDOG_USEC is set.
+// When ctx is canceled, the server is shut down gracefully, waiting at most ShutdownTimeout.
 func ListenAndServeSrv(ctx context.Context, addr string, srv *http.Server) error {
 	addr = strings.TrimPrefix(addr, "http+")
+	srv.Addr = addr
 	var ln net.Listener
-	if !strings.HasPrefix(addr, "unix:") {
-		srv.Addr = addr
-	} else {
-		srv.Addr = addr
+	var err error
+	switch {
+	case strings.HasPrefix(addr, "systemd:"):
+		if ln, err = ActivationListener(strings.TrimPrefix(addr, "systemd:")); err != nil {
+			return fmt.Errorf("%s: %w", addr, err)
+		}
+	case strings.HasPrefix(addr, "unix:"):
 		addrU := addr
 		addr = strings.TrimPrefix(addr[4:], "://")
 		addr = strings.TrimPrefix(addr, ":")
-		os.Remove(addr)
+		if !strings.HasPrefix(addr, "@") {
+			os.Remove(addr)
+		}
 		slog.Debug("Listen", "addr", addr)
-		var err error
 		if ln, err = net.Listen("unix", addr); err != nil {
 			return fmt.Errorf("%s: %w", addrU, err)
 		}
-		defer ln.Close()
+	default:
+		if addr == "" {
+			addr = ":http"
+		}
+		if ln, err = net.Listen("tcp", addr); err != nil {
+			return err
+		}
+	}
+	defer ln.Close()
+	if srv.ConnContext == nil {
+		srv.ConnContext = ConnContext
 	}
+
+	done, shutdownDone := make(chan struct{}), make(chan struct{})
+	defer close(done)
 	go func() {
-		<-ctx.Done()
-		if ln != nil {
-			ln.Close()
+		defer close(shutdownDone)
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
 		}
-		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		Notify(NotifyStopping)
+		ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
 		defer cancel()
 		srv.Shutdown(ctx)
 		srv.Close()
 	}()
-	if ln != nil {
-		return srv.Serve(ln)
+	if d := WatchdogInterval(); d > 0 {
+		go func() {
+			ticker := time.NewTicker(d / 2)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					Notify(NotifyWatchdog)
+				}
+			}
+		}()
+	}
+	Notify(NotifyReady)
+	err = srv.Serve(ln)
+	if ctx.Err() != nil {
+		<-shutdownDone
 	}
-	return srv.ListenAndServe()
+	return err
 }
@@ -0,0 +1,71 @@
+// Copyright (c) 2026 Tamás Gulácsi.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package httpunix
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// ErrNoPeerCred is returned when the peer credentials are not available.
+var ErrNoPeerCred = errors.New("peer credentials are not available")
+
+// PeerCred is the credentials of the process on the other side of a unix domain socket.
+type PeerCred struct {
+	PID      int32
+	UID, GID uint32
+}
+
+type ctxKeyPeerCred struct{}
+
+// ConnContext can be used as http.Server.ConnContext:
+// it puts the peer credentials of unix domain socket connections into the context
+// (see PeerCredFromContext).
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	uc, ok := c.(*net.UnixConn)
+	if !ok {
+		return ctx
+	}
+	if pc, err := GetPeerCred(uc); err == nil {
+		return context.WithValue(ctx, ctxKeyPeerCred{}, pc)
+	}
+	return ctx
+}
+
+// PeerCredFromContext returns the PeerCred stored by ConnContext.
+func PeerCredFromContext(ctx context.Context) (PeerCred, bool) {
+	pc, ok := ctx.Value(ctxKeyPeerCred{}).(PeerCred)
+	return pc, ok
+}
+
+// AllowPeers serves hndl only if the request comes from a peer accepted by allow,
+// responds with 403 Forbidden otherwise (also when there are no peer credentials).
+func AllowPeers(allow func(PeerCred) bool, hndl http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if pc, ok := PeerCredFromContext(r.Context()); !ok || !allow(pc) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		hndl.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2026 Tamás Gulácsi.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build linux
+
+package httpunix
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// GetPeerCred returns the credentials of the peer (SO_PEERCRED).
+func GetPeerCred(c *net.UnixConn) (PeerCred, error) {
+	var pc PeerCred
+	rc, err := c.SyscallConn()
+	if err != nil {
+		return pc, err
+	}
+	var credErr error
+	if err = rc.Control(func(fd uintptr) {
+		var uc *unix.Ucred
+		if uc, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED); credErr == nil {
+			pc = PeerCred{PID: uc.Pid, UID: uc.Uid, GID: uc.Gid}
+		}
+	}); err != nil {
+		return pc, err
+	}
+	return pc, credErr
+}
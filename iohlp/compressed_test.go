@@ -0,0 +1,213 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package iohlp_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/tgulacsi/go/iohlp"
+	"github.com/tgulacsi/go/zipfs"
+)
+
+// testData returns compressible, but not trivial data.
+func testData(n int) []byte {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	words := []string{"árvíztűrő", "tükörfúrógép", "lorem", "ipsum", "dolor", "sit", "amet", "\n"}
+	var buf bytes.Buffer
+	for buf.Len() < n {
+		if rnd.IntN(16) == 0 {
+			fmt.Fprintf(&buf, "%x ", rnd.Uint64())
+		} else {
+			buf.WriteString(words[rnd.IntN(len(words))])
+			buf.WriteByte(' ')
+		}
+	}
+	return buf.Bytes()[:n]
+}
+
+func gzipData(t testing.TB, level int, parts ...[]byte) []byte {
+	var buf bytes.Buffer
+	for _, p := range parts {
+		gw, err := gzip.NewWriterLevel(&buf, level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gw.Name = "test"
+		if _, err = gw.Write(p); err != nil {
+			t.Fatal(err)
+		}
+		if err = gw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func checkReaderAt(t *testing.T, ra iohlp.SizeReaderAt, want []byte) {
+	t.Helper()
+	if got := ra.Size(); got != int64(len(want)) {
+		t.Fatalf("size: got %d, wanted %d", got, len(want))
+	}
+	if got, err := io.ReadAll(io.NewSectionReader(ra, 0, ra.Size())); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(got, want) {
+		t.Fatal("sequential read mismatch")
+	}
+	rnd := rand.New(rand.NewPCG(3, 4))
+	buf := make([]byte, 100_000)
+	for range 100 {
+		off := rnd.Int64N(int64(len(want)))
+		p := buf[:rnd.IntN(len(buf))]
+		n, err := ra.ReadAt(p, off)
+		wantN := min(len(p), len(want)-int(off))
+		if n != wantN || (err != nil && (err != io.EOF || n == len(p))) {
+			t.Fatalf("ReadAt(%d, %d): got %d, %+v, wanted %d", len(p), off, n, err, wantN)
+		}
+		if !bytes.Equal(p[:n], want[off:off+int64(n)]) {
+			t.Fatalf("ReadAt(%d, %d): mismatch", len(p), off)
+		}
+	}
+	if n, err := ra.ReadAt(buf[:1], ra.Size()); n != 0 || err != io.EOF {
+		t.Errorf("ReadAt(size): got %d, %+v", n, err)
+	}
+}
+
+func TestGzipReaderAt(t *testing.T) {
+	data := testData(3 << 20)
+	for _, tC := range []struct {
+		Name      string
+		Parts     [][]byte
+		Level     int
+		MinPoints int
+	}{
+		{"best", [][]byte{data}, gzip.BestCompression, 8},
+		{"stored", [][]byte{data}, gzip.NoCompression, 8},
+		// compress/flate writes one huge block, so there are no other checkpoints
+		{"huffman", [][]byte{data}, gzip.HuffmanOnly, 1},
+		{"multi", [][]byte{data[:1<<20], data[1<<20 : 1<<20], data[1<<20:]}, gzip.DefaultCompression, 8},
+	} {
+		t.Run(tC.Name, func(t *testing.T) {
+			gz := gzipData(t, tC.Level, tC.Parts...)
+			idx, err := iohlp.BuildGzipIndex(bytes.NewReader(gz), 256<<10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("%d bytes in %d checkpoints", len(gz), len(idx.Points))
+			if len(idx.Points) < tC.MinPoints {
+				t.Errorf("got %d checkpoints, wanted at least %d", len(idx.Points), tC.MinPoints)
+			}
+			b, err := idx.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var idx2 iohlp.GzipIndex
+			if err = idx2.UnmarshalBinary(b); err != nil {
+				t.Fatal(err)
+			}
+			ra, err := iohlp.NewGzipReaderAt(bytes.NewReader(gz), &idx2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkReaderAt(t, ra, data)
+		})
+	}
+
+	gz := gzipData(t, gzip.DefaultCompression, data[:100_000])
+	gz[len(gz)/2] ^= 0xff
+	if _, err := iohlp.BuildGzipIndex(bytes.NewReader(gz), 0); err == nil {
+		t.Error("corrupt stream: no error")
+	}
+}
+
+func TestZstdReaderAt(t *testing.T) {
+	data := testData(3<<20 + 123)
+	var buf bytes.Buffer
+	zw, err := iohlp.NewZstdSeekableWriter(&buf, 256<<10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ra, err := iohlp.NewZstdReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkReaderAt(t, ra, data)
+
+	if _, err = iohlp.NewZstdReaderAt(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("not seekable: no error")
+	}
+}
+
+func TestCompressedZipFS(t *testing.T) {
+	data := testData(1 << 20)
+	var zbuf bytes.Buffer
+	zipW := zip.NewWriter(&zbuf)
+	for i := range 3 {
+		w, err := zipW.CreateHeader(&zip.FileHeader{Name: fmt.Sprintf("dir/%d.txt", i), Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zipW.Close(); err != nil {
+		t.Fatal(err)
+	}
+	half := zbuf.Len() / 2
+
+	// the first half as gzip, the second half as zstd
+	gz := gzipData(t, gzip.DefaultCompression, zbuf.Bytes()[:half])
+	gzRA, err := iohlp.NewGzipReaderAt(bytes.NewReader(gz), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var zst bytes.Buffer
+	zw, err := iohlp.NewZstdSeekableWriter(&zst, 64<<10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw.Write(zbuf.Bytes()[half:])
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zstRA, err := iohlp.NewZstdReaderAt(bytes.NewReader(zst.Bytes()), int64(zst.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsys, err := zipfs.NewZipFS(iohlp.NewMultiReaderAt(gzRA, zstRA))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := fs.ReadFile(fsys, "dir/2.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("mismatch")
+	}
+}
+
+func BenchmarkBuildGzipIndex(b *testing.B) {
+	data := testData(4 << 20)
+	gz := gzipData(b, gzip.DefaultCompression, data)
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		if _, err := iohlp.BuildGzipIndex(bytes.NewReader(gz), 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+// +build windows
+
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package iohlp
+
+import "os"
+
+// SetDirect is a no-op on Windows, which has no O_DIRECT flag.
+func SetDirect(f *os.File) error { return nil }
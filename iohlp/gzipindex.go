@@ -0,0 +1,216 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package iohlp
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+// DefaultGzipSpan is the default distance of the checkpoints in the uncompressed data.
+const DefaultGzipSpan = 1 << 20
+
+// GzipCheckpoint is a point where the decompression can be restarted (as in zlib's zran.c).
+type GzipCheckpoint struct {
+	// Window is the last (at most) 32KiB of the uncompressed data before Out.
+	Window []byte
+	// Out is the offset in the uncompressed data.
+	Out int64
+	// In is the offset in the compressed data, Bits is the number of bits
+	// to skip from the byte at In.
+	In   int64
+	Bits uint8
+}
+
+// GzipIndex is a random access index of a gzip file.
+type GzipIndex struct {
+	Points []GzipCheckpoint
+	// Size is the uncompressed size.
+	Size int64
+}
+
+// BuildGzipIndex decompresses the gzip stream (which may have multiple members),
+// and returns an index with checkpoints at about span (DefaultGzipSpan if <= 0)
+// distances of the uncompressed data.
+func BuildGzipIndex(r io.Reader, span int64) (*GzipIndex, error) {
+	if span <= 0 {
+		span = DefaultGzipSpan
+	}
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReaderSize(r, 64<<10)
+	}
+	d := newGzipDecoder(br)
+	d.verify = true
+	if err := d.readHeader(); err != nil {
+		return nil, err
+	}
+	var idx GzipIndex
+	add := func() {
+		pos := d.br.bitPos()
+		idx.Points = append(idx.Points, GzipCheckpoint{
+			Out: d.out, In: pos / 8, Bits: uint8(pos % 8), Window: d.window(),
+		})
+	}
+	add()
+	buf := make([]byte, 64<<10)
+	for {
+		_, err := d.Read(buf)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if d.atBoundary() && d.out-idx.Points[len(idx.Points)-1].Out >= span {
+			add()
+		}
+	}
+	idx.Size = d.out
+	return &idx, nil
+}
+
+const gzipIndexMagic = "gzix\x01"
+
+// MarshalBinary encodes the index.
+func (idx *GzipIndex) MarshalBinary() ([]byte, error) {
+	n := len(gzipIndexMagic) + 2*binary.MaxVarintLen64
+	for _, p := range idx.Points {
+		n += 4*binary.MaxVarintLen64 + len(p.Window)
+	}
+	b := append(make([]byte, 0, n), gzipIndexMagic...)
+	b = binary.AppendUvarint(b, uint64(idx.Size))
+	b = binary.AppendUvarint(b, uint64(len(idx.Points)))
+	for _, p := range idx.Points {
+		b = binary.AppendUvarint(b, uint64(p.Out))
+		b = binary.AppendUvarint(b, uint64(p.In))
+		b = append(b, p.Bits)
+		b = binary.AppendUvarint(b, uint64(len(p.Window)))
+		b = append(b, p.Window...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes the index encoded by MarshalBinary.
+func (idx *GzipIndex) UnmarshalBinary(b []byte) error {
+	if len(b) < len(gzipIndexMagic) || string(b[:len(gzipIndexMagic)]) != gzipIndexMagic {
+		return errors.New("not a gzip index")
+	}
+	b = b[len(gzipIndexMagic):]
+	var err error
+	uvarint := func() int64 {
+		v, n := binary.Uvarint(b)
+		if n <= 0 || v > 1<<62 {
+			if err == nil {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0
+		}
+		b = b[n:]
+		return int64(v)
+	}
+	idx.Size = uvarint()
+	n := uvarint()
+	if err != nil {
+		return err
+	}
+	idx.Points = make([]GzipCheckpoint, 0, min(n, int64(len(b)/3)))
+	for range n {
+		p := GzipCheckpoint{Out: uvarint(), In: uvarint()}
+		if err != nil || len(b) == 0 {
+			return io.ErrUnexpectedEOF
+		}
+		p.Bits, b = b[0], b[1:]
+		m := uvarint()
+		if err != nil || int64(len(b)) < m || m > flateWindowSize || p.Bits > 7 {
+			return fmt.Errorf("bad gzip index checkpoint %d", len(idx.Points))
+		}
+		p.Window, b = b[:m:m], b[m:]
+		idx.Points = append(idx.Points, p)
+	}
+	return nil
+}
+
+// NewGzipReaderAt returns a SizeReaderAt of the uncompressed data of the gzip file.
+// A read decompresses from the last checkpoint before its offset only.
+//
+// The index is built with BuildGzipIndex if nil.
+func NewGzipReaderAt(ra io.ReaderAt, idx *GzipIndex) (SizeReaderAt, error) {
+	if idx == nil {
+		var err error
+		if idx, err = BuildGzipIndex(io.NewSectionReader(ra, 0, 1<<62), 0); err != nil {
+			return nil, err
+		}
+	}
+	if len(idx.Points) == 0 {
+		return nil, errors.New("empty gzip index")
+	}
+	return &gzipReaderAt{ra: ra, idx: idx}, nil
+}
+
+type gzipReaderAt struct {
+	ra  io.ReaderAt
+	idx *GzipIndex
+	// cur is the decoder of the last read, reused by the reads following it.
+	cur     *gzipDecoder
+	scratch []byte
+	mu      sync.Mutex
+}
+
+func (g *gzipReaderAt) Size() int64 { return g.idx.Size }
+
+func (g *gzipReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= g.idx.Size {
+		return 0, io.EOF
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	i, found := slices.BinarySearchFunc(g.idx.Points, off, func(p GzipCheckpoint, off int64) int {
+		return cmp.Compare(p.Out, off)
+	})
+	if !found {
+		i--
+	}
+	pt := g.idx.Points[i]
+	if g.cur == nil || g.cur.out > off || g.cur.out < pt.Out {
+		d := newGzipDecoder(bufio.NewReaderSize(io.NewSectionReader(g.ra, pt.In, 1<<62), 64<<10))
+		if _, err := d.br.get(uint(pt.Bits)); err != nil {
+			return 0, err
+		}
+		d.out = pt.Out - int64(len(pt.Window))
+		d.setWindow(pt.Window)
+		g.cur = d
+	}
+	for g.cur.out < off {
+		if g.scratch == nil {
+			g.scratch = make([]byte, 32<<10)
+		}
+		if _, err := g.cur.Read(g.scratch[:min(int64(len(g.scratch)), off-g.cur.out)]); err != nil {
+			g.cur = nil
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+	}
+	n, err := io.ReadFull(g.cur, p[:min(int64(len(p)), g.idx.Size-off)])
+	if err != nil {
+		g.cur = nil
+		return n, err
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
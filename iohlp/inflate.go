@@ -0,0 +1,591 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package iohlp
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// This is a minimal inflater (RFC 1951) and gzip member reader (RFC 1952),
+// following zlib's puff.c, which (unlike compress/flate) exposes the
+// block boundaries, so the decompression can be checkpointed and restarted.
+
+const (
+	flateWindowSize = 1 << 15
+	flateWindowMask = flateWindowSize - 1
+	flateMaxBits    = 15
+)
+
+var errFlateCorrupt = errors.New("corrupt deflate stream")
+
+var (
+	flateLenBase  = [29]uint16{3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 15, 17, 19, 23, 27, 31, 35, 43, 51, 59, 67, 83, 99, 115, 131, 163, 195, 227, 258}
+	flateLenExtra = [29]uint8{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0}
+	flateDistBase = [30]uint16{1, 2, 3, 4, 5, 7, 9, 13, 17, 25, 33, 49, 65, 97, 129, 193, 257, 385, 513, 769, 1025, 1537, 2049, 3073, 4097, 6145, 8193, 12289, 16385, 24577}
+	flateDistExtr = [30]uint8{0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13}
+	flateCLOrder  = [19]uint8{16, 17, 18, 0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15}
+
+	flateFixedLit, flateFixedDist huffman
+)
+
+func init() {
+	var lengths [288]uint8
+	for i := range lengths {
+		switch {
+		case i < 144:
+			lengths[i] = 8
+		case i < 256:
+			lengths[i] = 9
+		case i < 280:
+			lengths[i] = 7
+		default:
+			lengths[i] = 8
+		}
+	}
+	if err := flateFixedLit.build(lengths[:]); err != nil {
+		panic(err)
+	}
+	for i := range 30 {
+		lengths[i] = 5
+	}
+	if err := flateFixedDist.build(lengths[:30]); err != nil {
+		panic(err)
+	}
+}
+
+// huffman is a canonical Huffman code, with a lookup table for the short codes.
+type huffman struct {
+	count  [flateMaxBits + 1]uint16
+	symbol []uint16
+	// fast maps the next huffmanFastBits (reversed) bits to symbol<<4 | length,
+	// 0 if the code is longer.
+	fast [1 << huffmanFastBits]uint16
+}
+
+const huffmanFastBits = 9
+
+func (h *huffman) build(lengths []uint8) error {
+	clear(h.count[:])
+	for _, l := range lengths {
+		h.count[l]++
+	}
+	if int(h.count[0]) == len(lengths) {
+		// no codes: complete, but decoding will fail
+		h.symbol = h.symbol[:0]
+		clear(h.fast[:])
+		return nil
+	}
+	left := 1
+	for l := 1; l <= flateMaxBits; l++ {
+		left <<= 1
+		if left -= int(h.count[l]); left < 0 {
+			return fmt.Errorf("%w: over-subscribed code", errFlateCorrupt)
+		}
+	}
+	var offs [flateMaxBits + 1]uint16
+	for l := 1; l < flateMaxBits; l++ {
+		offs[l+1] = offs[l] + h.count[l]
+	}
+	h.symbol = append(h.symbol[:0], make([]uint16, len(lengths))...)
+	for sym, l := range lengths {
+		if l != 0 {
+			h.symbol[offs[l]] = uint16(sym)
+			offs[l]++
+		}
+	}
+
+	// canonical codes, reversed, for the fast table
+	clear(h.fast[:])
+	var code, index int
+	for l := 1; l <= huffmanFastBits; l++ {
+		for range int(h.count[l]) {
+			var rev int
+			for i := range l {
+				rev |= (code >> i & 1) << (l - 1 - i)
+			}
+			v := h.symbol[index]<<4 | uint16(l)
+			for j := rev; j < len(h.fast); j += 1 << l {
+				h.fast[j] = v
+			}
+			code++
+			index++
+		}
+		code <<= 1
+	}
+	return nil
+}
+
+// bitReader reads the bits LSB first, counting the consumed bytes.
+type bitReader struct {
+	r    io.ByteReader
+	pos  int64
+	bits uint64
+	nb   uint
+}
+
+// fill tries to have at least n bits buffered.
+func (br *bitReader) fill(n uint) error {
+	for br.nb < n {
+		c, err := br.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		br.bits |= uint64(c) << br.nb
+		br.nb += 8
+		br.pos++
+	}
+	return nil
+}
+
+func (br *bitReader) get(n uint) (int, error) {
+	if err := br.fill(n); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	v := int(br.bits & (1<<n - 1))
+	br.bits >>= n
+	br.nb -= n
+	return v, nil
+}
+
+// bitPos returns the position of the next unread bit.
+func (br *bitReader) bitPos() int64 { return br.pos*8 - int64(br.nb) }
+
+// align drops the bits up to the byte boundary.
+func (br *bitReader) align() {
+	br.bits >>= br.nb & 7
+	br.nb -= br.nb & 7
+}
+
+// readByte reads a byte (after align).
+func (br *bitReader) readByte() (byte, error) {
+	if br.nb >= 8 {
+		c := byte(br.bits)
+		br.bits >>= 8
+		br.nb -= 8
+		return c, nil
+	}
+	c, err := br.r.ReadByte()
+	if err == nil {
+		br.pos++
+	}
+	return c, err
+}
+
+func (br *bitReader) decode(h *huffman) (int, error) {
+	if err := br.fill(huffmanFastBits); err == nil || br.nb != 0 {
+		if v := h.fast[br.bits&(1<<huffmanFastBits-1)]; v != 0 && uint(v&15) <= br.nb {
+			br.bits >>= v & 15
+			br.nb -= uint(v & 15)
+			return int(v >> 4), nil
+		}
+	}
+	var code, first, index int
+	for l := 1; l <= flateMaxBits; l++ {
+		b, err := br.get(1)
+		if err != nil {
+			return 0, err
+		}
+		code |= b
+		count := int(h.count[l])
+		if code-count < first {
+			return int(h.symbol[index+(code-first)]), nil
+		}
+		index += count
+		first += count
+		first <<= 1
+		code <<= 1
+	}
+	return 0, fmt.Errorf("%w: bad code", errFlateCorrupt)
+}
+
+const (
+	inflateHeader = iota
+	inflateStored
+	inflateHuffman
+	inflateDone
+)
+
+// inflater decompresses a raw deflate stream, keeping the last 32KiB as window.
+type inflater struct {
+	lit, dist      *huffman
+	dynLit, dynDis huffman
+	br             bitReader
+	out            int64 // total output (including the preloaded window)
+	have           int   // valid bytes in win
+	stored         int
+	copyLen        int
+	copyDist       int
+	state          int
+	final          bool
+	win            [flateWindowSize]byte
+}
+
+// atBoundary reports whether the inflater is between two blocks of the stream.
+func (f *inflater) atBoundary() bool { return f.state == inflateHeader && !f.final }
+
+// window returns the last (at most) 32KiB output.
+func (f *inflater) window() []byte {
+	w := make([]byte, f.have)
+	start := int(f.out) - f.have
+	for i := range w {
+		w[i] = f.win[(start+i)&flateWindowMask]
+	}
+	return w
+}
+
+// setWindow preloads the window.
+func (f *inflater) setWindow(w []byte) {
+	w = w[max(0, len(w)-flateWindowSize):]
+	for i, c := range w {
+		f.win[(int(f.out)+i)&flateWindowMask] = c
+	}
+	f.out += int64(len(w))
+	f.have = len(w)
+}
+
+// reset prepares for a new deflate stream (the window is not valid anymore).
+func (f *inflater) reset() {
+	f.state, f.final, f.have = inflateHeader, false, 0
+}
+
+func (f *inflater) put(p []byte, n int, c byte) {
+	p[n] = c
+	f.win[int(f.out)&flateWindowMask] = c
+	f.out++
+	if f.have < flateWindowSize {
+		f.have++
+	}
+}
+
+// Read reads the decompressed data, returning at block boundaries,
+// and io.EOF at the end of the deflate stream.
+func (f *inflater) Read(p []byte) (int, error) {
+	var n int
+	for n < len(p) {
+		switch f.state {
+		case inflateDone:
+			if n == 0 {
+				return 0, io.EOF
+			}
+			return n, nil
+
+		case inflateHeader:
+			if n != 0 {
+				return n, nil
+			}
+			if f.final {
+				f.state = inflateDone
+				continue
+			}
+			if err := f.header(); err != nil {
+				return n, err
+			}
+
+		case inflateStored:
+			for ; f.stored > 0 && n < len(p); f.stored-- {
+				c, err := f.br.readByte()
+				if err != nil {
+					if err == io.EOF {
+						err = io.ErrUnexpectedEOF
+					}
+					return n, err
+				}
+				f.put(p, n, c)
+				n++
+			}
+			if f.stored == 0 {
+				f.state = inflateHeader
+			}
+
+		case inflateHuffman:
+			if f.copyLen != 0 {
+				for ; f.copyLen > 0 && n < len(p); f.copyLen-- {
+					f.put(p, n, f.win[int(f.out-int64(f.copyDist))&flateWindowMask])
+					n++
+				}
+				continue
+			}
+			sym, err := f.br.decode(f.lit)
+			if err != nil {
+				return n, err
+			}
+			if sym < 256 {
+				f.put(p, n, byte(sym))
+				n++
+				continue
+			} else if sym == 256 {
+				f.state = inflateHeader
+				continue
+			}
+			if sym -= 257; sym >= len(flateLenBase) {
+				return n, fmt.Errorf("%w: bad length symbol", errFlateCorrupt)
+			}
+			extra, err := f.br.get(uint(flateLenExtra[sym]))
+			if err != nil {
+				return n, err
+			}
+			f.copyLen = int(flateLenBase[sym]) + extra
+			if sym, err = f.br.decode(f.dist); err != nil {
+				return n, err
+			} else if sym >= len(flateDistBase) {
+				return n, fmt.Errorf("%w: bad distance symbol", errFlateCorrupt)
+			}
+			if extra, err = f.br.get(uint(flateDistExtr[sym])); err != nil {
+				return n, err
+			}
+			if f.copyDist = int(flateDistBase[sym]) + extra; f.copyDist > f.have {
+				return n, fmt.Errorf("%w: distance %d too far back (%d)", errFlateCorrupt, f.copyDist, f.have)
+			}
+		}
+	}
+	return n, nil
+}
+
+// header reads the block header.
+func (f *inflater) header() error {
+	v, err := f.br.get(3)
+	if err != nil {
+		return err
+	}
+	f.final = v&1 != 0
+	switch v >> 1 {
+	case 0:
+		f.br.align()
+		var b [4]byte
+		for i := range b {
+			if b[i], err = f.br.readByte(); err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return err
+			}
+		}
+		length := int(b[0]) | int(b[1])<<8
+		if length != ^(int(b[2])|int(b[3])<<8)&0xffff {
+			return fmt.Errorf("%w: stored block length mismatch", errFlateCorrupt)
+		}
+		f.stored, f.state = length, inflateStored
+		if length == 0 {
+			f.state = inflateHeader
+		}
+	case 1:
+		f.lit, f.dist, f.state = &flateFixedLit, &flateFixedDist, inflateHuffman
+	case 2:
+		if err = f.dynamic(); err != nil {
+			return err
+		}
+		f.lit, f.dist, f.state = &f.dynLit, &f.dynDis, inflateHuffman
+	default:
+		return fmt.Errorf("%w: bad block type", errFlateCorrupt)
+	}
+	return nil
+}
+
+// dynamic reads the code lengths of a dynamic block.
+func (f *inflater) dynamic() error {
+	nlen, err := f.br.get(5)
+	if err != nil {
+		return err
+	}
+	ndist, err := f.br.get(5)
+	if err != nil {
+		return err
+	}
+	ncode, err := f.br.get(4)
+	if err != nil {
+		return err
+	}
+	nlen, ndist, ncode = nlen+257, ndist+1, ncode+4
+	if nlen > 286 || ndist > 30 {
+		return fmt.Errorf("%w: bad counts", errFlateCorrupt)
+	}
+	var lengths [286 + 30]uint8
+	for i := range ncode {
+		v, err := f.br.get(3)
+		if err != nil {
+			return err
+		}
+		lengths[flateCLOrder[i]] = uint8(v)
+	}
+	var lencode huffman
+	if err = lencode.build(lengths[:19]); err != nil {
+		return err
+	}
+	clear(lengths[:19])
+	for i := 0; i < nlen+ndist; {
+		sym, err := f.br.decode(&lencode)
+		if err != nil {
+			return err
+		}
+		if sym < 16 {
+			lengths[i] = uint8(sym)
+			i++
+			continue
+		}
+		var c uint8
+		var rep int
+		switch sym {
+		case 16:
+			if i == 0 {
+				return fmt.Errorf("%w: repeat with no first length", errFlateCorrupt)
+			}
+			c = lengths[i-1]
+			rep, err = f.br.get(2)
+			rep += 3
+		case 17:
+			rep, err = f.br.get(3)
+			rep += 3
+		default:
+			rep, err = f.br.get(7)
+			rep += 11
+		}
+		if err != nil {
+			return err
+		}
+		if i+rep > nlen+ndist {
+			return fmt.Errorf("%w: too many lengths", errFlateCorrupt)
+		}
+		for ; rep > 0; rep-- {
+			lengths[i] = c
+			i++
+		}
+	}
+	if lengths[256] == 0 {
+		return fmt.Errorf("%w: no end-of-block code", errFlateCorrupt)
+	}
+	if err = f.dynLit.build(lengths[:nlen]); err != nil {
+		return err
+	}
+	return f.dynDis.build(lengths[nlen : nlen+ndist])
+}
+
+// gzipDecoder decompresses the (possibly multi-member) gzip stream.
+type gzipDecoder struct {
+	crc hash.Hash32
+	inflater
+	size   uint32
+	verify bool
+}
+
+func newGzipDecoder(r io.ByteReader) *gzipDecoder {
+	d := gzipDecoder{crc: crc32.NewIEEE()}
+	d.br.r = r
+	return &d
+}
+
+// readHeader reads the gzip member header, returning io.EOF at the end of the stream.
+func (d *gzipDecoder) readHeader() error {
+	d.br.align()
+	var b [10]byte
+	for i := range b {
+		var err error
+		if b[i], err = d.br.readByte(); err != nil {
+			if err == io.EOF && i != 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+	}
+	if b[0] != 0x1f || b[1] != 0x8b || b[2] != 8 {
+		return fmt.Errorf("bad gzip header %x", b[:3])
+	}
+	skip := func(n int) error {
+		for range n {
+			if _, err := d.br.readByte(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	skipZ := func() error {
+		for {
+			if c, err := d.br.readByte(); err != nil || c == 0 {
+				return err
+			}
+		}
+	}
+	flg := b[3]
+	if flg&4 != 0 { // FEXTRA
+		lo, err := d.br.readByte()
+		if err != nil {
+			return err
+		}
+		hi, err := d.br.readByte()
+		if err != nil {
+			return err
+		}
+		if err = skip(int(lo) | int(hi)<<8); err != nil {
+			return err
+		}
+	}
+	for _, bit := range []byte{8, 16} { // FNAME, FCOMMENT
+		if flg&bit != 0 {
+			if err := skipZ(); err != nil {
+				return err
+			}
+		}
+	}
+	if flg&2 != 0 { // FHCRC
+		if err := skip(2); err != nil {
+			return err
+		}
+	}
+	d.inflater.reset()
+	d.crc.Reset()
+	d.size = 0
+	return nil
+}
+
+// readTrailer reads (and checks if verify) the gzip member trailer.
+func (d *gzipDecoder) readTrailer() error {
+	d.br.align()
+	var b [8]byte
+	for i := range b {
+		var err error
+		if b[i], err = d.br.readByte(); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+	}
+	if !d.verify {
+		return nil
+	}
+	crc := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+	size := uint32(b[4]) | uint32(b[5])<<8 | uint32(b[6])<<16 | uint32(b[7])<<24
+	if crc != d.crc.Sum32() || size != d.size {
+		return fmt.Errorf("gzip checksum mismatch")
+	}
+	return nil
+}
+
+// Read reads the decompressed data, returning at the deflate block boundaries.
+func (d *gzipDecoder) Read(p []byte) (int, error) {
+	for {
+		n, err := d.inflater.Read(p)
+		if d.verify && n != 0 {
+			d.crc.Write(p[:n])
+			d.size += uint32(n)
+		}
+		if err != io.EOF || n != 0 {
+			return n, err
+		}
+		if err = d.readTrailer(); err != nil {
+			return 0, err
+		}
+		if err = d.readHeader(); err != nil {
+			return 0, err
+		}
+		d.verify = true
+	}
+}
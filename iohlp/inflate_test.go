@@ -0,0 +1,54 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package iohlp
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"testing"
+)
+
+// FuzzInflate checks the inflater against compress/flate:
+// arbitrary input must give the same output, or an error from both.
+func FuzzInflate(f *testing.F) {
+	data := bytes.Repeat([]byte("árvíztűrő tükörfúrógép, lorem ipsum dolor sit amet\n"), 50)
+	for _, level := range []int{flate.NoCompression, flate.BestSpeed, flate.DefaultCompression, flate.HuffmanOnly} {
+		var buf bytes.Buffer
+		fw, _ := flate.NewWriter(&buf, level)
+		fw.Write(data[:len(data)/2])
+		fw.Flush()
+		fw.Write(data[len(data)/2:])
+		fw.Close()
+		f.Add(buf.Bytes())
+		b := buf.Bytes()
+		f.Add(b[:len(b)/2])
+		corrupt := bytes.Clone(b)
+		corrupt[len(corrupt)/3] ^= 0x55
+		f.Add(corrupt)
+	}
+	f.Add([]byte{})
+	f.Add([]byte{0x03, 0x00})
+	f.Add([]byte{0x01, 0x00, 0x00, 0xff, 0xff})
+
+	// the output is bounded, as a few bytes can expand to a lot
+	const maxOut = 1 << 20
+	f.Fuzz(func(t *testing.T, in []byte) {
+		want, wantErr := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(in)), maxOut))
+		var inf inflater
+		inf.br.r = bytes.NewReader(in)
+		got, err := io.ReadAll(io.LimitReader(&inf, maxOut))
+		switch {
+		case err == nil && wantErr == nil:
+			if !bytes.Equal(got, want) {
+				t.Fatalf("%x: got %d bytes, wanted %d", in, len(got), len(want))
+			}
+		case err == nil:
+			t.Fatalf("%x: got %d bytes, wanted error %v", in, len(got), wantErr)
+		case wantErr == nil:
+			t.Fatalf("%x: got error %v, wanted %d bytes", in, err, len(want))
+		}
+	})
+}
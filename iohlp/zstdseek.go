@@ -0,0 +1,254 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package iohlp
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// The zstd seekable format (https://github.com/facebook/zstd/blob/dev/contrib/seekable_format/zstd_seekable_compression_format.md)
+// is a sequence of independent zstd frames, followed by a seek table in a skippable frame.
+const (
+	zstdSkippableMagic = 0x184D2A5E
+	zstdSeekableMagic  = 0x8F92EAB1
+	zstdSeekFooterSize = 9
+	zstdChecksumFlag   = 0x80
+
+	// DefaultZstdFrameSize is the default uncompressed size of the frames written by NewZstdSeekableWriter.
+	DefaultZstdFrameSize = 1 << 20
+)
+
+var ErrNotSeekable = errors.New("not a zstd seekable file")
+
+type zstdFrame struct {
+	cOff, dOff   int64
+	cSize, dSize uint32
+	checksum     uint32
+}
+
+// NewZstdReaderAt returns a SizeReaderAt of the uncompressed data
+// of the zstd seekable format file of the given size.
+// A read decompresses only the frames it needs.
+func NewZstdReaderAt(ra io.ReaderAt, size int64) (SizeReaderAt, error) {
+	var footer [zstdSeekFooterSize]byte
+	if size < 8+zstdSeekFooterSize {
+		return nil, ErrNotSeekable
+	}
+	if _, err := ra.ReadAt(footer[:], size-zstdSeekFooterSize); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(footer[5:]) != zstdSeekableMagic {
+		return nil, ErrNotSeekable
+	}
+	n := int64(binary.LittleEndian.Uint32(footer[:4]))
+	desc := footer[4]
+	if desc&0x7c != 0 {
+		return nil, fmt.Errorf("%w: reserved bits set in descriptor %x", ErrNotSeekable, desc)
+	}
+	entrySize := int64(8)
+	if desc&zstdChecksumFlag != 0 {
+		entrySize = 12
+	}
+	tableSize := n*entrySize + zstdSeekFooterSize
+	if size < tableSize+8 {
+		return nil, fmt.Errorf("%w: seek table of %d frames does not fit", ErrNotSeekable, n)
+	}
+	table := make([]byte, 8+tableSize)
+	if _, err := ra.ReadAt(table, size-int64(len(table))); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(table) != zstdSkippableMagic ||
+		int64(binary.LittleEndian.Uint32(table[4:])) != tableSize {
+		return nil, fmt.Errorf("%w: bad skippable frame header", ErrNotSeekable)
+	}
+	zr := zstdReaderAt{ra: ra, frames: make([]zstdFrame, 0, n), cacheIdx: -1}
+	var cOff, dOff int64
+	for b := table[8 : 8+n*entrySize]; len(b) != 0; b = b[entrySize:] {
+		f := zstdFrame{
+			cOff: cOff, dOff: dOff,
+			cSize: binary.LittleEndian.Uint32(b), dSize: binary.LittleEndian.Uint32(b[4:]),
+		}
+		if entrySize == 12 {
+			f.checksum = binary.LittleEndian.Uint32(b[8:])
+		}
+		cOff += int64(f.cSize)
+		dOff += int64(f.dSize)
+		zr.frames = append(zr.frames, f)
+	}
+	zr.checksum = entrySize == 12
+	if cOff != size-int64(len(table)) {
+		return nil, fmt.Errorf("%w: frames size %d, wanted %d", ErrNotSeekable, cOff, size-int64(len(table)))
+	}
+	zr.size = dOff
+	var err error
+	if zr.dec, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0)); err != nil {
+		return nil, err
+	}
+	return &zr, nil
+}
+
+type zstdReaderAt struct {
+	ra     io.ReaderAt
+	dec    *zstd.Decoder
+	frames []zstdFrame
+	size   int64
+	// cache is the last decompressed frame.
+	cache    []byte
+	cacheIdx int
+	mu       sync.Mutex
+	checksum bool
+}
+
+func (zr *zstdReaderAt) Size() int64 { return zr.size }
+
+func (zr *zstdReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= zr.size {
+		return 0, io.EOF
+	}
+	i, found := slices.BinarySearchFunc(zr.frames, off, func(f zstdFrame, off int64) int {
+		return cmp.Compare(f.dOff, off)
+	})
+	if !found {
+		i--
+	}
+	zr.mu.Lock()
+	defer zr.mu.Unlock()
+	var n int
+	for ; n < len(p) && i < len(zr.frames); i++ {
+		data, err := zr.frame(i)
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], data[off+int64(n)-zr.frames[i].dOff:])
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// frame returns the decompressed i-th frame, valid until the next call.
+// zr.mu must be held.
+func (zr *zstdReaderAt) frame(i int) ([]byte, error) {
+	if zr.cacheIdx == i {
+		return zr.cache, nil
+	}
+	f := zr.frames[i]
+	src := make([]byte, f.cSize)
+	if _, err := zr.ra.ReadAt(src, f.cOff); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	data, err := zr.dec.DecodeAll(src, zr.cache[:0])
+	if err != nil {
+		zr.cacheIdx = -1
+		return nil, fmt.Errorf("frame %d: %w", i, err)
+	}
+	if len(data) != int(f.dSize) {
+		zr.cacheIdx = -1
+		return nil, fmt.Errorf("frame %d: decompressed %d bytes, wanted %d", i, len(data), f.dSize)
+	}
+	if zr.checksum && uint32(xxhash.Sum64(data)) != f.checksum {
+		zr.cacheIdx = -1
+		return nil, fmt.Errorf("frame %d: checksum mismatch", i)
+	}
+	zr.cache, zr.cacheIdx = data, i
+	return data, nil
+}
+
+// NewZstdSeekableWriter returns a WriteCloser which writes the data
+// in the zstd seekable format, in independent frames of frameSize
+// (DefaultZstdFrameSize if <= 0) uncompressed bytes.
+//
+// Close must be called to write the last frame and the seek table.
+func NewZstdSeekableWriter(w io.Writer, frameSize int, opts ...zstd.EOption) (io.WriteCloser, error) {
+	if frameSize <= 0 {
+		frameSize = DefaultZstdFrameSize
+	}
+	enc, err := zstd.NewWriter(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &zstdSeekableWriter{w: w, enc: enc, frameSize: frameSize, buf: make([]byte, 0, frameSize)}, nil
+}
+
+type zstdSeekableWriter struct {
+	w         io.Writer
+	enc       *zstd.Encoder
+	err       error
+	buf, dst  []byte
+	table     []byte
+	frames    uint32
+	frameSize int
+}
+
+func (zw *zstdSeekableWriter) Write(p []byte) (int, error) {
+	if zw.err != nil {
+		return 0, zw.err
+	}
+	var n int
+	for len(p) != 0 {
+		m := min(len(p), zw.frameSize-len(zw.buf))
+		zw.buf = append(zw.buf, p[:m]...)
+		p = p[m:]
+		n += m
+		if len(zw.buf) == zw.frameSize {
+			if zw.err = zw.flush(); zw.err != nil {
+				return n, zw.err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (zw *zstdSeekableWriter) flush() error {
+	if len(zw.buf) == 0 {
+		return nil
+	}
+	zw.dst = zw.enc.EncodeAll(zw.buf, zw.dst[:0])
+	if _, err := zw.w.Write(zw.dst); err != nil {
+		return err
+	}
+	zw.table = binary.LittleEndian.AppendUint32(zw.table, uint32(len(zw.dst)))
+	zw.table = binary.LittleEndian.AppendUint32(zw.table, uint32(len(zw.buf)))
+	zw.table = binary.LittleEndian.AppendUint32(zw.table, uint32(xxhash.Sum64(zw.buf)))
+	zw.frames++
+	zw.buf = zw.buf[:0]
+	return nil
+}
+
+// Close writes the last frame and the seek table.
+func (zw *zstdSeekableWriter) Close() error {
+	if zw.err != nil {
+		return zw.err
+	}
+	if zw.err = zw.flush(); zw.err != nil {
+		return zw.err
+	}
+	zw.err = errors.New("closed")
+	b := make([]byte, 0, 8+len(zw.table)+zstdSeekFooterSize)
+	b = binary.LittleEndian.AppendUint32(b, zstdSkippableMagic)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(zw.table)+zstdSeekFooterSize))
+	b = append(b, zw.table...)
+	b = binary.LittleEndian.AppendUint32(b, zw.frames)
+	b = append(b, zstdChecksumFlag)
+	b = binary.LittleEndian.AppendUint32(b, zstdSeekableMagic)
+	_, err := zw.w.Write(b)
+	return errors.Join(err, zw.enc.Close())
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2026 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jittimer
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+// Jitter is a strategy for randomizing delays,
+// as in https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+type Jitter uint8
+
+const (
+	// NoJitter keeps the delay.
+	NoJitter = Jitter(iota)
+	// FullJitter chooses uniformly from [0, d].
+	FullJitter
+	// EqualJitter chooses uniformly from [d/2, d].
+	EqualJitter
+	// DecorrelatedJitter chooses uniformly from [base, 3*previous], capped at d:
+	// the delay depends on the previous one, not on the attempt number.
+	DecorrelatedJitter
+)
+
+func (j Jitter) String() string {
+	switch j {
+	case NoJitter:
+		return "none"
+	case FullJitter:
+		return "full"
+	case EqualJitter:
+		return "equal"
+	case DecorrelatedJitter:
+		return "decorrelated"
+	default:
+		return "Jitter(" + strconv.Itoa(int(j)) + ")"
+	}
+}
+
+// Apply returns the randomized d.
+// base and prev (the previous randomized delay) are used only by DecorrelatedJitter.
+//
+// r may be nil for the global random source.
+func (j Jitter) Apply(r *rand.Rand, d, base, prev time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	switch j {
+	case FullJitter:
+		return randN(r, d+1)
+	case EqualJitter:
+		return d - d/2 + randN(r, d/2+1)
+	case DecorrelatedJitter:
+		base = max(0, min(base, d))
+		hi := max(base, min(d, 3*prev))
+		if prev > math.MaxInt64/3 {
+			hi = d
+		}
+		return base + randN(r, hi-base+1)
+	default:
+		return d
+	}
+}
+
+func randN(r *rand.Rand, n time.Duration) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+	if r == nil {
+		return rand.N(n)
+	}
+	return time.Duration(r.Int64N(int64(n)))
+}
+
+// Backoff computes exponentially growing, randomized delays for retry loops:
+// Initial * Multiplier^attempt, capped at Max, randomized with Jitter.
+//
+// DecorrelatedJitter uses Initial as the base and Max as the cap.
+//
+// A Backoff is not safe for concurrent use.
+type Backoff struct {
+	// Clock is used by Wait; nil means RealClock.
+	Clock Clock
+	// Rand is the random source; nil means the global one.
+	Rand *rand.Rand
+	// Initial is the first delay (default 100ms).
+	Initial time.Duration
+	// Max is the cap of the delays; no cap if <= 0.
+	Max time.Duration
+	// Multiplier is the growth factor (default 2).
+	Multiplier float64
+	Jitter     Jitter
+
+	attempt int
+	prev    time.Duration
+}
+
+// Next returns the next delay.
+func (b *Backoff) Next() time.Duration {
+	initial, mult, limit := b.Initial, b.Multiplier, b.Max
+	if initial <= 0 {
+		initial = 100 * time.Millisecond
+	}
+	if mult < 1 {
+		mult = 2
+	}
+	if limit <= 0 {
+		limit = math.MaxInt64
+	}
+	var d time.Duration
+	if b.Jitter == DecorrelatedJitter {
+		if b.prev == 0 {
+			b.prev = initial
+		}
+		d = b.Jitter.Apply(b.Rand, limit, initial, b.prev)
+	} else {
+		// float64 does not overflow, but it may be larger than MaxInt64
+		f := float64(initial) * math.Pow(mult, float64(b.attempt))
+		d = limit
+		if f < float64(limit) {
+			d = time.Duration(f)
+		}
+		d = b.Jitter.Apply(b.Rand, d, initial, b.prev)
+	}
+	b.attempt++
+	b.prev = d
+	return d
+}
+
+// Attempt returns the number of delays returned by Next since the last Reset.
+func (b *Backoff) Attempt() int { return b.attempt }
+
+// Reset the backoff to the Initial delay, e.g. after a success.
+func (b *Backoff) Reset() { b.attempt, b.prev = 0, 0 }
+
+// Wait for the Next delay, or till the context is done.
+func (b *Backoff) Wait(ctx context.Context) error {
+	clock := b.Clock
+	if clock == nil {
+		clock = RealClock
+	}
+	timer := clock.NewTimer(b.Next())
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-timer.C():
+		return nil
+	}
+}
+
+// Retry calls f until it returns nil, or maxAttempts (unlimited if <= 0) calls,
+// waiting with b between the calls.
+//
+// Returns the last error of f, joined with the cause of the context if it is done.
+func Retry(ctx context.Context, b *Backoff, maxAttempts int, f func(context.Context) error) error {
+	b.Reset()
+	for i := 1; ; i++ {
+		err := f(ctx)
+		if err == nil || maxAttempts > 0 && i >= maxAttempts {
+			return err
+		}
+		if waitErr := b.Wait(ctx); waitErr != nil {
+			return errors.Join(waitErr, err)
+		}
+	}
+}
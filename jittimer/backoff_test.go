@@ -0,0 +1,111 @@
+/*
+Copyright 2026 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jittimer
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+	for i, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		if got := b.Next(); got != want*time.Millisecond {
+			t.Errorf("%d: got %v, wanted %v", i, got, want*time.Millisecond)
+		}
+	}
+	if b.Attempt() != 6 {
+		t.Errorf("Attempt: got %d", b.Attempt())
+	}
+	b.Reset()
+	if got := b.Next(); got != 100*time.Millisecond {
+		t.Errorf("after Reset: got %v", got)
+	}
+
+	// no overflow without cap
+	b = Backoff{Multiplier: 10}
+	var prev time.Duration
+	for range 30 {
+		d := b.Next()
+		if d < prev {
+			t.Fatalf("%d: %v < %v", b.Attempt(), d, prev)
+		}
+		prev = d
+	}
+
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for _, j := range []Jitter{FullJitter, EqualJitter, DecorrelatedJitter} {
+		b := Backoff{Rand: rnd, Initial: 100 * time.Millisecond, Max: 10 * time.Second, Jitter: j}
+		seen := make(map[time.Duration]bool)
+		for i := range 20 {
+			d := b.Next()
+			seen[d] = true
+			exp := min(b.Max, b.Initial<<i)
+			lo, hi := time.Duration(0), exp
+			switch j {
+			case EqualJitter:
+				lo = exp / 2
+			case DecorrelatedJitter:
+				lo, hi = b.Initial, b.Max
+			}
+			if d < lo || d > hi {
+				t.Errorf("%s #%d: %v is out of [%v, %v]", j, i, d, lo, hi)
+			}
+		}
+		if len(seen) < 15 {
+			t.Errorf("%s: only %d distinct values", j, len(seen))
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := Backoff{Clock: clock, Initial: time.Second}
+	go func() {
+		for range 2 {
+			clock.BlockUntil(1)
+			clock.Advance(time.Minute)
+		}
+	}()
+	errTemp := errors.New("temporary")
+	var calls int
+	err := Retry(context.Background(), &b, 5, func(context.Context) error {
+		if calls++; calls < 3 {
+			return errTemp
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("got %+v after %d calls", err, calls)
+	}
+
+	calls = 0
+	if err = Retry(context.Background(), &b, 1, func(context.Context) error { calls++; return errTemp }); err != errTemp || calls != 1 {
+		t.Errorf("max 1 attempt: got %+v after %d calls", err, calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		clock.BlockUntil(1)
+		cancel()
+	}()
+	if err = Retry(ctx, &b, 0, func(context.Context) error { return errTemp }); !errors.Is(err, context.Canceled) || !errors.Is(err, errTemp) {
+		t.Errorf("canceled: got %+v", err)
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2026 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jittimer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the next activation time after t, or the zero time if there is no more.
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// Every returns a Schedule which activates in every d.
+func Every(d time.Duration) Schedule { return every(d) }
+
+type every time.Duration
+
+func (d every) Next(t time.Time) time.Time { return t.Add(time.Duration(d)) }
+
+// Cron is a cron-style schedule, with seconds resolution.
+type Cron struct {
+	// Location is the time zone of the schedule; nil means the location of the time given to Next.
+	Location *time.Location
+
+	second, minute, hour, dom, month, dow uint64
+	// domDowOr is true if both day of month and day of week are restricted,
+	// and either of them should match (as in the classic cron).
+	domDowOr bool
+}
+
+var _ Schedule = (*Cron)(nil)
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+var cronFields = [...]struct {
+	Name     string
+	Min, Max int
+	Names    []string
+}{
+	{Name: "second", Max: 59},
+	{Name: "minute", Max: 59},
+	{Name: "hour", Max: 23},
+	{Name: "day of month", Min: 1, Max: 31},
+	{Name: "month", Min: 1, Max: 12, Names: []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	// 7 is Sunday, too
+	{Name: "day of week", Max: 7, Names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// ParseCron parses a cron specification:
+//
+//	[TZ=zone ]second minute hour day-of-month month day-of-week
+//
+// The second field may be omitted (5 fields), then it is 0.
+// The fields may contain *, ?, lists (1,3), ranges (1-5), steps (*/10 or 10-30/5),
+// and the month and day-of-week fields English names (JAN, MON).
+// If both the day of month and the day of week are restricted, either of them should match.
+//
+// The TZ= (or CRON_TZ=) prefix sets the time zone.
+// @yearly, @monthly, @weekly, @daily, @hourly and "@every <duration>" are accepted, too.
+func ParseCron(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	var loc *time.Location
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		tz, rest, _ := strings.Cut(spec, " ")
+		_, tz, _ = strings.Cut(tz, "=")
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("%q: %w", spec, err)
+		}
+		spec = strings.TrimSpace(rest)
+	}
+	if d, ok := strings.CutPrefix(spec, "@every "); ok {
+		dur, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", spec, err)
+		}
+		if dur <= 0 {
+			return nil, fmt.Errorf("%q: duration must be positive", spec)
+		}
+		return Every(dur), nil
+	}
+	if s, ok := cronDescriptors[strings.ToLower(spec)]; ok {
+		spec = s
+	}
+	fields := strings.Fields(spec)
+	if len(fields) == 5 {
+		fields = append([]string{"0"}, fields...)
+	}
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("%q: wanted 5 or 6 fields, got %d", spec, len(fields))
+	}
+	c := Cron{Location: loc}
+	for i, dst := range []*uint64{&c.second, &c.minute, &c.hour, &c.dom, &c.month, &c.dow} {
+		var err error
+		if *dst, err = parseCronField(fields[i], i); err != nil {
+			return nil, fmt.Errorf("%q: %s: %w", spec, cronFields[i].Name, err)
+		}
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domDowOr = !isStar(fields[3]) && !isStar(fields[5])
+	return &c, nil
+}
+
+func isStar(s string) bool { return s == "*" || s == "?" }
+
+// parseCronField returns the bit set of the matching values.
+func parseCronField(s string, idx int) (uint64, error) {
+	f := cronFields[idx]
+	var bits uint64
+	for part := range strings.SplitSeq(s, ",") {
+		rng, stepS, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepS); err != nil || step <= 0 {
+				return 0, fmt.Errorf("bad step %q", stepS)
+			}
+		}
+		lo, hi := f.Min, f.Max
+		if !isStar(rng) {
+			loS, hiS, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = parseCronValue(loS, f.Names); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseCronValue(hiS, f.Names); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.Max
+			}
+		}
+		if lo < f.Min || hi > f.Max || lo > hi {
+			return 0, fmt.Errorf("%q is out of range [%d, %d]", part, f.Min, f.Max)
+		}
+		for i := lo; i <= hi; i += step {
+			bits |= 1 << i
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(s string, names []string) (int, error) {
+	for i, nm := range names {
+		if nm != "" && strings.EqualFold(s, nm) {
+			return i, nil
+		}
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad value %q", s)
+	}
+	return i, nil
+}
+
+// Next returns the first activation time after t, in t's location;
+// the zero time if there is none in the next 5 years (e.g. for Feb 30).
+//
+// Times skipped by a daylight saving transition are not activated,
+// and times repeated by it may be activated twice.
+func (c *Cron) Next(t time.Time) time.Time {
+	origLoc, loc := t.Location(), t.Location()
+	if c.Location != nil {
+		loc = c.Location
+	}
+	t = t.In(loc).Truncate(time.Second).Add(time.Second)
+	yearLimit := t.Year() + 5
+	// truncated is true when the smaller units are already reset to their minimum.
+	truncated := false
+	truncate := func(y int, mo time.Month, d, h, mi int) {
+		if !truncated {
+			truncated = true
+			t = time.Date(y, mo, d, h, mi, 0, 0, loc)
+		}
+	}
+Wrap:
+	for t.Year() <= yearLimit {
+		for c.month&(1<<uint(t.Month())) == 0 {
+			truncate(t.Year(), t.Month(), 1, 0, 0)
+			if t = t.AddDate(0, 1, 0); t.Month() == time.January {
+				continue Wrap
+			}
+		}
+		for !c.dayMatches(t) {
+			truncate(t.Year(), t.Month(), t.Day(), 0, 0)
+			// AddDate keeps the wall clock, but the midnight may not exist (DST)
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			if t.Day() == 1 {
+				continue Wrap
+			}
+		}
+		for c.hour&(1<<uint(t.Hour())) == 0 {
+			truncate(t.Year(), t.Month(), t.Day(), t.Hour(), 0)
+			if t = t.Add(time.Hour); t.Hour() == 0 {
+				continue Wrap
+			}
+		}
+		for c.minute&(1<<uint(t.Minute())) == 0 {
+			truncate(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute())
+			if t = t.Add(time.Minute); t.Minute() == 0 {
+				continue Wrap
+			}
+		}
+		for c.second&(1<<uint(t.Second())) == 0 {
+			truncated = true
+			if t = t.Add(time.Second); t.Second() == 0 {
+				continue Wrap
+			}
+		}
+		return t.In(origLoc)
+	}
+	return time.Time{}
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domDowOr {
+		return dom || dow
+	}
+	return dom && dow
+}
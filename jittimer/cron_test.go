@@ -0,0 +1,92 @@
+/*
+Copyright 2026 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jittimer
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestCron(t *testing.T) {
+	budapest, err := time.LoadLocation("Europe/Budapest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(s string) time.Time {
+		t.Helper()
+		tm, err := time.Parse(time.DateTime, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	for _, tC := range []struct {
+		Spec      string
+		From      time.Time
+		Want      []string
+		WantInUTC bool
+	}{
+		// 2026-10-17 is a Saturday
+		{Spec: "0 30 9 * * MON-FRI", From: utc("2026-10-17 10:00:00"), Want: []string{"2026-10-19 09:30:00", "2026-10-20 09:30:00"}},
+		{Spec: "30 9 * * 1-5", From: utc("2026-10-19 09:30:00"), Want: []string{"2026-10-20 09:30:00"}},
+		{Spec: "*/20 * * * * *", From: utc("2026-12-31 23:59:30"), Want: []string{"2026-12-31 23:59:40", "2027-01-01 00:00:00", "2027-01-01 00:00:20"}},
+		{Spec: "0 0 0 29 feb ?", From: utc("2026-01-01 00:00:00"), Want: []string{"2028-02-29 00:00:00", "2032-02-29 00:00:00"}},
+		{Spec: "0 0 0 31 2 *", From: utc("2026-01-01 00:00:00"), Want: []string{"0001-01-01 00:00:00"}},
+		// Friday 13th or any 13th or any Friday
+		{Spec: "0 0 12 13 * FRI", From: utc("2026-11-01 00:00:00"), Want: []string{"2026-11-06 12:00:00", "2026-11-13 12:00:00", "2026-11-20 12:00:00", "2026-11-27 12:00:00", "2026-12-04 12:00:00"}},
+		{Spec: "0 0 12 * * 7", From: utc("2026-11-01 00:00:00"), Want: []string{"2026-11-01 12:00:00", "2026-11-08 12:00:00"}},
+		{Spec: "5,10-12 0 0 1 1 *", From: utc("2026-06-01 00:00:00"), Want: []string{"2027-01-01 00:00:05", "2027-01-01 00:00:10", "2027-01-01 00:00:11", "2027-01-01 00:00:12"}},
+		{Spec: "@monthly", From: utc("2026-01-31 10:00:00"), Want: []string{"2026-02-01 00:00:00", "2026-03-01 00:00:00"}},
+		{Spec: "@every 90s", From: utc("2026-01-31 10:00:00"), Want: []string{"2026-01-31 10:01:30", "2026-01-31 10:03:00"}},
+		// 02:30 does not exist on 2026-03-29 in Budapest
+		{Spec: "TZ=Europe/Budapest 0 30 2 * * *", From: utc("2026-03-28 00:00:00"), Want: []string{"2026-03-28 01:30:00", "2026-03-30 00:30:00"}, WantInUTC: true},
+		{Spec: "CRON_TZ=Europe/Budapest 0 0 9 * * *", From: utc("2026-10-24 12:00:00"), Want: []string{"2026-10-25 08:00:00", "2026-10-26 08:00:00"}, WantInUTC: true},
+		// without TZ, the location of the given time is used
+		{Spec: "0 0 9 * * *", From: utc("2026-10-24 12:00:00").In(budapest), Want: []string{"2026-10-25 08:00:00", "2026-10-26 08:00:00"}, WantInUTC: true},
+	} {
+		sched, err := ParseCron(tC.Spec)
+		if err != nil {
+			t.Errorf("%q: %+v", tC.Spec, err)
+			continue
+		}
+		tm := tC.From
+		for i, want := range tC.Want {
+			tm = sched.Next(tm)
+			got := tm
+			if tC.WantInUTC {
+				got = got.UTC()
+			}
+			if s := got.Format(time.DateTime); s != want {
+				t.Errorf("%q #%d: got %s, wanted %s", tC.Spec, i, s, want)
+				break
+			}
+			if tm.Location() != tC.From.Location() {
+				t.Errorf("%q #%d: got location %v, wanted %v", tC.Spec, i, tm.Location(), tC.From.Location())
+			}
+		}
+	}
+
+	for _, spec := range []string{
+		"", "* * * *", "* * * * * * *", "60 * * * * *", "* * 24 * * *", "* * * 0 * *",
+		"* * * * 13 *", "* * * * * 8", "*/0 * * * * *", "5-1 * * * * *", "x * * * * *",
+		"TZ=Nowhere/City * * * * *", "@every -1s", "@every x",
+	} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("%q: no error", spec)
+		}
+	}
+}
@@ -14,7 +14,11 @@
   limitations under the License.
 */
 
-// Package jittimer provides a ticker, a chan that sends time on a jittered interval.
+// Package jittimer provides a ticker, a chan that sends time on a jittered interval,
+// a Scheduler for Every and cron Schedules with jitter and missed tick policies,
+// and exponential Backoff for retry loops.
+//
+// The Scheduler and Backoff use a Clock, which can be a FakeClock for deterministic tests.
 package jittimer
 
 import (
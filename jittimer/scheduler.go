@@ -0,0 +1,266 @@
+/*
+Copyright 2026 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jittimer
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// MissedPolicy decides what happens with the ticks which are due
+// while the previous one is not received yet, or when the clock jumps over several ticks.
+type MissedPolicy uint8
+
+const (
+	// Skip drops the missed ticks, just as time.Ticker:
+	// the pending tick is kept, and the next one is the first in the future.
+	Skip = MissedPolicy(iota)
+	// CatchUp delivers all the missed ticks (up to Options.MaxCatchUp), one after the other.
+	CatchUp
+	// Coalesce merges the missed ticks into the pending one,
+	// which is updated to the latest scheduled time.
+	Coalesce
+)
+
+// Tick is sent by the Scheduler.
+type Tick struct {
+	// Scheduled is the time the tick was scheduled for, without jitter.
+	Scheduled time.Time
+	// Fired is the time the tick was fired (Scheduled + jitter, or later).
+	Fired time.Time
+	// Missed is the number of ticks skipped or coalesced before this one was received.
+	//
+	// After a gap longer than MaxCatchUp activations of a Schedule other than Every
+	// (a suspend, or a clock jump), the activations in the gap are counted as one.
+	Missed int
+}
+
+// Options of the Scheduler.
+type Options struct {
+	// Clock is the source of time; nil means RealClock.
+	Clock Clock
+	// Rand is the random source of the jitter; nil means the global one.
+	Rand *rand.Rand
+	// Spread is the maximal delay added to the scheduled times by Jitter.
+	Spread time.Duration
+	// Jitter is the strategy of choosing the delay from [0, Spread].
+	// DecorrelatedJitter uses Spread/10 as base.
+	Jitter Jitter
+	// Missed is the policy for the missed ticks.
+	Missed MissedPolicy
+	// MaxCatchUp is the maximal number of pending ticks with CatchUp (default 100);
+	// the older ones are dropped, and counted in Missed.
+	MaxCatchUp int
+}
+
+// Scheduler sends Ticks on C according to a Schedule, with jitter.
+type Scheduler struct {
+	// C receives the ticks; it is closed when the Scheduler is stopped,
+	// or the Schedule has no more activations.
+	C <-chan Tick
+
+	reset     chan Schedule
+	resetDone chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	once      sync.Once
+}
+
+// NewScheduler starts a new Scheduler, which runs till Stop is called or the context is done.
+func NewScheduler(ctx context.Context, sched Schedule, opts Options) *Scheduler {
+	if opts.Clock == nil {
+		opts.Clock = RealClock
+	}
+	if opts.MaxCatchUp <= 0 {
+		opts.MaxCatchUp = 100
+	}
+	ch := make(chan Tick)
+	s := Scheduler{
+		C:         ch,
+		reset:     make(chan Schedule),
+		resetDone: make(chan struct{}),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	r := runner{Options: opts, ch: ch}
+	go func() {
+		defer close(s.done)
+		defer close(ch)
+		r.run(ctx, sched, s.reset, s.resetDone, s.stop)
+	}()
+	return &s
+}
+
+// Stop the Scheduler. Returns false if it has already been stopped.
+// C is closed after Stop returns.
+func (s *Scheduler) Stop() bool {
+	stopped := false
+	s.once.Do(func() { close(s.stop); stopped = true })
+	<-s.done
+	return stopped
+}
+
+// Reset restarts the Scheduler from now, dropping the pending ticks.
+// If sched is not nil, it replaces the Schedule.
+//
+// Returns false if the Scheduler is stopped.
+func (s *Scheduler) Reset(sched Schedule) bool {
+	select {
+	case s.reset <- sched:
+	case <-s.done:
+		return false
+	}
+	select {
+	case <-s.resetDone:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+type runner struct {
+	ch      chan<- Tick
+	timer   Timer
+	pending []Tick
+	sched   Schedule
+	// next is the next scheduled time, prevJitter is the jitter added to the previous one.
+	next       time.Time
+	prevJitter time.Duration
+	Options
+}
+
+func (r *runner) run(ctx context.Context, sched Schedule, reset <-chan Schedule, resetDone chan<- struct{}, stop <-chan struct{}) {
+	r.sched = sched
+	if !r.restart() {
+		return
+	}
+	defer r.timer.Stop()
+	for {
+		var out chan<- Tick
+		var head Tick
+		if len(r.pending) != 0 {
+			out, head = r.ch, r.pending[0]
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case sched := <-reset:
+			if sched != nil {
+				r.sched = sched
+			}
+			r.pending = r.pending[:0]
+			r.timer.Stop()
+			if !r.restart() {
+				return
+			}
+			resetDone <- struct{}{}
+		case out <- head:
+			r.pending = r.pending[1:]
+		case now := <-r.timer.C():
+			r.fire(now)
+		}
+		if r.next.IsZero() && len(r.pending) == 0 {
+			return
+		}
+	}
+}
+
+// restart schedules the next tick from now. Returns false if there is none.
+func (r *runner) restart() bool {
+	r.next = r.sched.Next(r.Clock.Now())
+	if r.next.IsZero() {
+		return false
+	}
+	r.schedule()
+	return true
+}
+
+// schedule sets the timer for r.next, with a new jitter.
+func (r *runner) schedule() {
+	var jitter time.Duration
+	if r.Jitter != NoJitter && r.Spread > 0 {
+		jitter = r.Jitter.Apply(r.Rand, r.Spread, r.Spread/10, r.prevJitter)
+		r.prevJitter = jitter
+	}
+	d := r.next.Add(jitter).Sub(r.Clock.Now())
+	if r.timer == nil {
+		r.timer = r.Clock.NewTimer(d)
+	} else {
+		r.timer.Reset(d)
+	}
+}
+
+// fire handles the due ticks, and schedules the next (if there is any).
+func (r *runner) fire(now time.Time) {
+	// the scheduled times which passed, at most MaxCatchUp
+	var due []time.Time
+	var dropped int
+	if d, ok := r.sched.(every); ok && d > 0 && !r.next.IsZero() {
+		// jump over the activations which would be dropped anyway
+		if n := int(now.Sub(r.next)/time.Duration(d)) + 1 - r.MaxCatchUp; n > 0 {
+			r.next = r.next.Add(time.Duration(n) * time.Duration(d))
+			dropped = n
+		}
+	}
+	for !r.next.IsZero() && !r.next.After(now) {
+		if len(due) == r.MaxCatchUp {
+			// a long gap (suspend, clock jump): continue from now,
+			// counting the skipped activations as one
+			dropped++
+			r.next = r.sched.Next(now)
+			break
+		}
+		due = append(due, r.next)
+		r.next = r.sched.Next(r.next)
+	}
+	if len(due) != 0 {
+		last := due[len(due)-1]
+		missed := dropped + len(due) - 1
+		switch r.Missed {
+		case CatchUp:
+			for _, t := range due {
+				r.pending = append(r.pending, Tick{Scheduled: t, Fired: now})
+			}
+			if n := len(r.pending) - r.MaxCatchUp; n > 0 {
+				for _, t := range r.pending[:n] {
+					dropped += 1 + t.Missed
+				}
+				r.pending = r.pending[n:]
+			}
+			r.pending[0].Missed += dropped
+		case Coalesce:
+			if len(r.pending) != 0 {
+				r.pending[0].Scheduled, r.pending[0].Fired = last, now
+				r.pending[0].Missed += missed + 1
+			} else {
+				r.pending = append(r.pending, Tick{Scheduled: last, Fired: now, Missed: missed})
+			}
+		default:
+			if len(r.pending) == 0 {
+				r.pending = append(r.pending, Tick{Scheduled: last, Fired: now, Missed: missed})
+			} else {
+				r.pending[0].Missed += missed + 1
+			}
+		}
+	}
+	if !r.next.IsZero() {
+		r.schedule()
+	}
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2026 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jittimer
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func receive(t *testing.T, s *Scheduler) Tick {
+	t.Helper()
+	select {
+	case tick, ok := <-s.C:
+		if !ok {
+			t.Fatal("C is closed")
+		}
+		return tick
+	case <-time.After(5 * time.Second):
+		t.Fatal("no tick")
+	}
+	return Tick{}
+}
+
+func checkTick(t *testing.T, tick Tick, scheduled time.Duration, missed int) {
+	t.Helper()
+	if want := start.Add(scheduled); !tick.Scheduled.Equal(want) || tick.Missed != missed {
+		t.Errorf("got %s missed %d, wanted %s missed %d", tick.Scheduled.Sub(start), tick.Missed, scheduled, missed)
+	}
+}
+
+// advance the clock, and wait until the scheduler has processed the fired timer.
+func advance(c *FakeClock, d time.Duration) {
+	c.Advance(d)
+	c.BlockUntil(1)
+}
+
+func TestSchedulerSkip(t *testing.T) {
+	c := NewFakeClock(start)
+	s := NewScheduler(t.Context(), Every(time.Minute), Options{Clock: c})
+	defer s.Stop()
+	c.BlockUntil(1)
+	advance(c, time.Minute)
+	checkTick(t, receive(t, s), time.Minute, 0)
+	// slow consumer: the pending tick is kept, the next ones are dropped and counted
+	advance(c, 3*time.Minute)
+	advance(c, time.Minute)
+	checkTick(t, receive(t, s), 2*time.Minute, 3)
+	advance(c, time.Minute)
+	checkTick(t, receive(t, s), 6*time.Minute, 0)
+}
+
+func TestSchedulerLongGap(t *testing.T) {
+	c := NewFakeClock(start)
+	s := NewScheduler(t.Context(), Every(10*time.Millisecond), Options{Clock: c})
+	defer s.Stop()
+	c.BlockUntil(1)
+	// a suspend: must not iterate over all the missed activations
+	advance(c, 24*time.Hour)
+	checkTick(t, receive(t, s), 10*time.Millisecond, 24*360_000-1)
+}
+
+func TestSchedulerCatchUp(t *testing.T) {
+	c := NewFakeClock(start)
+	s := NewScheduler(t.Context(), Every(time.Minute), Options{Clock: c, Missed: CatchUp, MaxCatchUp: 3})
+	defer s.Stop()
+	c.BlockUntil(1)
+	advance(c, 2*time.Minute)
+	advance(c, time.Minute)
+	for i := range 3 {
+		checkTick(t, receive(t, s), time.Duration(i+1)*time.Minute, 0)
+	}
+	// more than MaxCatchUp
+	advance(c, 5*time.Minute)
+	checkTick(t, receive(t, s), 6*time.Minute, 2)
+	checkTick(t, receive(t, s), 7*time.Minute, 0)
+	checkTick(t, receive(t, s), 8*time.Minute, 0)
+}
+
+func TestSchedulerCoalesce(t *testing.T) {
+	c := NewFakeClock(start)
+	s := NewScheduler(t.Context(), Every(time.Minute), Options{Clock: c, Missed: Coalesce})
+	defer s.Stop()
+	c.BlockUntil(1)
+	advance(c, time.Minute)
+	advance(c, 2*time.Minute)
+	advance(c, time.Minute)
+	tick := receive(t, s)
+	checkTick(t, tick, 4*time.Minute, 3)
+	if !tick.Fired.Equal(start.Add(4 * time.Minute)) {
+		t.Errorf("Fired: got %v", tick.Fired)
+	}
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	const spread = 30 * time.Second
+	for _, j := range []Jitter{FullJitter, EqualJitter, DecorrelatedJitter} {
+		t.Run(j.String(), func(t *testing.T) {
+			c := NewFakeClock(start)
+			s := NewScheduler(t.Context(), Every(time.Minute), Options{
+				Clock: c, Missed: CatchUp, Jitter: j, Spread: spread, Rand: rand.New(rand.NewPCG(1, 2)),
+			})
+			defer s.Stop()
+			c.BlockUntil(1)
+			c.Advance(spread)
+			seen := make(map[time.Duration]bool)
+			for i := range 20 {
+				advance(c, time.Minute)
+				tick := receive(t, s)
+				checkTick(t, tick, time.Duration(i+1)*time.Minute, 0)
+				d := tick.Fired.Sub(tick.Scheduled)
+				lo := time.Duration(0)
+				if j == EqualJitter {
+					lo = spread / 2
+				}
+				if d < lo || d > spread {
+					t.Errorf("%d: jitter %v is out of [%v, %v]", i, d, lo, spread)
+				}
+				seen[d] = true
+			}
+			if len(seen) < 15 {
+				t.Errorf("only %d distinct jitters", len(seen))
+			}
+		})
+	}
+}
+
+func TestSchedulerResetStop(t *testing.T) {
+	c := NewFakeClock(start)
+	s := NewScheduler(context.Background(), Every(time.Minute), Options{Clock: c})
+	c.BlockUntil(1)
+	advance(c, 90*time.Second)
+	// drops the pending tick, and restarts from now (1m30s)
+	if !s.Reset(Every(time.Hour)) {
+		t.Fatal("Reset: false")
+	}
+	c.BlockUntil(1)
+	advance(c, 59*time.Minute)
+	select {
+	case tick := <-s.C:
+		t.Fatalf("got tick %v", tick)
+	default:
+	}
+	advance(c, time.Minute)
+	checkTick(t, receive(t, s), time.Hour+90*time.Second, 0)
+
+	if !s.Stop() {
+		t.Error("first Stop: false")
+	}
+	if _, ok := <-s.C; ok {
+		t.Error("C is not closed")
+	}
+	if s.Stop() {
+		t.Error("second Stop: true")
+	}
+	if s.Reset(nil) {
+		t.Error("Reset after Stop: true")
+	}
+
+	// context
+	ctx, cancel := context.WithCancel(context.Background())
+	s = NewScheduler(ctx, Every(time.Minute), Options{Clock: c})
+	cancel()
+	if _, ok := <-s.C; ok {
+		t.Error("C is not closed after cancel")
+	}
+}
+
+type onceAt time.Time
+
+func (o onceAt) Next(t time.Time) time.Time {
+	if t.Before(time.Time(o)) {
+		return time.Time(o)
+	}
+	return time.Time{}
+}
+
+func TestSchedulerEnd(t *testing.T) {
+	c := NewFakeClock(start)
+	s := NewScheduler(t.Context(), onceAt(start.Add(time.Hour)), Options{Clock: c})
+	c.BlockUntil(1)
+	c.Advance(2 * time.Hour)
+	checkTick(t, receive(t, s), time.Hour, 0)
+	if _, ok := <-s.C; ok {
+		t.Error("C is not closed")
+	}
+	s.Stop()
+
+	// cron, with the fake clock
+	sched, err := ParseCron("0 0 9 * * MON-FRI")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = NewScheduler(t.Context(), sched, Options{Clock: c})
+	defer s.Stop()
+	c.BlockUntil(1)
+	advance(c, 24*time.Hour)
+	// 2026-01-01 02:00 + 24h is Friday 02:00; the tick was at Thursday 09:00
+	checkTick(t, receive(t, s), 9*time.Hour, 0)
+}
+
+func TestSchedulerLongGapCron(t *testing.T) {
+	sched, err := ParseCron("* * * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewFakeClock(start)
+	s := NewScheduler(t.Context(), sched, Options{Clock: c, Missed: CatchUp, MaxCatchUp: 3})
+	defer s.Stop()
+	c.BlockUntil(1)
+	advance(c, time.Second)
+	checkTick(t, receive(t, s), time.Second, 0)
+	// only MaxCatchUp activations are iterated, then it continues from now
+	advance(c, 24*time.Hour)
+	checkTick(t, receive(t, s), 3*time.Second, 2)
+	checkTick(t, receive(t, s), 4*time.Second, 0)
+	checkTick(t, receive(t, s), 5*time.Second, 0)
+	advance(c, time.Second)
+	checkTick(t, receive(t, s), 24*time.Hour+2*time.Second, 0)
+}
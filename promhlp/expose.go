@@ -0,0 +1,125 @@
+/*
+Copyright 2026 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package promhlp
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric is a metric family in the Prometheus text exposition format.
+type Metric struct {
+	// Name of the metric, Help is its description.
+	Name, Help string
+	// Type is "counter", "gauge" or "untyped".
+	Type    string
+	Samples []Sample
+}
+
+// Sample is one value of a Metric.
+type Sample struct {
+	// Labels are name, value pairs.
+	Labels []string
+	Value  float64
+}
+
+// Collector returns its current metrics.
+type Collector interface {
+	Collect() []Metric
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []Collector
+)
+
+// Register the Collector for Handler.
+func Register(c Collector) {
+	registryMu.Lock()
+	registry = append(registry, c)
+	registryMu.Unlock()
+}
+
+// Handler returns a http.Handler which serves the metrics of the registered Collectors.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registryMu.Lock()
+		cs := slices.Clone(registry)
+		registryMu.Unlock()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteText(w, cs...)
+	})
+}
+
+// WriteText writes the metrics of the collectors in the Prometheus text exposition format.
+//
+// Metrics with the same name (from different collectors) are merged into one family,
+// with the Help and Type of the first.
+func WriteText(w io.Writer, cs ...Collector) error {
+	var families []*Metric
+	byName := make(map[string]*Metric)
+	for _, c := range cs {
+		for _, m := range c.Collect() {
+			name := ClearName(m.Name, true, '_')
+			f := byName[name]
+			if f == nil {
+				f = &Metric{Name: name, Help: m.Help, Type: m.Type}
+				byName[name] = f
+				families = append(families, f)
+			}
+			f.Samples = append(f.Samples, m.Samples...)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, m := range families {
+		if m.Help != "" {
+			bw.WriteString("# HELP " + m.Name + " " + helpEscaper.Replace(m.Help) + "\n")
+		}
+		if m.Type != "" {
+			bw.WriteString("# TYPE " + m.Name + " " + m.Type + "\n")
+		}
+		for _, s := range m.Samples {
+			bw.WriteString(m.Name)
+			for i := 0; i+1 < len(s.Labels); i += 2 {
+				if i == 0 {
+					bw.WriteByte('{')
+				} else {
+					bw.WriteByte(',')
+				}
+				bw.WriteString(ClearName(s.Labels[i], false, '_') + `="` + labelEscaper.Replace(s.Labels[i+1]) + `"`)
+			}
+			if len(s.Labels) >= 2 {
+				bw.WriteByte('}')
+			}
+			bw.WriteByte(' ')
+			bw.WriteString(strconv.FormatFloat(s.Value, 'g', -1, 64))
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
@@ -21,7 +21,16 @@ import (
 	"os"
 )
 
-var errNoPunch = errors.New("punchHole not supported")
+var (
+	errNoPunch       = errors.New("punchHole not supported")
+	errNoPreallocate = errors.New("preallocate not supported")
+)
 
 // PunchHole punches a hole in f from offset to offset+size, if non-nil.
 var PunchHole func(file *os.File, offset, size int64) error
+
+// Preallocate reserves disk space for the file's first size bytes,
+// without changing the file's size.
+//
+// It is nil if not supported on the platform.
+var Preallocate func(file *os.File, size int64) error
@@ -31,6 +31,7 @@ const (
 
 func init() {
 	PunchHole = punchHoleLinux
+	Preallocate = preallocateLinux
 }
 
 // puncHoleLinux punches a hole into the given file starting at offset,
@@ -44,3 +45,11 @@ func punchHoleLinux(file *os.File, offset int64, size int64) error {
 	}
 	return err
 }
+
+func preallocateLinux(file *os.File, size int64) error {
+	err := syscall.Fallocate(int(file.Fd()), fallocFlKeepSize, 0, size)
+	if err == syscall.ENOSYS || err == syscall.EOPNOTSUPP {
+		return errNoPreallocate
+	}
+	return err
+}
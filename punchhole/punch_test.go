@@ -68,3 +68,26 @@ func TestPunch(t *testing.T) {
 		}
 	}
 }
+
+func TestPreallocate(t *testing.T) {
+	if Preallocate == nil {
+		t.Skip("No Preallocate implementation is available, skipping.")
+	}
+	file, err := os.CreateTemp("", "preallocate-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	if err = Preallocate(file, 1<<20); err != nil {
+		if err == errNoPreallocate {
+			t.Skip(err)
+		}
+		t.Fatal(err)
+	}
+	if fi, err := file.Stat(); err != nil {
+		t.Fatal(err)
+	} else if fi.Size() != 0 {
+		t.Errorf("size changed to %d", fi.Size())
+	}
+}
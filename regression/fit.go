@@ -0,0 +1,520 @@
+// Copyright 2026 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package regression
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+var (
+	// ErrLengthMismatch is returned when the x, y (and weight) slices differ in length.
+	ErrLengthMismatch = errors.New("x, y and w must have the same length")
+	// ErrNotEnoughData is returned when there are too few (distinct) points for the fit.
+	ErrNotEnoughData = errors.New("not enough data")
+	// ErrNoConsensus is returned by RANSAC when no model has enough inliers.
+	ErrNoConsensus = errors.New("no consensus")
+)
+
+// LineFit is a fitted line: y = Slope*x + Intercept, with its statistics.
+type LineFit struct {
+	Slope, Intercept float64
+	// SlopeStdErr and InterceptStdErr are the standard errors of the estimates.
+	SlopeStdErr, InterceptStdErr float64
+	// ResidualStdErr is the estimated standard deviation of the residuals.
+	ResidualStdErr float64
+	// R2 is the coefficient of determination.
+	R2 float64
+	// DF is the degrees of freedom of the residuals.
+	DF int
+
+	meanX, sxx, sumW float64
+}
+
+// At returns the fitted value at x.
+func (f LineFit) At(x float64) float64 { return f.Slope*x + f.Intercept }
+
+// SlopeInterval returns the confidence interval of the slope, on the given level (e.g. 0.95).
+func (f LineFit) SlopeInterval(level float64) (lo, hi float64) {
+	d := tCritical(level, f.DF) * f.SlopeStdErr
+	return f.Slope - d, f.Slope + d
+}
+
+// InterceptInterval returns the confidence interval of the intercept, on the given level (e.g. 0.95).
+func (f LineFit) InterceptInterval(level float64) (lo, hi float64) {
+	d := tCritical(level, f.DF) * f.InterceptStdErr
+	return f.Intercept - d, f.Intercept + d
+}
+
+// MeanInterval returns the confidence interval of the mean response at x.
+func (f LineFit) MeanInterval(x, level float64) (lo, hi float64) {
+	return f.interval(x, level, 0)
+}
+
+// PredictionInterval returns the interval which contains a new observation at x
+// (with unit weight) with the given probability.
+func (f LineFit) PredictionInterval(x, level float64) (lo, hi float64) {
+	return f.interval(x, level, 1)
+}
+
+func (f LineFit) interval(x, level, extra float64) (lo, hi float64) {
+	y := f.At(x)
+	if f.sumW == 0 || f.sxx == 0 {
+		return math.NaN(), math.NaN()
+	}
+	dx := x - f.meanX
+	d := tCritical(level, f.DF) * f.ResidualStdErr * math.Sqrt(extra+1/f.sumW+dx*dx/f.sxx)
+	return y - d, y + d
+}
+
+// LeastSquares fits a line with ordinary least squares.
+func LeastSquares(x, y []float64) (LineFit, error) {
+	return WeightedLeastSquares(x, y, nil)
+}
+
+// WeightedLeastSquares fits a line with weighted least squares,
+// w is the relative precision (inverse variance) of the points; nil means all 1.
+// Points with zero weight are ignored.
+//
+// The sums are computed around the weighted means, so large offsets in x or y don't lose precision.
+func WeightedLeastSquares(x, y, w []float64) (LineFit, error) {
+	if len(x) != len(y) || w != nil && len(w) != len(x) {
+		return LineFit{}, ErrLengthMismatch
+	}
+	f := LineFit{DF: -2}
+	var my float64
+	f.meanX, my, f.sumW = weightedMeans(x, y, w)
+	var sxy float64
+	for i := range x {
+		dx, dy := x[i]-f.meanX, y[i]-my
+		wi := weight(w, i)
+		if wi != 0 {
+			f.DF++
+		}
+		f.sxx += wi * dx * dx
+		sxy += wi * dx * dy
+	}
+	if f.DF < 0 || f.sxx == 0 {
+		return LineFit{}, fmt.Errorf("%w: %d points, with %d distinct x", ErrNotEnoughData, len(x), countDistinct(x))
+	}
+	f.Slope = sxy / f.sxx
+	f.Intercept = my - f.Slope*f.meanX
+	f.stats(x, y, w, my)
+	return f, nil
+}
+
+// TheilSen fits a line with the Theil-Sen estimator: the slope is the median of the slopes
+// of the point pairs with distinct x, the intercept is the median of y - slope*x.
+//
+// It tolerates up to ~29% outliers. The standard errors (and the intervals) are computed
+// as for least squares, so they are only approximate.
+func TheilSen(x, y []float64) (LineFit, error) {
+	if len(x) != len(y) {
+		return LineFit{}, ErrLengthMismatch
+	}
+	slopes := make([]float64, 0, len(x)*(len(x)-1)/2)
+	for i := range x {
+		for j := i + 1; j < len(x); j++ {
+			if x[i] != x[j] {
+				slopes = append(slopes, (y[j]-y[i])/(x[j]-x[i]))
+			}
+		}
+	}
+	if len(slopes) == 0 {
+		return LineFit{}, fmt.Errorf("%w: %d points, with %d distinct x", ErrNotEnoughData, len(x), countDistinct(x))
+	}
+	f := LineFit{DF: len(x) - 2, Slope: median(slopes)}
+	icepts := make([]float64, len(x))
+	for i := range x {
+		icepts[i] = y[i] - f.Slope*x[i]
+	}
+	f.Intercept = median(icepts)
+	return f.withStats(x, y, nil), nil
+}
+
+// RANSACOptions are the options of RANSAC.
+type RANSACOptions struct {
+	// Rand is the source of randomness; a fixed seeded one is used if nil, for reproducibility.
+	Rand *rand.Rand
+	// Threshold is the maximal absolute residual of an inlier.
+	// The default is 3 sigmas, estimated with the MAD of the residuals of the Theil-Sen fit.
+	Threshold float64
+	// Iterations is the number of random point pairs tried (default 100).
+	Iterations int
+	// MinInliers is the minimal number of inliers to accept a model (default: half of the points).
+	MinInliers int
+}
+
+// RANSAC fits a line with the RANdom SAmple Consensus algorithm: it fits lines to random point pairs,
+// and refits the one with the most inliers with least squares, on the inliers only
+// (so the statistics of the result are of the inliers).
+//
+// Returns the fitted line and which points are inliers.
+func RANSAC(x, y []float64, opts RANSACOptions) (LineFit, []bool, error) {
+	if len(x) != len(y) {
+		return LineFit{}, nil, ErrLengthMismatch
+	}
+	if countDistinct(x) < 2 {
+		return LineFit{}, nil, fmt.Errorf("%w: %d points, with %d distinct x", ErrNotEnoughData, len(x), countDistinct(x))
+	}
+	if opts.Rand == nil {
+		opts.Rand = rand.New(rand.NewPCG(uint64(len(x)), 0x5eed))
+	}
+	if opts.Iterations <= 0 {
+		opts.Iterations = 100
+	}
+	if opts.MinInliers <= 0 {
+		opts.MinInliers = (len(x) + 1) / 2
+	}
+	if opts.Threshold <= 0 {
+		ts, err := TheilSen(x, y)
+		if err != nil {
+			return LineFit{}, nil, err
+		}
+		opts.Threshold = 3 * MADScale * MAD(Residuals(x, y, ts.At))
+		if opts.Threshold == 0 {
+			// more than half of the points are on the line
+			opts.Threshold = 1e-9 * max(1, slices.Max(y), -slices.Min(y))
+		}
+	}
+
+	bestCount, bestSSE := 0, math.Inf(1)
+	var bestSlope, bestIntercept float64
+	for range opts.Iterations {
+		i, j := opts.Rand.IntN(len(x)), opts.Rand.IntN(len(x))
+		if x[i] == x[j] {
+			continue
+		}
+		slope := (y[j] - y[i]) / (x[j] - x[i])
+		intercept := y[i] - slope*x[i]
+		var count int
+		var sse float64
+		for k := range x {
+			if r := y[k] - (slope*x[k] + intercept); math.Abs(r) <= opts.Threshold {
+				count++
+				sse += r * r
+			}
+		}
+		if count > bestCount || count == bestCount && sse < bestSSE {
+			bestCount, bestSSE, bestSlope, bestIntercept = count, sse, slope, intercept
+		}
+	}
+	if bestCount < opts.MinInliers {
+		return LineFit{}, nil, fmt.Errorf("%w: best model has %d inliers, wanted %d", ErrNoConsensus, bestCount, opts.MinInliers)
+	}
+
+	inliers := make([]bool, len(x))
+	w := make([]float64, len(x))
+	for k := range x {
+		if math.Abs(y[k]-(bestSlope*x[k]+bestIntercept)) <= opts.Threshold {
+			inliers[k], w[k] = true, 1
+		}
+	}
+	f, err := WeightedLeastSquares(x, y, w)
+	return f, inliers, err
+}
+
+// withStats returns f with the statistics filled in.
+func (f LineFit) withStats(x, y, w []float64) LineFit {
+	var my float64
+	f.meanX, my, f.sumW = weightedMeans(x, y, w)
+	f.sxx = 0
+	for i := range x {
+		dx := x[i] - f.meanX
+		f.sxx += weight(w, i) * dx * dx
+	}
+	f.stats(x, y, w, my)
+	return f
+}
+
+// stats computes the residual statistics of f, which already has the Slope, Intercept and the sums.
+func (f *LineFit) stats(x, y, w []float64, my float64) {
+	var sse, sst float64
+	for i := range x {
+		wi := weight(w, i)
+		r, dy := y[i]-f.At(x[i]), y[i]-my
+		sse += wi * r * r
+		sst += wi * dy * dy
+	}
+	f.R2 = rSquared(sse, sst)
+	if f.DF > 0 && f.sxx != 0 {
+		f.ResidualStdErr = math.Sqrt(sse / float64(f.DF))
+		f.SlopeStdErr = f.ResidualStdErr / math.Sqrt(f.sxx)
+		f.InterceptStdErr = f.ResidualStdErr * math.Sqrt(1/f.sumW+f.meanX*f.meanX/f.sxx)
+	}
+}
+
+// Polynomial is a fitted polynomial in u = (x-Center)/Scale.
+type Polynomial struct {
+	// Coeffs are the coefficients of u, from the constant term upwards.
+	Coeffs []float64
+	// Center and Scale transform x to u, to keep the fit well conditioned.
+	Center, Scale float64
+	// ResidualStdErr is the estimated standard deviation of the residuals.
+	ResidualStdErr float64
+	// R2 is the coefficient of determination.
+	R2 float64
+	// DF is the degrees of freedom of the residuals.
+	DF int
+
+	// r is the R of the QR decomposition of the (weighted) design matrix, row major.
+	r    []float64
+	sumW float64
+}
+
+// At returns the value of the polynomial at x.
+func (p Polynomial) At(x float64) float64 {
+	u := p.u(x)
+	var y float64
+	for i := len(p.Coeffs) - 1; i >= 0; i-- {
+		y = y*u + p.Coeffs[i]
+	}
+	return y
+}
+
+func (p Polynomial) u(x float64) float64 {
+	if p.Scale == 0 {
+		return x - p.Center
+	}
+	return (x - p.Center) / p.Scale
+}
+
+// Monomial returns the coefficients of x (from the constant term upwards).
+//
+// This may lose much precision if Center is large compared to Scale.
+func (p Polynomial) Monomial() []float64 {
+	scale := p.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	// sum_k c_k ((x-C)/S)^k = sum_k c_k/S^k sum_j binom(k,j) x^j (-C)^(k-j)
+	res := make([]float64, len(p.Coeffs))
+	for k, c := range p.Coeffs {
+		c /= math.Pow(scale, float64(k))
+		binom := 1.0
+		for j := 0; j <= k; j++ {
+			res[j] += c * binom * math.Pow(-p.Center, float64(k-j))
+			binom = binom * float64(k-j) / float64(j+1)
+		}
+	}
+	return res
+}
+
+// MeanInterval returns the confidence interval of the mean response at x.
+func (p Polynomial) MeanInterval(x, level float64) (lo, hi float64) {
+	return p.interval(x, level, 0)
+}
+
+// PredictionInterval returns the interval which contains a new observation at x
+// (with unit weight) with the given probability.
+func (p Polynomial) PredictionInterval(x, level float64) (lo, hi float64) {
+	return p.interval(x, level, 1)
+}
+
+func (p Polynomial) interval(x, level, extra float64) (lo, hi float64) {
+	y := p.At(x)
+	m := len(p.Coeffs)
+	if len(p.r) != m*m {
+		return math.NaN(), math.NaN()
+	}
+	// Var(y) = s² vᵀ (RᵀR)⁻¹ v = s² |z|², where Rᵀz = v
+	z := make([]float64, m)
+	u, uk := p.u(x), 1.0
+	var zz float64
+	for i := range m {
+		s := uk
+		for k := range i {
+			s -= p.r[k*m+i] * z[k]
+		}
+		z[i] = s / p.r[i*m+i]
+		zz += z[i] * z[i]
+		uk *= u
+	}
+	d := tCritical(level, p.DF) * p.ResidualStdErr * math.Sqrt(extra+zz)
+	return y - d, y + d
+}
+
+// FitPolynomial fits a polynomial of the given degree with weighted least squares,
+// w is the relative precision (inverse variance) of the points; nil means all 1.
+//
+// x is centered and scaled, and the system is solved with QR decomposition,
+// to avoid the ill-conditioning of the normal equations.
+func FitPolynomial(x, y, w []float64, degree int) (Polynomial, error) {
+	if len(x) != len(y) || w != nil && len(w) != len(x) {
+		return Polynomial{}, ErrLengthMismatch
+	}
+	// the zero weight points don't count
+	weighted := x
+	if w != nil {
+		weighted = make([]float64, 0, len(x))
+		for i, v := range x {
+			if w[i] != 0 {
+				weighted = append(weighted, v)
+			}
+		}
+	}
+	m := degree + 1
+	if degree < 0 || countDistinct(weighted) < m {
+		return Polynomial{}, fmt.Errorf("%w: %d distinct x for degree %d", ErrNotEnoughData, countDistinct(weighted), degree)
+	}
+	p := Polynomial{DF: len(weighted) - m}
+	var my float64
+	p.Center, my, p.sumW = weightedMeans(x, y, w)
+	for _, v := range x {
+		p.Scale = max(p.Scale, math.Abs(v-p.Center))
+	}
+	if p.Scale == 0 {
+		p.Scale = 1
+	}
+
+	// a is the weighted Vandermonde matrix, b the weighted y, both row major
+	n := len(x)
+	a := make([]float64, n*m)
+	b := make([]float64, n)
+	for i := range n {
+		sw := math.Sqrt(weight(w, i))
+		u, uk := p.u(x[i]), sw
+		for k := range m {
+			a[i*m+k] = uk
+			uk *= u
+		}
+		b[i] = sw * y[i]
+	}
+
+	// Householder QR, applying the reflections to b, too
+	for k := range m {
+		var norm float64
+		for i := k; i < n; i++ {
+			norm = math.Hypot(norm, a[i*m+k])
+		}
+		if norm == 0 {
+			return Polynomial{}, fmt.Errorf("%w: singular design matrix", ErrNotEnoughData)
+		}
+		if a[k*m+k] > 0 {
+			norm = -norm
+		}
+		// v = a[k:,k] - norm*e_k, stored in place
+		a[k*m+k] -= norm
+		var vv float64
+		for i := k; i < n; i++ {
+			vv += a[i*m+k] * a[i*m+k]
+		}
+		reflect := func(get func(i int) float64, set func(i int, v float64)) {
+			var s float64
+			for i := k; i < n; i++ {
+				s += a[i*m+k] * get(i)
+			}
+			s = 2 * s / vv
+			for i := k; i < n; i++ {
+				set(i, get(i)-s*a[i*m+k])
+			}
+		}
+		for j := k + 1; j < m; j++ {
+			reflect(func(i int) float64 { return a[i*m+j] }, func(i int, v float64) { a[i*m+j] = v })
+		}
+		reflect(func(i int) float64 { return b[i] }, func(i int, v float64) { b[i] = v })
+		a[k*m+k] = norm
+	}
+
+	p.r = make([]float64, m*m)
+	for i := range m {
+		copy(p.r[i*m+i:(i+1)*m], a[i*m+i:(i+1)*m])
+	}
+	p.Coeffs = make([]float64, m)
+	for i := m - 1; i >= 0; i-- {
+		s := b[i]
+		for j := i + 1; j < m; j++ {
+			s -= p.r[i*m+j] * p.Coeffs[j]
+		}
+		p.Coeffs[i] = s / p.r[i*m+i]
+	}
+
+	var sse, sst float64
+	for i := range x {
+		wi := weight(w, i)
+		r, dy := y[i]-p.At(x[i]), y[i]-my
+		sse += wi * r * r
+		sst += wi * dy * dy
+	}
+	p.R2 = rSquared(sse, sst)
+	if p.DF > 0 {
+		p.ResidualStdErr = math.Sqrt(sse / float64(p.DF))
+	}
+	return p, nil
+}
+
+// Residuals returns y[i] - f(x[i]).
+func Residuals(x, y []float64, f func(float64) float64) []float64 {
+	res := make([]float64, len(x))
+	for i := range x {
+		res[i] = y[i] - f(x[i])
+	}
+	return res
+}
+
+// R2 returns the coefficient of determination of the fitted f: 1 - SSresidual/SStotal.
+func R2(x, y []float64, f func(float64) float64) float64 {
+	var w Welford
+	for _, v := range y {
+		w.Add(v)
+	}
+	var sse float64
+	for _, r := range Residuals(x, y, f) {
+		sse += r * r
+	}
+	return rSquared(sse, w.m2)
+}
+
+func rSquared(sse, sst float64) float64 {
+	if sst == 0 {
+		if sse == 0 {
+			return 1
+		}
+		return math.Inf(-1)
+	}
+	return 1 - sse/sst
+}
+
+func weight(w []float64, i int) float64 {
+	if w == nil {
+		return 1
+	}
+	return w[i]
+}
+
+// weightedMeans returns the weighted means of x and y, and the sum of weights.
+func weightedMeans(x, y, w []float64) (mx, my, sumW float64) {
+	for i := range x {
+		wi := weight(w, i)
+		if wi == 0 {
+			continue
+		}
+		// incremental, to avoid the loss of precision of summing large values
+		sumW += wi
+		mx += wi / sumW * (x[i] - mx)
+		my += wi / sumW * (y[i] - my)
+	}
+	return mx, my, sumW
+}
+
+func countDistinct(x []float64) int {
+	s := slices.Clone(x)
+	slices.Sort(s)
+	return len(slices.Compact(s))
+}
@@ -0,0 +1,271 @@
+// Copyright 2026 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package regression
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func near(got, want, tol float64) bool {
+	return math.Abs(got-want) <= tol*max(1, math.Abs(want))
+}
+
+func TestLeastSquares(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5, 6, 7, 8}
+	y := []float64{2.1, 3.9, 6.2, 7.8, 10.1, 12.2, 13.8, 16.1}
+	f, err := LeastSquares(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		Name      string
+		Got, Want float64
+	}{
+		{"slope", f.Slope, 1.9976190476190478},
+		{"intercept", f.Intercept, 0.03571428571428292},
+		{"slopeErr", f.SlopeStdErr, 0.027800444266884092},
+		{"interceptErr", f.InterceptStdErr, 0.1403853620810278},
+		{"residualErr", f.ResidualStdErr, 0.1801674705942152},
+		{"R2", f.R2, 0.9988392866011389},
+		{"R2()", R2(x, y, f.At), 0.9988392866011389},
+	} {
+		if !near(c.Got, c.Want, 1e-12) {
+			t.Errorf("%s: got %v, wanted %v", c.Name, c.Got, c.Want)
+		}
+	}
+	if lo, hi := f.SlopeInterval(0.95); !near(lo, 1.9976190476190478-2.446911851144969*0.027800444266884092, 1e-9) ||
+		!near(hi, 1.9976190476190478+2.446911851144969*0.027800444266884092, 1e-9) {
+		t.Errorf("SlopeInterval: got [%v, %v]", lo, hi)
+	}
+	mlo, mhi := f.MeanInterval(4.5, 0.95)
+	plo, phi := f.PredictionInterval(4.5, 0.95)
+	if !(plo < mlo && mlo < f.At(4.5) && f.At(4.5) < mhi && mhi < phi) {
+		t.Errorf("intervals: mean [%v, %v], prediction [%v, %v]", mlo, mhi, plo, phi)
+	}
+
+	// weights of 0 and 2 are the same as dropping and duplicating the points
+	w := []float64{1, 2, 1, 0, 1, 1, 2, 1}
+	var dx, dy []float64
+	for i := range x {
+		for range int(w[i]) {
+			dx, dy = append(dx, x[i]), append(dy, y[i])
+		}
+	}
+	wf, err := WeightedLeastSquares(x, y, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	df, err := LeastSquares(dx, dy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(wf.Slope, df.Slope, 1e-12) || !near(wf.Intercept, df.Intercept, 1e-12) || !near(wf.R2, df.R2, 1e-12) {
+		t.Errorf("weighted %+v, duplicated %+v", wf, df)
+	}
+
+	if _, err := LeastSquares([]float64{1, 1, 1}, []float64{1, 2, 3}); !errors.Is(err, ErrNotEnoughData) {
+		t.Errorf("same x: got %+v", err)
+	}
+	if _, err := WeightedLeastSquares(x, y, w[:2]); !errors.Is(err, ErrLengthMismatch) {
+		t.Errorf("short w: got %+v", err)
+	}
+}
+
+func TestLeastSquaresStability(t *testing.T) {
+	// A naive sum of x² would lose all the precision with such an offset.
+	const offset = 1e9
+	x := make([]float64, 100)
+	y := make([]float64, len(x))
+	for i := range x {
+		x[i] = offset + float64(i)
+		y[i] = 2*float64(i) + 3 + 0.01*float64(i%3-1)
+	}
+	f, err := LeastSquares(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(f.Slope, 2, 1e-4) || !near(f.At(offset), 3, 1e-4) || f.R2 < 0.9999 {
+		t.Errorf("got %+v", f)
+	}
+}
+
+func TestTheilSen(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	y := []float64{3, 5, 7, 9, 11, 13, 15, 17, 19, 21}
+	// outliers
+	y[2], y[7] = 100, -50
+	f, err := TheilSen(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(f.Slope, 2, 1e-12) || !near(f.Intercept, 1, 1e-12) {
+		t.Errorf("got %+v", f)
+	}
+	ls, _ := LeastSquares(x, y)
+	if near(ls.Slope, 2, 0.1) {
+		t.Errorf("least squares is not disturbed by the outliers: %+v", ls)
+	}
+
+	// LinearRegression estimates the same line
+	if a, b := LinearRegression(x, y); !near(a, 2, 1e-12) || !near(b, 1, 1e-12) {
+		t.Errorf("LinearRegression: got %v, %v", a, b)
+	}
+}
+
+func TestRANSAC(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	var x, y []float64
+	var outliers []bool
+	for i := range 200 {
+		x = append(x, float64(i))
+		if i%4 == 0 {
+			y = append(y, rnd.Float64()*1000)
+			outliers = append(outliers, true)
+		} else {
+			y = append(y, 0.5*float64(i)-7+rnd.NormFloat64()*0.1)
+			outliers = append(outliers, false)
+		}
+	}
+	f, inliers, err := RANSAC(x, y, RANSACOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(f.Slope, 0.5, 1e-3) || !near(f.Intercept, -7, 1e-2) {
+		t.Errorf("got %+v", f)
+	}
+	var wrong int
+	for i := range inliers {
+		if inliers[i] == outliers[i] {
+			wrong++
+		}
+	}
+	// some random outlier may fall close to the line
+	if wrong > 3 {
+		t.Errorf("%d points are classified wrong", wrong)
+	}
+	if lo, hi := f.SlopeInterval(0.99); !(lo < 0.5 && 0.5 < hi) {
+		t.Errorf("slope interval [%v, %v]", lo, hi)
+	}
+
+	if _, _, err := RANSAC(x, y, RANSACOptions{Threshold: 1e-6, MinInliers: 100}); !errors.Is(err, ErrNoConsensus) {
+		t.Errorf("small threshold: got %+v", err)
+	}
+}
+
+func TestFitPolynomial(t *testing.T) {
+	// a quadratic, at a large offset
+	const offset = 1e6
+	x := make([]float64, 50)
+	y := make([]float64, len(x))
+	for i := range x {
+		u := float64(i) - 25
+		x[i] = offset + u
+		y[i] = 3 - 2*u + 0.5*u*u
+	}
+	p, err := FitPolynomial(x, y, nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []float64{offset - 30, offset, offset + 7.5, offset + 100} {
+		u := v - offset
+		if got, want := p.At(v), 3-2*u+0.5*u*u; !near(got, want, 1e-9) {
+			t.Errorf("At(%v): got %v, wanted %v", v, got, want)
+		}
+	}
+	if p.R2 != 1 && !near(p.R2, 1, 1e-12) {
+		t.Errorf("R2: got %v", p.R2)
+	}
+	if _, err := FitPolynomial(x[:2], y[:2], nil, 2); !errors.Is(err, ErrNotEnoughData) {
+		t.Errorf("2 points for degree 2: got %+v", err)
+	}
+
+	// zero weight points don't count in the degrees of freedom
+	xn, yn := []float64{0, 1, 2, 3, 4, 5}, []float64{1, 2.1, 4.9, 10.2, 16.8, 26.1}
+	pn, err := FitPolynomial(xn, yn, nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pz, err := FitPolynomial(append(xn, 6, 7), append(yn, 100, -100), []float64{1, 1, 1, 1, 1, 1, 0, 0}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pz.DF != pn.DF || pz.DF != 3 {
+		t.Errorf("DF: got %d, wanted %d", pz.DF, pn.DF)
+	}
+	if !near(pz.ResidualStdErr, pn.ResidualStdErr, 1e-9) {
+		t.Errorf("ResidualStdErr: got %v, wanted %v", pz.ResidualStdErr, pn.ResidualStdErr)
+	}
+	if _, err := FitPolynomial([]float64{0, 1, 2}, []float64{1, 2, 3}, []float64{1, 1, 0}, 2); !errors.Is(err, ErrNotEnoughData) {
+		t.Errorf("2 weighted points for degree 2: got %+v", err)
+	}
+
+	// the monomial coefficients are fine with small offsets
+	q, err := FitPolynomial([]float64{-1, 0, 1, 2}, []float64{-3, 1, 3, 15}, nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []float64{1, 1, -1, 2} { // 1 + x - x² + 2x³
+		if got := q.Monomial()[i]; !near(got, want, 1e-12) {
+			t.Errorf("monomial %d: got %v, wanted %v", i, got, want)
+		}
+	}
+
+	// degree 1 is the same as the least squares line
+	lx := []float64{1, 2, 3, 4, 5, 6, 7, 8}
+	ly := []float64{2.1, 3.9, 6.2, 7.8, 10.1, 12.2, 13.8, 16.1}
+	w := []float64{1, 2, 1, 3, 1, 1, 2, 1}
+	l, _ := WeightedLeastSquares(lx, ly, w)
+	lp, err := FitPolynomial(lx, ly, w, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(lp.R2, l.R2, 1e-12) || !near(lp.ResidualStdErr, l.ResidualStdErr, 1e-12) {
+		t.Errorf("polynomial %+v, line %+v", lp, l)
+	}
+	for _, v := range []float64{0, 4.5, 10} {
+		llo, lhi := l.MeanInterval(v, 0.9)
+		plo, phi := lp.MeanInterval(v, 0.9)
+		if !near(llo, plo, 1e-9) || !near(lhi, phi, 1e-9) {
+			t.Errorf("MeanInterval(%v): line [%v, %v], polynomial [%v, %v]", v, llo, lhi, plo, phi)
+		}
+		llo, lhi = l.PredictionInterval(v, 0.9)
+		plo, phi = lp.PredictionInterval(v, 0.9)
+		if !near(llo, plo, 1e-9) || !near(lhi, phi, 1e-9) {
+			t.Errorf("PredictionInterval(%v): line [%v, %v], polynomial [%v, %v]", v, llo, lhi, plo, phi)
+		}
+	}
+}
+
+func TestTCritical(t *testing.T) {
+	for _, c := range []struct {
+		Level float64
+		DF    int
+		Want  float64
+	}{
+		{0.95, 1, 12.706204736174698},
+		{0.95, 6, 2.446911851144969},
+		{0.95, 10, 2.2281388519649385},
+		{0.99, 10, 3.169272672616954},
+		{0.95, 1000, 1.9623390808264078},
+	} {
+		if got := tCritical(c.Level, c.DF); !near(got, c.Want, 1e-9) {
+			t.Errorf("%v/%d: got %v, wanted %v", c.Level, c.DF, got, c.Want)
+		}
+	}
+}
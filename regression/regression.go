@@ -13,7 +13,8 @@
 //    See the License for the specific language governing permissions and
 //    limitations under the License.
 
-// Package regression contains a simple Thiel-Sen estimator for linear regression.
+// Package regression contains line and polynomial fitting (least squares, Theil-Sen, RANSAC)
+// and robust and streaming statistics (quantiles, MAD, Welford, EWMA).
 package regression
 
 import (
@@ -0,0 +1,170 @@
+// Copyright 2026 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package regression
+
+import (
+	"math"
+	"slices"
+)
+
+// MADScale is the factor of MAD for estimating the standard deviation of normally distributed data.
+const MADScale = 1.482602218505602
+
+// Quantile returns the q-quantile (0 <= q <= 1) of x, interpolating linearly
+// between the closest ranks (type 7 of Hyndman and Fan, the default of R and NumPy).
+// x is not modified. Returns NaN for empty x.
+func Quantile(x []float64, q float64) float64 {
+	return Quantiles(x, q)[0]
+}
+
+// Quantiles returns the quantiles of x for each q, sorting (a copy of) x only once.
+func Quantiles(x []float64, qs ...float64) []float64 {
+	s := slices.Clone(x)
+	slices.Sort(s)
+	res := make([]float64, len(qs))
+	for i, q := range qs {
+		res[i] = sortedQuantile(s, q)
+	}
+	return res
+}
+
+func sortedQuantile(s []float64, q float64) float64 {
+	if len(s) == 0 || !(q >= 0 && q <= 1) {
+		return math.NaN()
+	}
+	h := q * float64(len(s)-1)
+	i := int(h)
+	if i >= len(s)-1 {
+		return s[len(s)-1]
+	}
+	// a + t*(b-a) is exact at the ends and monotone, unlike (1-t)*a + t*b
+	return s[i] + (h-float64(i))*(s[i+1]-s[i])
+}
+
+// median returns the median of x, sorting x in place.
+func median(x []float64) float64 {
+	slices.Sort(x)
+	return sortedQuantile(x, 0.5)
+}
+
+// MAD returns the median absolute deviation of x: the median of |x[i] - median(x)|.
+// Multiply it by MADScale to estimate the standard deviation.
+func MAD(x []float64) float64 {
+	if len(x) == 0 {
+		return math.NaN()
+	}
+	s := slices.Clone(x)
+	m := median(s)
+	for i, v := range s {
+		s[i] = math.Abs(v - m)
+	}
+	return median(s)
+}
+
+// Welford computes the running mean and variance in one pass,
+// with Welford's numerically stable algorithm.
+//
+// The zero value is ready to use.
+type Welford struct {
+	n        int
+	mean, m2 float64
+}
+
+// Add the value.
+func (w *Welford) Add(x float64) {
+	w.n++
+	d := x - w.mean
+	w.mean += d / float64(w.n)
+	w.m2 += d * (x - w.mean)
+}
+
+// Merge the other (e.g. computed in parallel) into w, with Chan's method.
+func (w *Welford) Merge(other Welford) {
+	if other.n == 0 {
+		return
+	}
+	n := w.n + other.n
+	d := other.mean - w.mean
+	w.mean += d * float64(other.n) / float64(n)
+	w.m2 += other.m2 + d*d*float64(w.n)*float64(other.n)/float64(n)
+	w.n = n
+}
+
+// Count returns the number of values added.
+func (w Welford) Count() int { return w.n }
+
+// Mean returns the mean of the values.
+func (w Welford) Mean() float64 { return w.mean }
+
+// Variance returns the sample variance (divided by n-1); 0 for less than 2 values.
+func (w Welford) Variance() float64 {
+	if w.n < 2 {
+		return 0
+	}
+	return w.m2 / float64(w.n-1)
+}
+
+// PopulationVariance returns the population variance (divided by n).
+func (w Welford) PopulationVariance() float64 {
+	if w.n == 0 {
+		return 0
+	}
+	return w.m2 / float64(w.n)
+}
+
+// StdDev returns the sample standard deviation.
+func (w Welford) StdDev() float64 { return math.Sqrt(w.Variance()) }
+
+// EWMA is an exponentially weighted moving average and variance.
+//
+// Set Alpha (the weight of the new value, 0 < Alpha <= 1) before use, or use NewEWMA.
+type EWMA struct {
+	Alpha          float64
+	mean, variance float64
+	n              int
+}
+
+// NewEWMA returns an EWMA where the weight of a value halves after halfLife newer values.
+func NewEWMA(halfLife float64) *EWMA {
+	return &EWMA{Alpha: -math.Expm1(-math.Ln2 / halfLife)}
+}
+
+// Add the value, and return the new mean.
+// The first value initializes the mean.
+func (e *EWMA) Add(x float64) float64 {
+	e.n++
+	if e.n == 1 {
+		e.mean = x
+		return e.mean
+	}
+	d := x - e.mean
+	incr := e.Alpha * d
+	e.mean += incr
+	e.variance = (1 - e.Alpha) * (e.variance + d*incr)
+	return e.mean
+}
+
+// Count returns the number of values added.
+func (e *EWMA) Count() int { return e.n }
+
+// Mean returns the current average.
+func (e *EWMA) Mean() float64 { return e.mean }
+
+// Variance returns the exponentially weighted variance.
+func (e *EWMA) Variance() float64 { return e.variance }
+
+// StdDev returns the exponentially weighted standard deviation.
+func (e *EWMA) StdDev() float64 { return math.Sqrt(e.variance) }
@@ -0,0 +1,136 @@
+// Copyright 2026 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package regression
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestQuantiles(t *testing.T) {
+	x := []float64{3, 1, 4, 1, 5, 9, 2, 6}
+	orig := slices.Clone(x)
+	got := Quantiles(x, 0, 0.1, 0.25, 0.5, 0.9, 1)
+	if want := []float64{1, 1, 1.75, 3.5, 6.9, 9}; !slices.EqualFunc(got, want, func(a, b float64) bool { return near(a, b, 1e-12) }) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+	if !slices.Equal(x, orig) {
+		t.Error("x is modified")
+	}
+	if got := Quantile([]float64{7}, 0.3); got != 7 {
+		t.Errorf("single: got %v", got)
+	}
+	if got := Quantile(nil, 0.5); !math.IsNaN(got) {
+		t.Errorf("empty: got %v", got)
+	}
+	if got := Quantile(x, 1.5); !math.IsNaN(got) {
+		t.Errorf("q=1.5: got %v", got)
+	}
+	// no precision loss with large, close values
+	if got := Quantile([]float64{1e15 + 1, 1e15 + 2}, 0.5); got != 1e15+1.5 {
+		t.Errorf("large: got %v", got)
+	}
+}
+
+func TestMAD(t *testing.T) {
+	x := []float64{1, 1, 2, 2, 4, 6, 9}
+	if got := MAD(x); got != 1 {
+		t.Errorf("got %v, wanted 1", got)
+	}
+	// robust to outliers
+	if got := MAD(append(x, 1e12)); got != 2 {
+		t.Errorf("with outlier: got %v, wanted 2", got)
+	}
+}
+
+func TestWelford(t *testing.T) {
+	// The naive sum of squares would lose all the precision with such an offset:
+	// (1e9+4)² and the others differ only in the last few bits.
+	const offset = 1e9
+	var w Welford
+	var a, b Welford
+	for i, v := range []float64{4, 7, 13, 16} {
+		w.Add(offset + v)
+		if i%2 == 0 {
+			a.Add(offset + v)
+		} else {
+			b.Add(offset + v)
+		}
+	}
+	if w.Count() != 4 || w.Mean() != offset+10 || w.Variance() != 30 || w.PopulationVariance() != 22.5 {
+		t.Errorf("got n=%d mean=%v var=%v pvar=%v", w.Count(), w.Mean(), w.Variance(), w.PopulationVariance())
+	}
+	a.Merge(b)
+	if a.Count() != 4 || !near(a.Mean(), w.Mean(), 1e-15) || !near(a.Variance(), 30, 1e-12) {
+		t.Errorf("merged: got n=%d mean=%v var=%v", a.Count(), a.Mean(), a.Variance())
+	}
+
+	var naive struct{ sum, sum2 float64 }
+	var c Welford
+	for i := range 1_000_000 {
+		v := offset + float64(i%10)/10
+		c.Add(v)
+		naive.sum += v
+		naive.sum2 += v * v
+	}
+	const want = 0.0825000825000825 // variance of 0, 0.1, ..., 0.9 with n-1
+	if got := c.Variance(); !near(got, want, 1e-6) {
+		t.Errorf("1e6 values: got %v, wanted %v", got, want)
+	}
+	n := float64(c.Count())
+	t.Logf("Welford: %v, naive: %v", c.Variance(), (naive.sum2-naive.sum*naive.sum/n)/(n-1))
+
+	var z Welford
+	if z.Mean() != 0 || z.Variance() != 0 || z.StdDev() != 0 {
+		t.Errorf("zero value: %+v", z)
+	}
+}
+
+func TestEWMA(t *testing.T) {
+	e := NewEWMA(1)
+	if e.Alpha != 0.5 {
+		t.Errorf("Alpha for half life 1: got %v", e.Alpha)
+	}
+	for _, v := range []float64{10, 20, 20} {
+		e.Add(v)
+	}
+	if e.Mean() != 17.5 || e.Count() != 3 {
+		t.Errorf("got mean=%v n=%d", e.Mean(), e.Count())
+	}
+	if got := e.Variance(); got != 18.75 {
+		t.Errorf("variance: got %v, wanted 18.75", got)
+	}
+
+	// a constant, large input gives exactly the constant and zero variance
+	c := NewEWMA(10)
+	for range 1000 {
+		c.Add(1e15 + 0.5)
+	}
+	if c.Mean() != 1e15+0.5 || c.Variance() != 0 {
+		t.Errorf("constant: got mean=%v variance=%v", c.Mean(), c.Variance())
+	}
+
+	// a step is followed with the given half life
+	s := NewEWMA(20)
+	s.Add(0)
+	for range 20 {
+		s.Add(1)
+	}
+	if !near(s.Mean(), 0.5, 1e-12) {
+		t.Errorf("step after half life: got %v", s.Mean())
+	}
+}
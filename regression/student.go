@@ -0,0 +1,99 @@
+// Copyright 2026 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package regression
+
+import "math"
+
+// tCritical returns the two-sided critical value of Student's t distribution
+// with df degrees of freedom, for the confidence level (e.g. 0.95).
+func tCritical(level float64, df int) float64 {
+	if df <= 0 || !(level > 0 && level < 1) {
+		return math.NaN()
+	}
+	p := (1 + level) / 2
+	lo, hi := 0.0, 1.0
+	for tCDF(hi, df) < p {
+		lo, hi = hi, 2*hi
+	}
+	for range 100 {
+		mid := lo + (hi-lo)/2
+		if mid == lo || mid == hi {
+			break
+		}
+		if tCDF(mid, df) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi
+}
+
+// tCDF returns the cumulative distribution function of Student's t distribution at t >= 0.
+func tCDF(t float64, df int) float64 {
+	nu := float64(df)
+	return 1 - 0.5*betaInc(nu/2, 0.5, nu/(nu+t*t))
+}
+
+// betaInc returns the regularized incomplete beta function I_x(a, b).
+func betaInc(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log1p(-x))
+	// the continued fraction converges fast for x < (a+1)/(a+b+2)
+	if x < (a+1)/(a+b+2) {
+		return front * betaCF(a, b, x) / a
+	}
+	return 1 - front*betaCF(b, a, 1-x)/b
+}
+
+// betaCF evaluates the continued fraction of the incomplete beta function with the modified Lentz's method.
+func betaCF(a, b, x float64) float64 {
+	const tiny, eps = 1e-300, 1e-15
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= 300; m++ {
+		fm := float64(m)
+		for _, num := range [2]float64{
+			fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm)),
+			-(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1)),
+		} {
+			if d = 1 + num*d; math.Abs(d) < tiny {
+				d = tiny
+			}
+			if c = 1 + num/c; math.Abs(c) < tiny {
+				c = tiny
+			}
+			d = 1 / d
+			h *= d * c
+		}
+		if math.Abs(d*c-1) < eps {
+			break
+		}
+	}
+	return h
+}
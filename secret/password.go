@@ -6,7 +6,10 @@ package secret
 
 import (
 	"bytes"
+	"context"
 	"io"
+	"log/slog"
+	"strconv"
 	"strings"
 	"sync/atomic"
 
@@ -18,6 +21,10 @@ import (
 var MarshalPassword atomic.Bool
 
 // Password is a string that renders to *** in XML/JSON (MarshalText/<arshalJSON)
+//
+// It may be a reference to the secret (env:NAME, file:/path, cmd:gopass show x, ...),
+// see RegisterResolver for the schemes. Use Get to resolve it.
+// Only the reference is stored and marshaled, never the resolved secret.
 type Password string
 
 // MarshalText returns ***.
@@ -47,7 +54,7 @@ func (passw Password) marshalAppend(buf interface {
 	}
 }
 
-// UnmarshalJSON reads the text.
+// UnmarshalText reads the text, which may be a reference.
 // Watch out that this fails for itself (*** -marshaled text)!
 func (passw *Password) UnmarshalText(p []byte) error {
 	if bytes.IndexFunc(p, func(r rune) bool { return r != '*' }) < 0 {
@@ -93,8 +100,32 @@ func (passw *Password) UnmarshalJSON(p []byte) error {
 	return err
 }
 
-// String returns the real password.
-func (passw Password) String() string { return string(passw) }
+// String returns the garbled representation of Password, the same as Text.
+func (passw Password) String() string {
+	if passw == "" {
+		return ""
+	}
+	return passw.Text()
+}
+
+// GoString returns the garbled representation of Password, for %#v.
+func (passw Password) GoString() string {
+	return "secret.Password(" + strconv.Quote(passw.String()) + ")"
+}
+
+// LogValue implements slog.LogValuer, returning the garbled representation.
+func (passw Password) LogValue() slog.Value { return slog.StringValue(passw.String()) }
+
+// IsRef reports whether the password is a reference with a registered scheme.
+func (passw Password) IsRef() bool {
+	r, _ := parseRef(string(passw))
+	return r != nil
+}
+
+// Get returns the real password, resolving (and caching) it if it is a reference.
+func (passw Password) Get(ctx context.Context) (string, error) {
+	return resolve(ctx, string(passw))
+}
 
 // Text returns the garbled representation of Password.
 func (passw Password) Text() string {
@@ -107,7 +138,7 @@ func (passw Password) Text() string {
 	return buf.String()
 }
 
-// Set the password (from a flag).
+// Set the password (from a flag), which may be a reference.
 func (passw *Password) Set(s string) error {
 	*passw = Password(s)
 	return nil
@@ -5,6 +5,13 @@
 package secret_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/go-json-experiment/json"
@@ -54,3 +61,67 @@ func TestPassword(t *testing.T) {
 		t.Errorf("unmarshaled %#v, wanted %#v", conf2, conf)
 	}
 }
+
+func TestPasswordRef(t *testing.T) {
+	const value = "s3cr3t-value-1234"
+	var calls int
+	prev := secret.RegisterResolver("stub", secret.ResolverFunc(func(_ context.Context, ref string) (string, error) {
+		calls++
+		if ref != "x" {
+			return "", secret.ErrNotFound
+		}
+		return value, nil
+	}))
+	t.Cleanup(func() { secret.RegisterResolver("stub", prev) })
+
+	fn := filepath.Join(t.TempDir(), "pw")
+	if err := os.WriteFile(fn, []byte(value+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SECRET_TEST_PW", value)
+	for _, s := range []string{
+		"stub:x", "pass:" + value, "env:SECRET_TEST_PW", "file:" + fn, "cmd:echo " + value,
+	} {
+		var passw secret.Password
+		if err := passw.Set(s); err != nil {
+			t.Fatal(err)
+		}
+		if !passw.IsRef() {
+			t.Errorf("%q is not a reference", s)
+		}
+		if got, err := passw.Get(t.Context()); err != nil {
+			t.Errorf("%q: %+v", s, err)
+		} else if got != value {
+			t.Errorf("%q: got %q, wanted %q", s, got, value)
+		}
+
+		var buf strings.Builder
+		logger := slog.New(slog.NewTextHandler(&buf, nil))
+		logger.Info("msg", "password", passw)
+		b, err := json.Marshal(struct{ Password secret.Password }{passw})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, out := range []string{
+			passw.String(), passw.Text(), fmt.Sprintf("%v %s %#v", passw, passw, passw),
+			buf.String(), string(b),
+		} {
+			if strings.Contains(out, value) {
+				t.Errorf("%q: %q contains the secret", s, out)
+			}
+		}
+	}
+
+	if _, err := secret.Password("stub:x").Get(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("resolver called %d times, wanted 1 (cached)", calls)
+	}
+	if _, err := secret.Password("stub:y").Get(t.Context()); !errors.Is(err, secret.ErrNotFound) {
+		t.Errorf("got %+v, wanted %v", err, secret.ErrNotFound)
+	}
+	if got, err := secret.Password("nonscheme:x").Get(t.Context()); err != nil || got != "nonscheme:x" {
+		t.Errorf("literal: got %q, %+v", got, err)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2026 Tamás Gulácsi. All rights reserved.
+//
+// SPDX-License-Identifier: AGPL-3.0
+
+package secret
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Resolver resolves the reference (the part after the "scheme:" prefix) to the secret.
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// ResolverFunc is a function implementing Resolver.
+type ResolverFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve calls f.
+func (f ResolverFunc) Resolve(ctx context.Context, ref string) (string, error) { return f(ctx, ref) }
+
+// ErrNotFound is returned by the resolvers when the secret does not exist.
+var ErrNotFound = errors.New("secret not found")
+
+var (
+	resolversMu sync.RWMutex
+	resolvers   = map[string]Resolver{
+		"pass":    ResolverFunc(resolvePass),
+		"env":     ResolverFunc(resolveEnv),
+		"file":    ResolverFunc(resolveFile),
+		"cmd":     ResolverFunc(resolveCmd),
+		"gopass":  ResolverFunc(resolveGopass),
+		"keyring": ResolverFunc(resolveKeyring),
+	}
+
+	cacheMu sync.Mutex
+	cache   = make(map[string]string)
+)
+
+// RegisterResolver registers the Resolver for the scheme, replacing the previous one
+// (nil removes it), and returns the previous one.
+// This allows stubbing the resolvers in tests.
+//
+// The built-in schemes are
+//
+//   - pass:literal - the literal password (as in openssl-passphrase-options),
+//   - env:NAME - the value of the NAME environment variable,
+//   - file:/path - the content of the file,
+//   - cmd:command - the output of the command, run with sh -c,
+//   - gopass:path - the password (first line) of the gopass (or pass) entry,
+//   - keyring:service/user - the secret of the Secret Service keyring (by secret-tool).
+func RegisterResolver(scheme string, r Resolver) Resolver {
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	prev := resolvers[scheme]
+	if r == nil {
+		delete(resolvers, scheme)
+	} else {
+		resolvers[scheme] = r
+	}
+	ClearCache()
+	return prev
+}
+
+// ClearCache forgets the cached resolved secrets.
+func ClearCache() {
+	cacheMu.Lock()
+	clear(cache)
+	cacheMu.Unlock()
+}
+
+// parseRef returns the resolver and the reference, if s is a reference with a registered scheme.
+func parseRef(s string) (Resolver, string) {
+	scheme, ref, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, ""
+	}
+	resolversMu.RLock()
+	r := resolvers[scheme]
+	resolversMu.RUnlock()
+	return r, ref
+}
+
+// resolve resolves the reference, caching the successful results.
+func resolve(ctx context.Context, s string) (string, error) {
+	r, ref := parseRef(s)
+	if r == nil {
+		return s, nil
+	}
+	cacheMu.Lock()
+	v, ok := cache[s]
+	cacheMu.Unlock()
+	if ok {
+		return v, nil
+	}
+	v, err := r.Resolve(ctx, ref)
+	if err != nil {
+		// the reference may contain secrets (cmd:), so only the scheme is shown
+		scheme, _, _ := strings.Cut(s, ":")
+		return "", fmt.Errorf("resolve %s: %w", scheme, err)
+	}
+	cacheMu.Lock()
+	cache[s] = v
+	cacheMu.Unlock()
+	return v, nil
+}
+
+func resolvePass(_ context.Context, ref string) (string, error) { return ref, nil }
+
+func resolveEnv(_ context.Context, ref string) (string, error) {
+	if v, ok := os.LookupEnv(ref); ok {
+		return v, nil
+	}
+	return "", fmt.Errorf("%s: %w", ref, ErrNotFound)
+}
+
+func resolveFile(_ context.Context, ref string) (string, error) {
+	b, err := os.ReadFile(ref)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = fmt.Errorf("%w: %w", err, ErrNotFound)
+		}
+		return "", err
+	}
+	return string(bytes.TrimRight(b, "\r\n")), nil
+}
+
+func resolveCmd(ctx context.Context, ref string) (string, error) {
+	return output(ctx, "sh", "-c", ref)
+}
+
+func resolveGopass(ctx context.Context, ref string) (string, error) {
+	if _, err := exec.LookPath("gopass"); err == nil {
+		return output(ctx, "gopass", "show", "--password", ref)
+	}
+	s, err := output(ctx, "pass", "show", ref)
+	s, _, _ = strings.Cut(s, "\n")
+	return s, err
+}
+
+func resolveKeyring(ctx context.Context, ref string) (string, error) {
+	service, user, _ := strings.Cut(ref, "/")
+	s, err := output(ctx, "secret-tool", "lookup", "service", service, "username", user)
+	if err == nil && s == "" {
+		err = fmt.Errorf("%s: %w", ref, ErrNotFound)
+	}
+	return s, err
+}
+
+// output returns the output of the command, without the trailing newline.
+func output(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var errBuf bytes.Buffer
+	cmd.Stderr = &errBuf
+	b, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s: %w", name, errBuf.String(), err)
+	}
+	return string(bytes.TrimRight(b, "\r\n")), nil
+}
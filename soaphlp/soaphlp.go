@@ -232,9 +232,20 @@ func (f Fault) WriteResponse(w http.ResponseWriter) {
 	}{Fault: f})
 }
 
+// Error writes the error as a SOAP Fault (see FaultFromError).
 func Error(w http.ResponseWriter, err error) {
-	Fault{Code: err.Error()}.WriteResponse(w)
+	FaultFromError(err).WriteResponse(w)
 }
+
+// FaultFromError returns the Fault in err's tree, or a new Fault with the error as Code.
 func FaultFromError(err error) Fault {
+	var f Fault
+	if errors.As(err, &f) {
+		return f
+	}
+	var fp *Fault
+	if errors.As(err, &fp) && fp != nil {
+		return *fp
+	}
 	return Fault{Code: err.Error()}
 }
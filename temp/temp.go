@@ -22,6 +22,7 @@ package temp
 import (
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -52,6 +53,9 @@ func ReaderToFile(r io.Reader, prefix, suffix string) (filename string, err erro
 		filename = dfh.Name()
 	}
 	dfh.Close()
+	if err != nil {
+		os.Remove(dfh.Name())
+	}
 	return
 }
 
@@ -66,3 +70,30 @@ func BaseName(fileName string) string {
 	}
 	return fileName
 }
+
+// WriteFileAtomic writes data to a temp file in filename's directory,
+// and renames it to filename, so readers see either the old or the new content.
+//
+// Unlike renameio.WriteFile, this works on Windows, too.
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	fh, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+"-*")
+	if err != nil {
+		return err
+	}
+	tmp := fh.Name()
+	if _, err = fh.Write(data); err == nil {
+		if err = fh.Chmod(perm); err == nil {
+			err = fh.Sync()
+		}
+	}
+	if closeErr := fh.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, filename)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2026 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package term
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tgulacsi/go/text"
+	"golang.org/x/term"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// ColorLevel is the color support of a terminal.
+type ColorLevel uint8
+
+const (
+	NoColor = ColorLevel(iota)
+	// Color16 is the basic 8/16 ANSI colors.
+	Color16
+	Color256
+	// TrueColor is 24-bit RGB.
+	TrueColor
+)
+
+// Console is a terminal (or a redirected stdin/stdout) with its capabilities.
+type Console struct {
+	In, Out *os.File
+	// Encoding of the console, never nil.
+	Encoding encoding.Encoding
+	// EncodingName is the canonical name of Encoding.
+	EncodingName string
+
+	br    *bufio.Reader
+	mu    sync.Mutex
+	Color ColorLevel
+	// IsTerminal reports whether both In and Out are terminals.
+	IsTerminal bool
+}
+
+// NewConsole returns the Console of os.Stdin and os.Stdout.
+func NewConsole() *Console { return NewConsoleOf(os.Stdin, os.Stdout) }
+
+// NewConsoleOf returns the Console of the given input and output,
+// with its capabilities detected from the environment.
+func NewConsoleOf(in, out *os.File) *Console {
+	c := Console{
+		In: in, Out: out,
+		IsTerminal: term.IsTerminal(int(in.Fd())) && term.IsTerminal(int(out.Fd())),
+	}
+	name := consoleEncodingName(out)
+	if name == "" {
+		name = GetEnvEncodingName(os.Getenv)
+	}
+	c.Encoding, c.EncodingName = lookupEncoding(name)
+	outTTY := term.IsTerminal(int(out.Fd()))
+	if outTTY {
+		outTTY = enableVT(out)
+	}
+	c.Color = GetEnvColorLevel(os.Getenv, outTTY)
+	return &c
+}
+
+// GetEnvEncodingName returns the name of the encoding from the locale settings
+// (LC_ALL, LC_CTYPE, LANG - the first non-empty wins), or empty if not found.
+func GetEnvEncodingName(getenv func(string) string) string {
+	for _, k := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := getenv(k); v != "" {
+			if i := strings.IndexByte(v, '@'); i >= 0 { // hu_HU.ISO-8859-2@euro
+				v = v[:i]
+			}
+			if !strings.Contains(v, ".") {
+				return ""
+			}
+			return GetLangEncodingName(v)
+		}
+	}
+	return ""
+}
+
+// lookupEncoding returns the encoding and its canonical name, UTF-8 if not found.
+func lookupEncoding(name string) (encoding.Encoding, string) {
+	if name != "" {
+		if enc := text.GetEncoding(name); enc != nil && enc != encoding.Replacement {
+			if nm := text.EncodingName(enc); nm != "UTF-8" {
+				return enc, nm
+			}
+		}
+	}
+	return unicode.UTF8, "UTF-8"
+}
+
+// GetEnvColorLevel returns the color support level from NO_COLOR, FORCE_COLOR, COLORTERM and TERM.
+// isTerminal is whether the output is a terminal.
+func GetEnvColorLevel(getenv func(string) string, isTerminal bool) ColorLevel {
+	if getenv("NO_COLOR") != "" {
+		return NoColor
+	}
+	if f := getenv("FORCE_COLOR"); f != "" {
+		if n, err := strconv.Atoi(f); err == nil && n >= 0 {
+			return ColorLevel(min(n, int(TrueColor)))
+		}
+		isTerminal = true
+	}
+	if !isTerminal {
+		return NoColor
+	}
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+	switch t := getenv("TERM"); {
+	case t == "dumb":
+		return NoColor
+	case strings.HasSuffix(t, "-direct"):
+		return TrueColor
+	case strings.Contains(t, "256color"):
+		return Color256
+	case t == "":
+		if getenv("WT_SESSION") != "" { // Windows Terminal
+			return TrueColor
+		}
+		return defaultColor
+	}
+	return Color16
+}
+
+// Size returns the width and height of the terminal.
+// Falls back to COLUMNS and LINES from the environment, then to 80x24.
+func (c *Console) Size() (width, height int) {
+	if w, h, err := term.GetSize(int(c.Out.Fd())); err == nil && w > 0 {
+		return w, h
+	}
+	width, height = 80, 24
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		width = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("LINES")); err == nil && n > 0 {
+		height = n
+	}
+	return width, height
+}
+
+// Writer returns a writer which encodes the UTF-8 text to the console's encoding.
+func (c *Console) Writer() io.Writer {
+	if c.isUTF8() {
+		return c.Out
+	}
+	return c.Encoding.NewEncoder().Writer(c.Out)
+}
+
+func (c *Console) isUTF8() bool { return c.EncodingName == "UTF-8" }
+
+// ReadLine reads a line with line editing (in raw mode) if the console is a terminal,
+// or just a line from In if not.
+func (c *Console) ReadLine(prompt string) (string, error) {
+	return c.readLine(prompt, false)
+}
+
+// ReadPassword reads a line without echoing it.
+//
+// If In is a terminal (even if Out is not), echo is turned off while reading;
+// if it is not, the line is read from In.
+func (c *Console) ReadPassword(prompt string) (string, error) {
+	return c.readLine(prompt, true)
+}
+
+func (c *Console) readLine(prompt string, password bool) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if password && !c.IsTerminal && term.IsTerminal(int(c.In.Fd())) {
+		return c.readPasswordNoEcho(prompt)
+	}
+	if !c.IsTerminal {
+		if c.br == nil {
+			var r io.Reader = c.In
+			if !c.isUTF8() {
+				r = c.Encoding.NewDecoder().Reader(r)
+			}
+			c.br = bufio.NewReader(r)
+		}
+		line, err := c.br.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimRight(line, "\r\n"), err
+	}
+
+	fd := int(c.In.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, state)
+	rw := struct {
+		io.Reader
+		io.Writer
+	}{c.In, c.Out}
+	if !c.isUTF8() {
+		rw.Reader, rw.Writer = c.Encoding.NewDecoder().Reader(c.In), c.Encoding.NewEncoder().Writer(c.Out)
+	}
+	t := term.NewTerminal(rw, prompt)
+	if w, h, err := term.GetSize(int(c.Out.Fd())); err == nil {
+		_ = t.SetSize(w, h)
+	}
+	if password {
+		return t.ReadPassword(prompt)
+	}
+	line, err := t.ReadLine()
+	if errors.Is(err, io.EOF) && line != "" {
+		err = nil
+	}
+	return line, err
+}
+
+// readPasswordNoEcho reads the password from the terminal In without echo,
+// when Out is not a terminal (redirected), so line editing is not possible.
+func (c *Console) readPasswordNoEcho(prompt string) (string, error) {
+	w := c.Writer()
+	if _, err := io.WriteString(w, prompt); err != nil {
+		return "", err
+	}
+	b, err := term.ReadPassword(int(c.In.Fd()))
+	io.WriteString(w, "\n")
+	if err != nil {
+		return "", err
+	}
+	if !c.isUTF8() {
+		if b, err = c.Encoding.NewDecoder().Bytes(b); err != nil {
+			return "", err
+		}
+	}
+	return string(b), nil
+}
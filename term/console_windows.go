@@ -0,0 +1,54 @@
+//go:build windows
+
+/*
+Copyright 2026 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package term
+
+import (
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/windows"
+)
+
+// Windows 10+ consoles know the ANSI colors, after enableVT.
+const defaultColor = Color16
+
+// consoleEncodingName returns the output codepage of the console.
+func consoleEncodingName(f *os.File) string {
+	cp, err := windows.GetConsoleOutputCP()
+	if err != nil || cp == 0 {
+		return ""
+	}
+	switch {
+	case cp == 65001:
+		return "utf-8"
+	case cp >= 1250 && cp <= 1258 || cp == 874:
+		return "windows-" + strconv.FormatUint(uint64(cp), 10)
+	}
+	return "cp" + strconv.FormatUint(uint64(cp), 10)
+}
+
+// enableVT enables the ANSI escape sequences, reports whether it succeeded.
+func enableVT(f *os.File) bool {
+	h := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		return false
+	}
+	return windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+}
@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/tgulacsi/go/iohlp"
+	"github.com/tgulacsi/go/text"
 	"golang.org/x/term"
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/htmlindex"
@@ -45,16 +46,19 @@ func GetRawTTYEncoding() encoding.Encoding {
 	if lang == "" {
 		return nil
 	}
-	enc, err := htmlindex.Get(lang)
-	if err != nil {
-		panic(err)
+	if enc, err := htmlindex.Get(lang); err == nil {
+		return enc
+	}
+	if enc := text.GetEncoding(lang); enc != encoding.Replacement {
+		return enc
 	}
-	return enc
+	return nil
 }
 
-// GetTTYEncodingName returns the TTY encoding's name, or empty if not found.
+// GetTTYEncodingName returns the TTY encoding's name
+// (from LC_ALL, LC_CTYPE or LANG), or empty if not found.
 func GetTTYEncodingName() string {
-	return GetLangEncodingName(os.Getenv("LANG"))
+	return GetEnvEncodingName(os.Getenv)
 }
 
 // GetLangEncodingName returns the encoding's name from the given LANG string.
@@ -65,7 +69,7 @@ func GetLangEncodingName(lang string) string {
 	if len(lang) > 7 &&
 		strings.EqualFold(lang[:7], "iso8859") {
 		lang = lang[7:]
-		for lang != "" && lang[0] == '-' || lang[0] == '_' {
+		for lang != "" && (lang[0] == '-' || lang[0] == '_') {
 			lang = lang[1:]
 		}
 		return "iso8859-" + lang
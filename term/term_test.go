@@ -1,6 +1,8 @@
 package term_test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/tgulacsi/go/term"
@@ -18,3 +20,76 @@ func TestGetLangEncodingName(t *testing.T) {
 		}
 	}
 }
+
+func TestGetEnvEncodingName(t *testing.T) {
+	for _, tC := range []struct {
+		Env  map[string]string
+		Want string
+	}{
+		{map[string]string{"LANG": "hu_HU.UTF-8"}, "utf-8"},
+		{map[string]string{"LANG": "hu_HU.UTF-8", "LC_CTYPE": "hu_HU.ISO-8859-2"}, "iso-8859-2"},
+		{map[string]string{"LANG": "hu_HU.UTF-8", "LC_CTYPE": "hu_HU.ISO-8859-2", "LC_ALL": "C"}, ""},
+		{map[string]string{"LANG": "de_DE.ISO8859-15@euro"}, "iso8859-15"},
+		{map[string]string{"LANG": "hu_HU"}, ""},
+		{nil, ""},
+	} {
+		if got := term.GetEnvEncodingName(func(k string) string { return tC.Env[k] }); got != tC.Want {
+			t.Errorf("%v: got %q, wanted %q", tC.Env, got, tC.Want)
+		}
+	}
+}
+
+func TestGetEnvColorLevel(t *testing.T) {
+	for _, tC := range []struct {
+		Env        map[string]string
+		IsTerminal bool
+		Want       term.ColorLevel
+	}{
+		{map[string]string{"TERM": "xterm"}, true, term.Color16},
+		{map[string]string{"TERM": "xterm"}, false, term.NoColor},
+		{map[string]string{"TERM": "xterm-256color"}, true, term.Color256},
+		{map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor"}, true, term.TrueColor},
+		{map[string]string{"TERM": "xterm-direct"}, true, term.TrueColor},
+		{map[string]string{"TERM": "dumb"}, true, term.NoColor},
+		{map[string]string{"TERM": "xterm-256color", "NO_COLOR": "1"}, true, term.NoColor},
+		{map[string]string{"TERM": "xterm", "FORCE_COLOR": "1"}, false, term.Color16},
+		{map[string]string{"FORCE_COLOR": "true", "COLORTERM": "24bit"}, false, term.TrueColor},
+	} {
+		if got := term.GetEnvColorLevel(func(k string) string { return tC.Env[k] }, tC.IsTerminal); got != tC.Want {
+			t.Errorf("%v (%t): got %d, wanted %d", tC.Env, tC.IsTerminal, got, tC.Want)
+		}
+	}
+}
+
+func TestConsoleNotTerminal(t *testing.T) {
+	dir := t.TempDir()
+	in, err := os.Create(filepath.Join(dir, "in"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "jelszó" in ISO-8859-2
+	if _, err = in.WriteString("first line\r\njelsz\xf3\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = in.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.Create(filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LC_ALL", "hu_HU.ISO-8859-2")
+	c := term.NewConsoleOf(in, out)
+	if c.IsTerminal || c.Color != term.NoColor || c.EncodingName != "ISO-8859-2" {
+		t.Errorf("got %+v", c)
+	}
+	if line, err := c.ReadLine("? "); err != nil || line != "first line" {
+		t.Errorf("got %q, %+v", line, err)
+	}
+	if line, err := c.ReadPassword("? "); err != nil || line != "jelszó" {
+		t.Errorf("got %q, %+v", line, err)
+	}
+	if w, h := c.Size(); w <= 0 || h <= 0 {
+		t.Errorf("size: %dx%d", w, h)
+	}
+}
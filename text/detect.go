@@ -0,0 +1,334 @@
+/*
+Copyright 2026 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package text
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	encunicode "golang.org/x/text/encoding/unicode"
+)
+
+// DetectSize is the length of the prefix DetectEncoding looks at.
+var DetectSize = 64 << 10
+
+// Detection is the result of DetectEncoding.
+type Detection struct {
+	// Encoding decodes the input (skipping the BOM, if any).
+	Encoding encoding.Encoding
+	// Name is the IANA name of the encoding.
+	Name string
+	// Language is the language ("hu", "de" or "en") whose byte-pair statistics
+	// fit the best, if the decision was based on them.
+	Language string
+	// Confidence is between 0 and 1.
+	// Pure ASCII input is UTF-8 with 0 confidence, as every candidate decodes it the same.
+	Confidence float64
+}
+
+// DetectEncoding guesses the encoding of p among UTF-8 (with or without BOM),
+// UTF-16LE/BE, windows-1250, ISO-8859-2, ISO-8859-1, IBM852 and IBM437.
+//
+// Pure ASCII and valid UTF-8 is UTF-8; for the single-byte encodings
+// the byte pairs of the decoded text are scored with Hungarian, German and English statistics.
+func DetectEncoding(p []byte) Detection {
+	switch {
+	case bytes.HasPrefix(p, []byte("\xef\xbb\xbf")):
+		return Detection{Encoding: encunicode.UTF8BOM, Name: "UTF-8", Confidence: 1}
+	case bytes.HasPrefix(p, []byte("\xff\xfe")):
+		return Detection{Encoding: encunicode.UTF16(encunicode.LittleEndian, encunicode.ExpectBOM), Name: "UTF-16LE", Confidence: 1}
+	case bytes.HasPrefix(p, []byte("\xfe\xff")):
+		return Detection{Encoding: encunicode.UTF16(encunicode.BigEndian, encunicode.ExpectBOM), Name: "UTF-16BE", Confidence: 1}
+	}
+	if d, ok := detectUTF16(p); ok {
+		return d
+	}
+
+	// a truncated prefix may end in an incomplete rune
+	q := p
+	for i := 1; i <= 3 && i <= len(q); i++ {
+		if c := q[len(q)-i]; utf8.RuneStart(c) {
+			if !utf8.FullRune(q[len(q)-i:]) {
+				q = q[:len(q)-i]
+			}
+			break
+		}
+	}
+	if utf8.Valid(q) {
+		var multi int
+		for _, c := range q {
+			if c >= 0xc0 {
+				multi++
+			}
+		}
+		// the probability of a random high byte pair being valid UTF-8 is about 1/8
+		return Detection{Encoding: encunicode.UTF8, Name: "UTF-8", Confidence: 1 - math.Pow(1.0/8, float64(multi))}
+	}
+	return detectSingleByte(p)
+}
+
+// detectUTF16 detects BOM-less UTF-16 by the zero bytes of (mostly) Latin text.
+func detectUTF16(p []byte) (Detection, bool) {
+	if len(p) < 4 {
+		return Detection{}, false
+	}
+	var zeros [2]int
+	n := len(p) &^ 1
+	for i, c := range p[:n] {
+		if c == 0 {
+			zeros[i&1]++
+		}
+	}
+	half := float64(n / 2)
+	even, odd := float64(zeros[0])/half, float64(zeros[1])/half
+	switch {
+	case odd > 0.4 && even < 0.05:
+		return Detection{Encoding: encunicode.UTF16(encunicode.LittleEndian, encunicode.IgnoreBOM), Name: "UTF-16LE", Confidence: odd - even}, true
+	case even > 0.4 && odd < 0.05:
+		return Detection{Encoding: encunicode.UTF16(encunicode.BigEndian, encunicode.IgnoreBOM), Name: "UTF-16BE", Confidence: even - odd}, true
+	}
+	return Detection{}, false
+}
+
+type sbCandidate struct {
+	Encoding encoding.Encoding
+	Name     string
+	table    [256]rune
+}
+
+// sbCandidates are the single-byte candidates, in order of preference when decoding identically.
+var sbCandidates = func() []*sbCandidate {
+	cc := make([]*sbCandidate, 0, 5)
+	for _, nm := range []string{"windows-1250", "ISO-8859-2", "ISO-8859-1", "IBM852", "IBM437"} {
+		gnm := nm
+		if strings.HasPrefix(nm, "IBM") {
+			gnm = "cp" + nm[3:]
+		}
+		c := sbCandidate{Encoding: GetEncoding(gnm), Name: nm}
+		dec := c.Encoding.NewDecoder()
+		for i := range c.table {
+			b, err := dec.Bytes([]byte{byte(i)})
+			if r, _ := utf8.DecodeRune(b); err == nil && len(b) != 0 {
+				c.table[i] = r
+			} else {
+				c.table[i] = utf8.RuneError
+			}
+		}
+		cc = append(cc, &c)
+	}
+	return cc
+}()
+
+// preferred is the order of preference of the candidates decoding identically, per language.
+var preferred = map[string][]string{
+	"hu": {"windows-1250", "ISO-8859-2", "IBM852", "ISO-8859-1", "IBM437"},
+	"de": {"ISO-8859-1", "windows-1250", "ISO-8859-2", "IBM437", "IBM852"},
+	"en": {"ISO-8859-1", "windows-1250", "ISO-8859-2", "IBM437", "IBM852"},
+}
+
+// Penalties (in log probability) for the unlikely characters.
+const (
+	penaltyControl = -15
+	penaltyBox     = -8
+	penaltySymbol  = -4
+)
+
+func detectSingleByte(p []byte) Detection {
+	p = p[:min(len(p), DetectSize)]
+	type result struct {
+		c        *sbCandidate
+		text     string
+		language string
+		score    float64
+	}
+	results := make([]result, 0, len(sbCandidates))
+	runes := make([]rune, len(p))
+	for _, c := range sbCandidates {
+		for i, b := range p {
+			runes[i] = c.table[b]
+		}
+		lang, score := scoreRunes(runes)
+		results = append(results, result{c: c, text: string(runes), language: lang, score: score})
+	}
+	best := slices.MaxFunc(results, func(a, b result) int {
+		if a.score < b.score {
+			return -1
+		} else if a.score > b.score {
+			return 1
+		}
+		return 0
+	})
+	// among the identically decoding ones, choose the preferred for the language
+	for _, nm := range preferred[best.language] {
+		if i := slices.IndexFunc(results, func(r result) bool { return r.c.Name == nm && r.text == best.text }); i >= 0 {
+			best = results[i]
+			break
+		}
+	}
+	sum := 1.0
+	for _, r := range results {
+		if r.text != best.text {
+			sum += math.Exp(r.score - best.score)
+		}
+	}
+	return Detection{Encoding: best.c.Encoding, Name: best.c.Name, Language: best.language, Confidence: 1 / sum}
+}
+
+// scoreRunes returns the language which fits the best, and the log likelihood of
+// the byte pairs containing non-ASCII runes, plus the penalties.
+func scoreRunes(runes []rune) (string, float64) {
+	var penalty float64
+	scores := make([]float64, len(bigramModels))
+	prev := ' '
+	for i, r := range runes {
+		cls := charClass(r)
+		if r >= 0x80 {
+			switch {
+			case r == utf8.RuneError || unicode.IsControl(r):
+				penalty += penaltyControl
+			case 0x2500 <= r && r <= 0x25ff:
+				penalty += penaltyBox
+			case cls == '?':
+				penalty += penaltySymbol
+			}
+		}
+		if r >= 0x80 || (i > 0 && runes[i-1] >= 0x80) {
+			for j, m := range bigramModels {
+				scores[j] += m.logProb(prev, cls)
+				if r >= 0x80 && cls != ' ' && cls != '?' && !m.letters[cls] {
+					// a letter foreign to the language
+					scores[j] += penaltySymbol
+				}
+			}
+		}
+		prev = cls
+	}
+	j := 0
+	for i, s := range scores {
+		if s > scores[j] {
+			j = i
+		}
+	}
+	return bigramModels[j].language, scores[j] + penalty
+}
+
+// charClass returns the lowercase letter, ' ' for the ASCII non-letters and common punctuation,
+// and '?' for the other non-ASCII runes.
+func charClass(r rune) rune {
+	if unicode.IsLetter(r) {
+		return unicode.ToLower(r)
+	}
+	if r < 0x80 || strings.ContainsRune(" „”“’‘‚–—…«»€", r) {
+		return ' '
+	}
+	return '?'
+}
+
+type bigramModel struct {
+	counts map[[2]rune]int
+	// letters are the non-ASCII letters of the language.
+	letters  map[rune]bool
+	language string
+	total    int
+}
+
+const bigramVocabulary = 64
+
+func (m *bigramModel) logProb(a, b rune) float64 {
+	return math.Log((float64(m.counts[[2]rune{a, b}]) + 0.5) / (float64(m.total) + 0.5*bigramVocabulary*bigramVocabulary))
+}
+
+func newBigramModel(language, sample string) *bigramModel {
+	m := bigramModel{language: language, counts: make(map[[2]rune]int), letters: make(map[rune]bool)}
+	prev := ' '
+	for _, r := range sample {
+		cls := charClass(r)
+		if r >= 0x80 && unicode.IsLetter(r) {
+			m.letters[cls] = true
+		}
+		m.counts[[2]rune{prev, cls}]++
+		m.total++
+		prev = cls
+	}
+	return &m
+}
+
+var bigramModels = []*bigramModel{
+	newBigramModel("hu", `A magyar nyelv az uráli nyelvcsalád finnugor ágának ugor csoportjába tartozik.
+Legközelebbi rokonai a manysi és a hanti nyelv. Magyarországon kívül jelentős magyar közösségek élnek
+a szomszédos országokban, például Romániában, Szlovákiában, Szerbiában és Ukrajnában.
+A helyesírás a latin ábécén alapul, amelyet ékezetes betűkkel egészítettek ki: á, é, í, ó, ö, ő, ú, ü és ű.
+Az árvíztűrő tükörfúrógép kifejezés mind a kilenc ékezetes magánhangzót tartalmazza,
+ezért gyakran használják a karakterkódolás ellenőrzésére. A számítógépes rendszerekben a magyar szövegeket
+régen a Latin-2 vagy a Windows-1250 kódlappal tárolták, a DOS alatt pedig a 852-es kódlap volt elterjedt.
+Ha egy fájl rossz kódolással nyílik meg, a hosszú ő és ű betűk helyén gyakran hullámvonalas vagy kalapos betűk jelennek meg.
+A számlázó program minden hónap végén elküldi az ügyfeleknek a kimutatást, amelyben szerepel a vevő neve,
+címe, adószáma és a fizetendő összeg. Kérjük, hogy a befizetést a megadott határidőig teljesítsék,
+különben késedelmi kamatot számítunk fel. Üdvözlettel: az ügyfélszolgálat munkatársai.
+Szeretnénk tájékoztatni önöket, hogy a szerződés módosítása után a díjak összege változhat.
+Kérdés esetén forduljanak bizalommal kollégáinkhoz, akik hétfőtől péntekig reggel nyolc órától
+délután négy óráig állnak rendelkezésükre. A biztosítási kötvény érvényessége egy év,
+amely automatikusan megújul, ha egyik fél sem mondja fel. Köszönjük, hogy minket választott!
+Név; Születési dátum; Cím; Irányítószám; Település; Telefonszám; Megjegyzés
+Kovács Éva; 1978.03.12; Fő utca 1.; 1011; Budapest; +36 1 234 5678; Nyugdíjas, özvegy
+Szőke Ödön; 1965.11.30; Petőfi Sándor út 23.; 6720; Szeged; +36 62 555 123; Új ügyfél
+Gyöngyösi Ákos; 1990.01.05; Kossuth Lajos tér 4.; 4025; Debrecen; +36 52 111 222; Átszerződött`),
+	newBigramModel("de", `Die deutsche Sprache gehört zum westgermanischen Zweig der indogermanischen Sprachen.
+Sie wird vor allem in Deutschland, Österreich, der Schweiz und Liechtenstein gesprochen.
+Die Rechtschreibung verwendet neben dem lateinischen Alphabet die Umlaute ä, ö und ü sowie das Eszett ß.
+Größere Unternehmen schicken ihren Kunden jeden Monat eine Übersicht über die offenen Beträge.
+Bitte überweisen Sie den fälligen Betrag bis zum angegebenen Datum, andernfalls müssen wir Verzugszinsen berechnen.
+Für Rückfragen steht Ihnen unser Kundenservice von Montag bis Freitag zur Verfügung.
+Mit freundlichen Grüßen, Ihre Buchhaltung. Die Straße vor dem Gebäude wird nächste Woche gesperrt,
+weil die Stadtwerke neue Leitungen verlegen. Bürger können sich über die Änderungen auf der Webseite informieren.
+Schöne Grüße aus München und Köln, wo es heute ziemlich kühl ist. Wir möchten Sie darüber informieren,
+dass sich die Gebühren nach der Vertragsänderung ändern können. Natürlich gilt für Sie weiterhin
+die gesetzliche Kündigungsfrist. Bei Fragen zur Rechnung wählen Sie bitte die Durchwahl der Abteilung.
+Name; Geburtsdatum; Straße; Postleitzahl; Ort; Bemerkung
+Müller Jürgen; 12.03.1978; Hauptstraße 1; 10115; Berlin; Stammkunde, zahlt pünktlich
+Schröder Käthe; 30.11.1965; Bahnhofstraße 23; 80331; München; Rückruf erwünscht`),
+	newBigramModel("en", `The quick brown fox jumps over the lazy dog. Please find attached the monthly statement of your account,
+including the customer’s name, address and the amount due. We’d be grateful if you could pay by the due date —
+otherwise late fees may apply. “Thank you” for choosing our services. The café on the corner has a new menu,
+and prices start at €4.50 or £3.90. Please send your résumé to the human resources department
+by the end of the month. Name; Date of birth; Address; Postcode; Town; Note`),
+}
+
+// DetectingReader decodes its input with the encoding detected from its prefix.
+type DetectingReader struct {
+	io.Reader
+	Detection
+}
+
+// NewDetectingReader peeks (at most) DetectSize bytes of r, detects its encoding,
+// and returns a reader which decodes it to UTF-8 (with replacement characters for invalid input).
+func NewDetectingReader(r io.Reader) (*DetectingReader, error) {
+	br := bufio.NewReaderSize(r, DetectSize)
+	p, err := br.Peek(DetectSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	d := DetectEncoding(p)
+	return &DetectingReader{Reader: NewReader(br, d.Encoding), Detection: d}, nil
+}
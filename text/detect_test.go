@@ -0,0 +1,107 @@
+/*
+Copyright 2026 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package text
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	encunicode "golang.org/x/text/encoding/unicode"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	const (
+		// not from the bigram samples of detect.go
+		hu = "Tájékoztatjuk, hogy a lakásbiztosítás az évforduló előtt harminc nappal írásban felmondható. " +
+			"A kárbejelentéshez csatolja a fényképeket és a javítási számlát; a kártérítést öt munkanapon belül utaljuk."
+		de = "Wir bestätigen den Eingang Ihrer Schadensmeldung vom Dienstag. Ein Gutachter wird sich in den nächsten Tagen " +
+			"bei Ihnen melden, um einen Termin für die Besichtigung zu vereinbaren. Beigefügt finden Sie außerdem die überarbeiteten Bedingungen."
+		// short, with only one or two accented letters
+		csv1 = "17;Tóth Eva;Pecs;2500;ok\n"
+		csv2 = "18;Nagy Jozsef;Gyor;1200;fizetve\n19;Kiss Anna;Eger;900;függő\n"
+		en   = "Dear Customer, the premium of your car insurance changes from January. “Please” pay on time — thank you!"
+	)
+	encode := func(enc encoding.Encoding, s string) []byte {
+		b, err := enc.NewEncoder().Bytes([]byte(s))
+		if err != nil {
+			t.Fatalf("encode %q: %+v", s, err)
+		}
+		return b
+	}
+	for _, tC := range []struct {
+		Name     string
+		Input    []byte
+		Text     string
+		Encoding []string
+		Language string
+	}{
+		{"ascii", []byte("plain old ASCII"), "plain old ASCII", []string{"UTF-8"}, ""},
+		{"utf8", []byte(hu), hu, []string{"UTF-8"}, ""},
+		{"utf8bom", append([]byte("\xef\xbb\xbf"), hu...), hu, []string{"UTF-8"}, ""},
+		{"utf16le", encode(encunicode.UTF16(encunicode.LittleEndian, encunicode.IgnoreBOM), de), de, []string{"UTF-16LE"}, ""},
+		{"utf16be", encode(encunicode.UTF16(encunicode.BigEndian, encunicode.IgnoreBOM), de), de, []string{"UTF-16BE"}, ""},
+		{"utf16lebom", encode(encunicode.UTF16(encunicode.LittleEndian, encunicode.UseBOM), hu), hu, []string{"UTF-16LE"}, ""},
+		{"utf16bebom", encode(encunicode.UTF16(encunicode.BigEndian, encunicode.UseBOM), hu), hu, []string{"UTF-16BE"}, ""},
+		{"hu-1250", encode(charmap.Windows1250, hu), hu, []string{"windows-1250", "ISO-8859-2"}, "hu"},
+		{"hu-8859-2", encode(charmap.ISO8859_2, hu), hu, []string{"windows-1250", "ISO-8859-2"}, "hu"},
+		{"hu-852", encode(charmap.CodePage852, hu), hu, []string{"IBM852"}, "hu"},
+		{"de-8859-1", encode(charmap.ISO8859_1, de), de, []string{"ISO-8859-1"}, "de"},
+		{"de-437", encode(charmap.CodePage437, de), de, []string{"IBM437"}, "de"},
+		{"en-1250", encode(charmap.Windows1250, en), en, []string{"windows-1250"}, ""},
+		{"csv-1250", encode(charmap.Windows1250, csv1), csv1, []string{"windows-1250", "ISO-8859-2", "ISO-8859-1"}, ""},
+		{"csv-852", encode(charmap.CodePage852, csv1), csv1, []string{"IBM852", "IBM437"}, ""},
+		{"csv2-1250", encode(charmap.Windows1250, csv2), csv2, []string{"windows-1250", "ISO-8859-2"}, "hu"},
+		{"csv2-852", encode(charmap.CodePage852, csv2), csv2, []string{"IBM852"}, "hu"},
+	} {
+		t.Run(tC.Name, func(t *testing.T) {
+			d := DetectEncoding(tC.Input)
+			t.Logf("%s: %+v", tC.Name, d)
+			ok := false
+			for _, nm := range tC.Encoding {
+				ok = ok || d.Name == nm
+			}
+			if !ok {
+				t.Errorf("got %s, wanted %v", d.Name, tC.Encoding)
+			}
+			if tC.Language != "" && d.Language != tC.Language {
+				t.Errorf("got language %q, wanted %q", d.Language, tC.Language)
+			}
+			if tC.Name == "ascii" {
+				if d.Confidence != 0 {
+					t.Errorf("ASCII confidence: got %f, wanted 0", d.Confidence)
+				}
+			} else if d.Confidence < 0.9 {
+				t.Errorf("low confidence %f", d.Confidence)
+			}
+
+			dr, err := NewDetectingReader(bytes.NewReader(tC.Input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := io.ReadAll(dr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tC.Text {
+				t.Errorf("got %q, wanted %q", got, tC.Text)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2026 Tamás Gulácsi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package text
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+// The EBCDIC codepages missing from golang.org/x/text/encoding/charmap,
+// derived from IBM037 (US/Canada) by their national variant characters.
+var (
+	// IBM500 is the international EBCDIC Latin-1 codepage.
+	IBM500 encoding.Encoding = newByteTable("IBM500", charmap.CodePage037, map[byte]rune{
+		0x4A: '[', 0x4F: '!', 0x5A: ']', 0x5F: '^', 0xB0: '¢', 0xBA: '¬', 0xBB: '|',
+	})
+	// IBM01148 is IBM500 with the euro sign.
+	IBM01148 encoding.Encoding = newByteTable("IBM01148", IBM500, map[byte]rune{0x9F: '€'})
+	// IBM273 is the German/Austrian EBCDIC codepage.
+	IBM273 encoding.Encoding = newByteTable("IBM273", charmap.CodePage037, map[byte]rune{
+		0x43: '{', 0x4A: 'Ä', 0x4F: '!', 0x59: '~', 0x5A: 'Ü', 0x5F: '^', 0x63: '[',
+		0x6A: 'ö', 0x7C: '§', 0xA1: 'ß', 0xB0: '¢', 0xB5: '@', 0xBA: '¬', 0xBB: '|',
+		0xBC: '‾', 0xC0: 'ä', 0xCC: '¦', 0xD0: 'ü', 0xDC: '}', 0xE0: 'Ö', 0xEC: '\\', 0xFC: ']',
+	})
+	// IBM01141 is IBM273 with the euro sign.
+	IBM01141 encoding.Encoding = newByteTable("IBM01141", IBM273, map[byte]rune{0x9F: '€'})
+)
+
+// byteTable is a single-byte encoding given by its decoding table.
+type byteTable struct {
+	name   string
+	decode [256]rune
+	encode map[rune]byte
+	// subst is the encoded '?', the suggested replacement of the unencodable runes
+	// (see encoding.ReplaceUnsupported).
+	subst byte
+}
+
+// newByteTable returns the single-byte base encoding, with the bytes in diff decoded differently.
+func newByteTable(name string, base encoding.Encoding, diff map[byte]rune) *byteTable {
+	t := byteTable{name: name, encode: make(map[rune]byte, 256)}
+	dec := base.NewDecoder()
+	for i := range t.decode {
+		var buf [utf8.UTFMax]byte
+		n, _, _ := dec.Transform(buf[:], []byte{byte(i)}, true)
+		t.decode[i], _ = utf8.DecodeRune(buf[:n])
+	}
+	for b, r := range diff {
+		t.decode[b] = r
+	}
+	for i := len(t.decode) - 1; i >= 0; i-- {
+		if r := t.decode[i]; r != utf8.RuneError {
+			t.encode[r] = byte(i)
+		}
+	}
+	t.subst = t.encode['?']
+	return &t
+}
+
+func (t *byteTable) String() string { return t.name }
+
+func (t *byteTable) NewDecoder() *encoding.Decoder {
+	return &encoding.Decoder{Transformer: fromByteTable{t: t}}
+}
+
+func (t *byteTable) NewEncoder() *encoding.Encoder {
+	return &encoding.Encoder{Transformer: toByteTable{t: t}}
+}
+
+type fromByteTable struct {
+	transform.NopResetter
+	t *byteTable
+}
+type toByteTable struct {
+	transform.NopResetter
+	t *byteTable
+}
+
+func (m fromByteTable) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for i, c := range src {
+		r := m.t.decode[c]
+		if nDst+utf8.RuneLen(r) > len(dst) {
+			err = transform.ErrShortDst
+			break
+		}
+		nSrc = i + 1
+		nDst += utf8.EncodeRune(dst[nDst:], r)
+	}
+	return nDst, nSrc, err
+}
+
+func (m toByteTable) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		if !atEOF && !utf8.FullRune(src[nSrc:]) {
+			err = transform.ErrShortSrc
+			break
+		}
+		r, n := utf8.DecodeRune(src[nSrc:])
+		if r == utf8.RuneError && n == 1 {
+			err = encoding.ErrInvalidUTF8
+			break
+		}
+		if nDst+1 > len(dst) {
+			err = transform.ErrShortDst
+			break
+		}
+		b, ok := m.t.encode[r]
+		if !ok {
+			err = repertoireError(m.t.subst)
+			break
+		}
+		nSrc += n
+		dst[nDst] = b
+		nDst++
+	}
+	return nDst, nSrc, err
+}
+
+// repertoireError is returned for the runes not in the encoding,
+// as the charmap encoders do.
+type repertoireError byte
+
+func (repertoireError) Error() string { return "encoding: rune not supported by encoding." }
+
+// Replacement returns the suggested replacement, for encoding.ReplaceUnsupported.
+func (r repertoireError) Replacement() byte { return byte(r) }
@@ -23,16 +23,60 @@ import (
 
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/ianaindex"
 	"golang.org/x/text/transform"
 )
 
 // GetEncoding returns the encoding.Encoding for the text name of the encoding
 // Returns nil if the encoding is not found.
 //
-// Knows the ISO8859 family, KOI8, Windows and Mac codepages,
-// but misses other Asian codepages
+// Knows the ISO8859 family, KOI8, Windows, Mac and IBM (also EBCDIC) codepages,
+// and everything in the IANA and WHATWG (https://encoding.spec.whatwg.org/#names-and-labels)
+// registries with their aliases: Shift_JIS, EUC-JP, ISO-2022-JP, GBK, GB18030, Big5, EUC-KR...
 func GetEncoding(name string) encoding.Encoding {
 	name = strings.ToLower(strings.TrimSpace(name))
+	if enc := getEncoding(name); enc != nil {
+		return enc
+	}
+	if enc, err := ianaindex.IANA.Encoding(name); err == nil && enc != nil {
+		if enc == charmap.ISO8859_1 {
+			return ISO8859_1
+		}
+		return enc
+	}
+	if enc, err := htmlindex.Get(name); err == nil {
+		return enc
+	}
+	return nil
+}
+
+// EncodingName returns the canonical (preferred MIME, if exists) name of the encoding,
+// as usable in a Content-Type header's charset parameter.
+// Returns the empty string for unknown encodings.
+func EncodingName(enc encoding.Encoding) string {
+	switch enc {
+	case nil, encoding.Replacement:
+		return "UTF-8"
+	case ISO8859_1:
+		return "ISO-8859-1"
+	}
+	if t, ok := enc.(*byteTable); ok {
+		return t.name
+	}
+	if nm, err := ianaindex.MIME.Name(enc); err == nil && nm != "" {
+		return nm
+	}
+	if nm, err := ianaindex.IANA.Name(enc); err == nil && nm != "" {
+		return nm
+	}
+	if nm, err := htmlindex.Name(enc); err == nil {
+		return nm
+	}
+	return ""
+}
+
+func getEncoding(name string) encoding.Encoding {
 	var err error
 	if strings.HasPrefix(name, "iso") {
 		i := strings.LastIndex(name, "-")
@@ -42,7 +86,7 @@ func GetEncoding(name string) encoding.Encoding {
 			}
 			i = len(name) - 2
 		}
-		if i, err = strconv.Atoi(name[i+1:]); err != nil {
+		if i, err = strconv.Atoi(name[i+1:]); err != nil || !strings.Contains(name, "8859") {
 			return nil
 		}
 		switch i {
@@ -75,7 +119,7 @@ func GetEncoding(name string) encoding.Encoding {
 		}
 		return nil
 	}
-	switch strings.Replace(name, "-", "", -1) {
+	switch strings.NewReplacer("-", "", "_", "").Replace(name) {
 	case "utf8":
 		return encoding.Replacement
 	case "cp437":
@@ -98,7 +142,7 @@ func GetEncoding(name string) encoding.Encoding {
 		return charmap.KOI8U
 	case "mac", "macintosh":
 		return charmap.Macintosh
-	case "macCyrillic", "macintoshCyrillic":
+	case "maccyrillic", "macintoshcyrillic":
 		return charmap.MacintoshCyrillic
 	case "windows1250", "win1250":
 		return charmap.Windows1250
@@ -120,6 +164,20 @@ func GetEncoding(name string) encoding.Encoding {
 		return charmap.Windows1258
 	case "windows874", "win874":
 		return charmap.Windows874
+	case "ibm037", "cp037", "ebcdiccpus":
+		return charmap.CodePage037
+	case "ibm1047", "cp1047":
+		return charmap.CodePage1047
+	case "ibm01140", "ibm1140", "cp1140":
+		return charmap.CodePage1140
+	case "ibm273", "cp273":
+		return IBM273
+	case "ibm01141", "ibm1141", "cp1141":
+		return IBM01141
+	case "ibm500", "cp500", "ebcdiccpbe", "ebcdiccpch":
+		return IBM500
+	case "ibm01148", "ibm1148", "cp1148":
+		return IBM01148
 	}
 	return nil
 }
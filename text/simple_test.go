@@ -20,6 +20,8 @@ import (
 	"bytes"
 	"io"
 	"testing"
+
+	"golang.org/x/text/encoding"
 )
 
 func TestISO8859_1(t *testing.T) {
@@ -57,3 +59,74 @@ func TestISO8859_1(t *testing.T) {
 		}
 	}
 }
+
+func TestGetEncoding(t *testing.T) {
+	for _, tC := range []struct {
+		Name, Canonical, Decoded string
+		Encoded                  []byte
+	}{
+		{"ISO-8859-2", "ISO-8859-2", "árvíztűrő", []byte("\xe1rv\xedzt\xfbr\xf5")},
+		{"iso_8859-2:1987", "ISO-8859-2", "árvíztűrő", []byte("\xe1rv\xedzt\xfbr\xf5")},
+		{"csISOLatin2", "ISO-8859-2", "árvíztűrő", []byte("\xe1rv\xedzt\xfbr\xf5")},
+		{"latin1", "ISO-8859-1", "árvíz", []byte("\xe1rv\xedz")},
+		{"windows_1250", "windows-1250", "árvíztűrő", []byte("\xe1rv\xedzt\xfbr\xf5")},
+		{"IBM852", "IBM852", "árvíztűrő", []byte("\xa0rv\xa1zt\xfbr\x8b")},
+		{"Shift_JIS", "Shift_JIS", "日本", []byte("\x93\xfa\x96{")},
+		{"sjis", "Shift_JIS", "日本", []byte("\x93\xfa\x96{")},
+		{"EUC-JP", "EUC-JP", "日本", []byte("\xc6\xfc\xcb\xdc")},
+		{"ISO-2022-JP", "ISO-2022-JP", "日本", []byte("\x1b$BF|K\\\x1b(B")},
+		{"GBK", "GBK", "中文", []byte("\xd6\xd0\xce\xc4")},
+		{"gb18030", "GB18030", "中文", []byte("\xd6\xd0\xce\xc4")},
+		{"big5", "Big5", "中文", []byte("\xa4\xa4\xa4\xe5")},
+		{"EUC-KR", "EUC-KR", "한국", []byte("\xc7\xd1\xb1\xb9")},
+		{"IBM037", "IBM037", "Hello [1]!", []byte("\xc8\x85\x93\x93\x96@\xba\xf1\xbbZ")},
+		{"ebcdic-cp-us", "IBM037", "Hello", []byte("\xc8\x85\x93\x93\x96")},
+		{"cp1047", "IBM1047", "Hello [1]", []byte("\xc8\x85\x93\x93\x96@\xad\xf1\xbd")},
+		{"IBM01140", "IBM01140", "10€", []byte("\xf1\xf0\x9f")},
+		{"IBM500", "IBM500", "Hello [1]!", []byte("\xc8\x85\x93\x93\x96@\x4a\xf1\x5a\x4f")},
+		{"IBM01148", "IBM01148", "[10€]", []byte("\x4a\xf1\xf0\x9f\x5a")},
+		{"cp273", "IBM273", "Grüße [1]", []byte("\xc7\x99\xd0\xa1\x85@\x63\xf1\xfc")},
+		{"IBM1141", "IBM01141", "Öl 10€", []byte("\xe0\x93@\xf1\xf0\x9f")},
+	} {
+		t.Run(tC.Name, func(t *testing.T) {
+			enc := GetEncoding(tC.Name)
+			if enc == nil {
+				t.Fatal("not found")
+			}
+			if got := EncodingName(enc); got != tC.Canonical {
+				t.Errorf("name: got %q, wanted %q", got, tC.Canonical)
+			}
+			if got, err := Decode(tC.Encoded, enc); err != nil {
+				t.Errorf("decode: %+v", err)
+			} else if got != tC.Decoded {
+				t.Errorf("decode: got %q, wanted %q", got, tC.Decoded)
+			}
+			if got, err := Encode(tC.Decoded, enc); err != nil {
+				t.Errorf("encode: %+v", err)
+			} else if !bytes.Equal(got, tC.Encoded) {
+				t.Errorf("encode: got %q, wanted %q", got, tC.Encoded)
+			}
+		})
+	}
+
+	for _, nm := range []string{"IBM037", "IBM500", "IBM01141"} {
+		enc := GetEncoding(nm)
+		if b, err := Encode("ő", enc); err == nil {
+			t.Errorf("%s: unencodable rune encoded to %q", nm, b)
+		}
+		if b, err := encoding.ReplaceUnsupported(enc.NewEncoder()).String("aő"); err != nil {
+			t.Errorf("%s: replace unsupported: %+v", nm, err)
+		} else if len(b) != 2 {
+			t.Errorf("%s: replace unsupported: got %q", nm, b)
+		}
+	}
+
+	for _, nm := range []string{"", "no-such-charset", "iso-10646-ucs-2x"} {
+		if enc := GetEncoding(nm); enc != nil {
+			t.Errorf("%q: got %v, wanted nil", nm, enc)
+		}
+	}
+	if got := EncodingName(GetEncoding("utf-8")); got != "UTF-8" {
+		t.Errorf("utf-8: got %q", got)
+	}
+}
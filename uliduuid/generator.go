@@ -0,0 +1,126 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package uliduuid
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+)
+
+// OverflowPolicy tells the Generator what to do when the counter
+// runs out within a millisecond.
+type OverflowPolicy uint8
+
+const (
+	// CarryRandom increments the random bits above the counter, too.
+	CarryRandom = OverflowPolicy(iota)
+	// WaitNextTick waits for the next millisecond
+	// (or for the clock to catch up after a backward step).
+	WaitNextTick
+)
+
+// Generator hands out strictly monotonic UUIDv7s and ULIDs:
+// within the same millisecond the IDs are incremented,
+// and a backward step of the clock is ignored.
+//
+// It is safe for concurrent use.
+type Generator struct {
+	// Now returns the current time, time.Now if nil.
+	Now func() time.Time
+	// Entropy is the source of the random bits, crypto/rand if nil.
+	Entropy io.Reader
+
+	uuid, ulid  genState
+	mu          sync.Mutex
+	counterBits int
+	overflow    OverflowPolicy
+}
+
+// genState is the last ID, as two big endian uint64s.
+type genState struct {
+	ms     int64
+	hi, lo uint64
+}
+
+// NewGenerator returns a new Generator, which zeroes the last counterBits (max 62) bits
+// of the random part of the ID in each new millisecond, and increments them for each new ID.
+func NewGenerator(counterBits int, overflow OverflowPolicy) *Generator {
+	if counterBits < 0 || counterBits > 62 {
+		panic("counterBits must be between 0 and 62")
+	}
+	return &Generator{counterBits: counterBits, overflow: overflow}
+}
+
+// The bits after the timestamp which may be random or counter.
+const (
+	uuidHiMask = 0x0fff // after the version
+	uuidLoMask = 1<<62 - 1
+	ulidHiMask = 0xffff
+	ulidLoMask = 1<<64 - 1
+)
+
+// NewUUID returns a new UUIDv7, greater than all the previous ones returned by this Generator.
+func (g *Generator) NewUUID() UUID {
+	hi, lo := g.next(&g.uuid, uuidHiMask, uuidLoMask, 0x7000, 1<<63)
+	var u UUID
+	binary.BigEndian.PutUint64(u.UUID[:8], hi)
+	binary.BigEndian.PutUint64(u.UUID[8:], lo)
+	return u
+}
+
+// NewULID returns a new ULID, greater than all the previous ones returned by this Generator.
+func (g *Generator) NewULID() ULID {
+	hi, lo := g.next(&g.ulid, ulidHiMask, ulidLoMask, 0, 0)
+	var u ULID
+	binary.BigEndian.PutUint64(u.ULID[:8], hi)
+	binary.BigEndian.PutUint64(u.ULID[8:], lo)
+	return u
+}
+
+func (g *Generator) next(st *genState, hiMask, loMask, hiFixed, loFixed uint64) (uint64, uint64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	counterMask := uint64(1)<<g.counterBits - 1
+	for {
+		now := time.Now
+		if g.Now != nil {
+			now = g.Now
+		}
+		ms := now().UnixMilli()
+		if ms > st.ms {
+			var b [16]byte
+			entropy := g.Entropy
+			if entropy == nil {
+				entropy = rand.Reader
+			}
+			if _, err := io.ReadFull(entropy, b[:]); err != nil {
+				panic(err)
+			}
+			st.ms = ms
+			st.hi = uint64(ms)<<16 | hiFixed | binary.BigEndian.Uint64(b[:8])&hiMask
+			st.lo = loFixed | binary.BigEndian.Uint64(b[8:])&loMask&^counterMask
+			return st.hi, st.lo
+		}
+
+		// The same (or an earlier) millisecond: increment.
+		if !(g.overflow == WaitNextTick && st.lo&counterMask == counterMask) {
+			lo := (st.lo | ^loMask) + 1
+			hi, carry := st.hi, lo == 0
+			if carry {
+				hi = (st.hi | ^hiMask) + 1
+				carry = hi == 0
+			}
+			if !carry { // not exhausted
+				st.lo = lo&loMask | st.lo&^loMask
+				st.hi = hi&hiMask | st.hi&^hiMask
+				return st.hi, st.lo
+			}
+		}
+		time.Sleep(time.Duration(st.ms+1-ms) * time.Millisecond)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package uliduuid_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tgulacsi/go/uliduuid"
+)
+
+func TestGeneratorMonotonic(t *testing.T) {
+	start := time.Now()
+	frozen := func() time.Time { return start }
+	for _, tC := range []struct {
+		Name        string
+		Now         func() time.Time
+		CounterBits int
+	}{
+		{"realtime", nil, 8},
+		{"frozen", frozen, 4},
+		{"frozen-nocounter", frozen, 0},
+	} {
+		t.Run(tC.Name, func(t *testing.T) {
+			g := uliduuid.NewGenerator(tC.CounterBits, uliduuid.CarryRandom)
+			g.Now = tC.Now
+			const goroutines, n = 8, 1000
+			uuids := make([][16]byte, 0, goroutines*n)
+			ulids := make([][16]byte, 0, goroutines*n)
+			var mu sync.Mutex
+			var wg sync.WaitGroup
+			for range goroutines {
+				wg.Go(func() {
+					for range n {
+						mu.Lock()
+						uu, ul := g.NewUUID(), g.NewULID()
+						uuids = append(uuids, uu.UUID)
+						ulids = append(ulids, ul.ULID)
+						mu.Unlock()
+					}
+				})
+			}
+			wg.Wait()
+			for i, ids := range [][][16]byte{uuids, ulids} {
+				if !slices.IsSortedFunc(ids, func(a, b [16]byte) int { return bytes.Compare(a[:], b[:]) }) {
+					t.Errorf("%d. not sorted", i)
+				}
+				if len(slices.Compact(slices.Clone(ids))) != len(ids) {
+					t.Errorf("%d. duplicates", i)
+				}
+			}
+			for _, u := range uuids {
+				if u[6]>>4 != 7 || u[8]>>6 != 2 {
+					t.Fatalf("bad version/variant: %x", u)
+				}
+			}
+			if tC.Now != nil {
+				if got := binary.BigEndian.Uint64(ulids[len(ulids)-1][:8]) >> 16; got != uint64(start.UnixMilli()) {
+					t.Errorf("timestamp changed: got %d, wanted %d", got, start.UnixMilli())
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratorWait(t *testing.T) {
+	start := time.Now()
+	var calls atomic.Int64
+	g := uliduuid.NewGenerator(2, uliduuid.WaitNextTick)
+	// the clock advances a millisecond at every 10th call
+	g.Now = func() time.Time { return start.Add(time.Duration(calls.Add(1)/10) * time.Millisecond) }
+	perMs := make(map[uint64]int)
+	var prev uliduuid.UUID
+	for i := range 100 {
+		u := g.NewUUID()
+		if i != 0 && bytes.Compare(prev.UUID[:], u.UUID[:]) >= 0 {
+			t.Fatalf("%d. %s <= %s", i, u, prev)
+		}
+		prev = u
+		perMs[binary.BigEndian.Uint64(u.UUID[:8])>>16]++
+	}
+	for ms, n := range perMs {
+		if n > 4 {
+			t.Errorf("%d: %d IDs in a millisecond with 2 counter bits", ms, n)
+		}
+	}
+}
+
+func TestIDForms(t *testing.T) {
+	g := uliduuid.NewGenerator(8, uliduuid.CarryRandom)
+	for _, id := range []uliduuid.ID{uliduuid.New(g.NewUUID()), uliduuid.New(g.NewULID())} {
+		for _, s := range []string{id.String(), id.Crockford(), id.Base64URL()} {
+			var got uliduuid.ID
+			if err := got.UnmarshalText([]byte(s)); err != nil {
+				t.Fatalf("%q: %+v", s, err)
+			}
+			if got.UUID() != id.UUID() {
+				t.Errorf("%q: got %s, wanted %s", s, got, id)
+			}
+		}
+		if len(id.Base64URL()) != 22 || len(id.Crockford()) != 26 {
+			t.Errorf("bad lengths: %q, %q", id.Base64URL(), id.Crockford())
+		}
+
+		v, err := id.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := id.MarshalBinary()
+		for _, src := range []any{v, []byte(v.(string)), b} {
+			var got uliduuid.ID
+			if err := got.Scan(src); err != nil {
+				t.Fatalf("Scan(%v): %+v", src, err)
+			}
+			if got.UUID() != id.UUID() {
+				t.Errorf("Scan(%v): got %s, wanted %s", src, got, id)
+			}
+		}
+	}
+	var id uliduuid.ID
+	if err := id.Scan(nil); err != nil || !id.IsZero() {
+		t.Errorf("Scan(nil): %v, %+v", id, err)
+	}
+	if v, err := id.Value(); v != nil || err != nil {
+		t.Errorf("zero Value: %v, %+v", v, err)
+	}
+}
@@ -5,7 +5,11 @@
 package uliduuid
 
 import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
 	"encoding"
+	"encoding/base32"
 	"encoding/base64"
 	"fmt"
 	"time"
@@ -202,8 +206,53 @@ var (
 	_ encoding.BinaryUnmarshaler = (*ID)(nil)
 	_ encoding.TextMarshaler     = ID{}
 	_ encoding.TextUnmarshaler   = (*ID)(nil)
+	_ sql.Scanner                = (*ID)(nil)
+	_ driver.Valuer              = ID{}
 )
 
+// Crockford returns the ID in Crockford's base32 - the ULID string form.
+func (t ID) Crockford() string {
+	b, _ := t.MarshalBinary()
+	if len(b) == 16 {
+		return ulid.ULID(b).String()
+	}
+	return crockford.EncodeToString(b)
+}
+
+// Base64URL returns the ID in the unpadded base64url form (22 characters for UUID/ULID).
+func (t ID) Base64URL() string {
+	b, _ := t.MarshalBinary()
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+var crockford = base32.NewEncoding("0123456789ABCDEFGHJKMNPQRSTVWXYZ").WithPadding(base32.NoPadding)
+
+// Scan implements sql.Scanner: accepts the text forms, and 16 bytes as binary.
+func (t *ID) Scan(src any) error {
+	switch x := src.(type) {
+	case nil:
+		*t = ID{}
+		return nil
+	case string:
+		return t.UnmarshalText([]byte(x))
+	case []byte:
+		if len(x) == 16 {
+			return t.UnmarshalBinary(bytes.Clone(x))
+		}
+		return t.UnmarshalText(bytes.Clone(x))
+	}
+	return fmt.Errorf("scan %T into ID", src)
+}
+
+// Value implements driver.Valuer, returns the text form (NULL for the zero ID).
+func (t ID) Value() (driver.Value, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+	b, err := t.MarshalText()
+	return string(b), err
+}
+
 func (t ID) MarshalText() ([]byte, error) {
 	if t.uuid != nil {
 		return t.uuid.MarshalText()
@@ -222,6 +271,11 @@ func (t ID) MarshalBinary() ([]byte, error) {
 }
 
 func (t *ID) UnmarshalText(p []byte) error {
+	if len(p) == 22 { // base64url
+		if b, err := base64.RawURLEncoding.DecodeString(string(p)); err == nil {
+			return t.UnmarshalBinary(b)
+		}
+	}
 	if len(p) == ulid.EncodedSize {
 		if id, err := ulid.Parse(string(p)); err == nil {
 			t.uuid, t.ulid, t.other = nil, &ULID{id}, nil
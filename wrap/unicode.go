@@ -0,0 +1,260 @@
+package wrap
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// Options of the Unicode-aware wrapping.
+type Options struct {
+	// Prefix is written at the start of each line (e.g. "> " for quoting).
+	// Hanging is written after Prefix on the continuation lines of a paragraph (hanging indent).
+	Prefix, Hanging string
+	// Width is the maximal display width of a line, including Prefix and Hanging,
+	// in monospace cells (East Asian wide characters and emojis count as 2).
+	// No wrapping happens if Width <= 0.
+	Width int
+	// Hyphenate the words longer than the available width when breaking them.
+	// Soft hyphens (U+00AD) are always honored.
+	Hyphenate bool
+	// Reflow joins the consecutive non-empty lines into one paragraph.
+	// Paragraphs are separated by empty lines.
+	// Without Reflow every input line is a separate paragraph.
+	Reflow bool
+}
+
+// Text wraps s according to the Unicode line breaking rules (UAX #14),
+// counting the display width of the text.
+//
+// Hard line breaks (CR, LF, CRLF, NEL, LS, PS) are preserved as \n,
+// leading whitespace of a paragraph is kept, trailing whitespace of the lines is removed.
+func Text(s string, opts Options) string {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, opts)
+	io.WriteString(w, s)
+	w.Close()
+	return buf.String()
+}
+
+// NewReader returns an io.Reader which reads the wrapped text of r.
+func NewReader(r io.Reader, opts Options) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		w := NewWriter(pw, opts)
+		_, err := io.Copy(w, r)
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// NewWriter returns an io.WriteCloser which writes the wrapped text to w.
+// The text is processed line by line, so Close must be called to write the last,
+// not newline-terminated line.
+func NewWriter(w io.Writer, opts Options) io.WriteCloser {
+	return &writer{w: w, Options: opts}
+}
+
+type writer struct {
+	w        io.Writer
+	err      error
+	buf, out []byte
+	// para is the paragraph collected in Reflow mode.
+	para strings.Builder
+	// paraNL is true if the last line of para was newline-terminated.
+	paraNL bool
+	Options
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.buf = append(w.buf, p...)
+	i := bytes.LastIndexByte(w.buf, '\n')
+	if i < 0 {
+		return len(p), nil
+	}
+	w.out = w.out[:0]
+	for _, line := range strings.SplitAfter(string(w.buf[:i+1]), "\n") {
+		if line != "" {
+			w.line(line)
+		}
+	}
+	w.buf = append(w.buf[:0], w.buf[i+1:]...)
+	if _, w.err = w.w.Write(w.out); w.err != nil {
+		return len(p), w.err
+	}
+	return len(p), nil
+}
+
+// Close writes the rest of the text.
+func (w *writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.out = w.out[:0]
+	if len(w.buf) != 0 {
+		w.line(string(w.buf))
+		w.buf = w.buf[:0]
+	}
+	if w.para.Len() != 0 {
+		w.out = w.appendParagraph(w.out, w.para.String())
+		if w.paraNL {
+			w.out = append(w.out, '\n')
+		}
+		w.para.Reset()
+	}
+	_, w.err = w.w.Write(w.out)
+	if w.err == nil {
+		w.err = io.ErrClosedPipe
+		return nil
+	}
+	return w.err
+}
+
+// line processes one line (with or without the terminating newline).
+func (w *writer) line(line string) {
+	if !w.Reflow {
+		w.out = w.appendParagraph(w.out, line)
+		return
+	}
+	text := strings.TrimRight(line, "\r\n")
+	if strings.TrimSpace(text) != "" {
+		if w.para.Len() != 0 {
+			w.para.WriteByte(' ')
+		}
+		w.para.WriteString(text)
+		w.paraNL = len(text) != len(line)
+		return
+	}
+	if w.para.Len() != 0 {
+		w.out = append(w.appendParagraph(w.out, w.para.String()), '\n')
+		w.para.Reset()
+	}
+	w.out = w.appendParagraph(w.out, line)
+}
+
+// appendParagraph appends the wrapped s to dst.
+func (o Options) appendParagraph(dst []byte, s string) []byte {
+	lw := lineWriter{Options: o, dst: dst}
+	state := -1
+	for len(s) != 0 {
+		var seg string
+		var mustBreak bool
+		seg, s, mustBreak, state = uniseg.FirstLineSegmentInString(s, state)
+		body := strings.TrimRight(seg, "\r\n\v\f\u0085\u2028\u2029")
+		word := strings.TrimRight(body, " \t")
+		lw.add(word, body[len(word):])
+		if mustBreak && len(body) != len(seg) {
+			lw.endLine()
+			lw.line = 0
+		}
+	}
+	return lw.dst
+}
+
+// lineWriter wraps one paragraph.
+type lineWriter struct {
+	dst []byte
+	// pending is the whitespace after the last word, written only if another word follows on the same line.
+	pending string
+	Options
+	// line is the number of the line in the paragraph, width is the display width of the current line.
+	line, width int
+	// started is true if the prefix of the current line is written.
+	started bool
+	// shy is true if the current line ends with a soft hyphen.
+	shy bool
+}
+
+// avail returns the width available for the text on the current line.
+func (lw *lineWriter) avail() int {
+	if lw.Width <= 0 {
+		return int(^uint(0) >> 1)
+	}
+	n := lw.Width - uniseg.StringWidth(lw.Prefix)
+	if lw.line != 0 {
+		n -= uniseg.StringWidth(lw.Hanging)
+	}
+	return max(1, n)
+}
+
+func (lw *lineWriter) write(s string, width int) {
+	if !lw.started {
+		lw.dst = append(lw.dst, lw.Prefix...)
+		if lw.line != 0 {
+			lw.dst = append(lw.dst, lw.Hanging...)
+		}
+		lw.started = true
+	}
+	lw.dst = append(lw.dst, s...)
+	lw.width += width
+}
+
+// endLine terminates the current line.
+func (lw *lineWriter) endLine() {
+	if !lw.started {
+		lw.dst = append(lw.dst, strings.TrimRight(lw.Prefix, " \t")...)
+	} else if lw.shy {
+		lw.dst = append(lw.dst, '-')
+	}
+	lw.dst = append(lw.dst, '\n')
+	lw.line++
+	lw.width, lw.pending, lw.started, lw.shy = 0, "", false, false
+}
+
+// add the word (an unbreakable segment) and the whitespace following it.
+func (lw *lineWriter) add(word, space string) {
+	if word != "" {
+		shy := strings.HasSuffix(word, "\u00ad")
+		word = strings.ReplaceAll(word, "\u00ad", "")
+		width := uniseg.StringWidth(word)
+		need := width
+		if shy {
+			need++ // for the hyphen, if the line is broken after this word
+		}
+		if lw.started && lw.width+len(lw.pending)+need > lw.avail() {
+			lw.endLine()
+		}
+		if lw.width+len(lw.pending)+need > lw.avail() {
+			lw.breakWord(word)
+		} else {
+			lw.write(lw.pending, len(lw.pending))
+			lw.write(word, width)
+		}
+		lw.pending, lw.shy = "", shy
+	}
+	// Only the leading whitespace of the paragraph is kept at the start of a line.
+	if lw.started || lw.line == 0 {
+		lw.pending += space
+	}
+}
+
+// breakWord writes the word which is longer than the available width, breaking it between grapheme clusters.
+func (lw *lineWriter) breakWord(word string) {
+	lw.write(lw.pending, len(lw.pending))
+	lw.pending = ""
+	state := -1
+	// wrote is true if a grapheme of the word is written, so a hyphen does not end a whitespace-only line.
+	var wrote bool
+	for word != "" {
+		cluster, rest, width, newState := uniseg.FirstGraphemeClusterInString(word, state)
+		need := width
+		if lw.Hyphenate && rest != "" {
+			need++
+		}
+		if lw.width != 0 && lw.width+need > lw.avail() {
+			lw.shy = lw.Hyphenate && wrote
+			lw.endLine()
+		}
+		lw.write(cluster, width)
+		wrote = true
+		word, state = rest, newState
+	}
+}
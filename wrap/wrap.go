@@ -1,8 +1,11 @@
-// Package wrap handles simple text wrapping to a fixed number of columns.
+// Package wrap handles text wrapping to a fixed number of columns.
+//
+// String is the simple, byte-counting, space-breaking wrapper;
+// Text, NewWriter and NewReader implement the Unicode line breaking rules (UAX #14)
+// with display width (East Asian wide characters, emojis) counting.
 package wrap
 
 import (
-	"bufio"
 	"bytes"
 	"io"
 )
@@ -12,7 +15,7 @@ import (
 // carriage returns in the input will be preserved, which means that if you
 // pass in text containing several paragraphs you'll get one giant paragraph
 // back. If you wish to avoid that, then split your text into paragraphs and
-// call wrap.String individually on each paragraph.
+// call wrap.String individually on each paragraph - or use Text.
 func String(s string, numCols uint) string {
 	var (
 		ob  bytes.Buffer // "output buffer" (for storing the finished output)
@@ -91,48 +94,9 @@ func String(s string, numCols uint) string {
 	return ob.String()
 }
 
-// WrappingReader returns an io.Reader which will wrap lines longer than the given width.
+// WrappingReader returns an io.Reader which will wrap lines wider than the given width,
+// according to the Unicode line breaking rules.
 // All other lines (LF chars) will be preserved.
 func WrappingReader(r io.Reader, width uint) io.Reader {
-	pr, pw := io.Pipe()
-
-	go func() {
-		scanner := bufio.NewScanner(r)
-		ew := &errWriter{Writer: pw}
-		for scanner.Scan() { // split lines
-			if uint(len(scanner.Bytes())) <= width {
-				ew.Write(scanner.Bytes())
-				if _, err := ew.Write([]byte{'\n'}); err != nil {
-					break
-				}
-				continue
-			}
-			io.WriteString(ew, String(scanner.Text(), width))
-			if _, err := ew.Write([]byte{'\n'}); err != nil {
-				break
-			}
-		}
-		err := scanner.Err()
-		if err == nil {
-			err = ew.Err()
-		}
-		pw.CloseWithError(err)
-	}()
-
-	return pr
-}
-
-type errWriter struct {
-	io.Writer
-	err error
-}
-
-func (w *errWriter) Write(p []byte) (int, error) {
-	if w.err != nil {
-		return 0, w.err
-	}
-	var n int
-	n, w.err = w.Writer.Write(p)
-	return n, w.err
+	return NewReader(r, Options{Width: int(width)})
 }
-func (w *errWriter) Err() error { return w.err }
@@ -1,8 +1,10 @@
 package wrap
 
 import (
+	"io"
 	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 type T struct {
@@ -118,3 +120,53 @@ func TestString(t *testing.T) {
 		}
 	}
 }
+
+func TestText(t *testing.T) {
+	for _, tC := range []struct {
+		Name, In, Out string
+		Options
+	}{
+		{"nowrap", "árvíztűrő tükörfúrógép", "árvíztűrő tükörfúrógép", Options{}},
+		{"accents", "árvíztűrő tükörfúrógép", "árvíztűrő\ntükörfúrógép", Options{Width: 15}},
+		{"fits", "árvíztűrő tükörfúrógép", "árvíztűrő tükörfúrógép", Options{Width: 22}},
+		{"paragraphs", "egy kettő három\n\nnégy öt\r\nhat hét nyolc",
+			"egy kettő\nhárom\n\nnégy öt\nhat hét\nnyolc", Options{Width: 10}},
+		{"reflow", "egy kettő\nhárom négy\n\n\nöt hat\nhét", "egy kettő három\nnégy\n\n\nöt hat hét", Options{Width: 16, Reflow: true}},
+		{"reflow-newline", "a\nb\n", "a b\n", Options{Width: 10, Reflow: true}},
+		{"indent", "  first line is indented", "  first line\nis indented", Options{Width: 12}},
+		{"spaces", "a  b   c    d", "a  b   c\nd", Options{Width: 9}},
+		{"cjk", "日本語のテキストです", "日本語の\nテキスト\nです", Options{Width: 9}},
+		{"cjk-punct", "こんにちは、世界。", "こんにちは、\n世界。", Options{Width: 12}},
+		{"emoji", "ok 👍👍👍 yes", "ok 👍\n👍👍\nyes", Options{Width: 5}},
+		{"prefix", "Lorem ipsum dolor sit amet", "> Lorem\n>   ipsum\n>   dolor\n>   sit\n>   amet", Options{Width: 10, Prefix: "> ", Hanging: "  "}},
+		{"prefix-empty", "a\n\nb", "> a\n>\n> b", Options{Width: 10, Prefix: "> "}},
+		{"long", "x abcdefghij y", "x\nabcd\nefgh\nij y", Options{Width: 4}},
+		{"hyphenate", "x abcdefghij y", "x\nabc-\ndef-\nghij\ny", Options{Width: 4, Hyphenate: true}},
+		{"hyphenate-indent", "\t\tx y", "> \t\t\n> x\n> y", Options{Width: 3, Prefix: "> ", Hyphenate: true}},
+		{"shy", "gép­jár­mű biz­to­sí­tás", "gépjármű\nbiztosí-\ntás", Options{Width: 8}},
+		{"hyphen", "state-of-the-art", "state-\nof-the-\nart", Options{Width: 8}},
+		{"url", "see https://example.com/a/b", "see\nhttps://\nexample.\ncom/a/b", Options{Width: 8}},
+	} {
+		t.Run(tC.Name, func(t *testing.T) {
+			if got := Text(tC.In, tC.Options); got != tC.Out {
+				t.Errorf("got %q, wanted %q", got, tC.Out)
+			}
+		})
+	}
+}
+
+func TestWrappingReader(t *testing.T) {
+	const in = "short line\nthis is a long line with árvíztűrő words\n\n日本語のテキストです\n"
+	want := Text(in, Options{Width: 12})
+	// feed it byte by byte to check the streaming
+	b, err := io.ReadAll(WrappingReader(iotest.OneByteReader(strings.NewReader(in)), 12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+	if want != "short line\nthis is a\nlong line\nwith\nárvíztűrő\nwords\n\n日本語のテキ\nストです\n" {
+		t.Errorf("Text: got %q", want)
+	}
+}